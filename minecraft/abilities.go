@@ -0,0 +1,150 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// Abilities holds the abilities of the player of a Conn, such as whether it may fly or build, as sent by the
+// server in an UpdateAbilities packet. Servers on versions older than v1.19.10 send an AdventureSettings packet
+// instead, which is converted into a single base layer.
+type Abilities struct {
+	// PlayerPermissions is the permission level of the player as it shows up in the player list. It is one of
+	// the packet.PermissionLevel constants.
+	PlayerPermissions byte
+	// CommandPermissions is the permission level that specifies what commands the player is allowed to
+	// execute. It is one of the packet.CommandPermissionLevel constants.
+	CommandPermissions byte
+	// Layers holds the ability layers of the player, such as the base layer and the spectator layer.
+	Layers []protocol.AbilityLayer
+}
+
+// Enabled checks if the ability passed, which is one of the protocol.Ability constants, is enabled. Layers may
+// override abilities set in layers before them, so the value of the last layer that sets the ability is used.
+// False is returned if no layer sets the ability.
+func (a Abilities) Enabled(ability uint32) bool {
+	enabled := false
+	for _, layer := range a.Layers {
+		if layer.Abilities&ability != 0 {
+			enabled = layer.Values&ability != 0
+		}
+	}
+	return enabled
+}
+
+// FlySpeed returns the fly speed of the base layer of the player, or protocol.AbilityBaseFlySpeed if the
+// abilities do not have a base layer.
+func (a Abilities) FlySpeed() float32 {
+	for _, layer := range a.Layers {
+		if layer.Type == protocol.AbilityLayerTypeBase {
+			return layer.FlySpeed
+		}
+	}
+	return protocol.AbilityBaseFlySpeed
+}
+
+// WalkSpeed returns the walk speed of the base layer of the player, or protocol.AbilityBaseWalkSpeed if the
+// abilities do not have a base layer.
+func (a Abilities) WalkSpeed() float32 {
+	for _, layer := range a.Layers {
+		if layer.Type == protocol.AbilityLayerTypeBase {
+			return layer.WalkSpeed
+		}
+	}
+	return protocol.AbilityBaseWalkSpeed
+}
+
+// legacyAbilities maps flags of the AdventureSettings packet to the abilities they represent.
+var legacyAbilities = []struct {
+	flag, actionPermission, ability uint32
+}{
+	{flag: packet.AdventureFlagAllowFlight, ability: protocol.AbilityMayFly},
+	{flag: packet.AdventureFlagNoClip, ability: protocol.AbilityNoClip},
+	{flag: packet.AdventureFlagWorldBuilder, ability: protocol.AbilityWorldBuilder},
+	{flag: packet.AdventureFlagFlying, ability: protocol.AbilityFlying},
+	{flag: packet.AdventureFlagMuted, ability: protocol.AbilityMuted},
+	{actionPermission: packet.ActionPermissionMine, ability: protocol.AbilityMine},
+	{actionPermission: packet.ActionPermissionDoorsAndSwitches, ability: protocol.AbilityDoorsAndSwitches},
+	{actionPermission: packet.ActionPermissionOpenContainers, ability: protocol.AbilityOpenContainers},
+	{actionPermission: packet.ActionPermissionAttackPlayers, ability: protocol.AbilityAttackPlayers},
+	{actionPermission: packet.ActionPermissionAttackMobs, ability: protocol.AbilityAttackMobs},
+	{actionPermission: packet.ActionPermissionOperator, ability: protocol.AbilityOperatorCommands},
+	{actionPermission: packet.ActionPermissionTeleport, ability: protocol.AbilityTeleport},
+	{actionPermission: packet.ActionPermissionBuild, ability: protocol.AbilityBuild},
+}
+
+// abilitiesFromAdventureSettings converts the legacy AdventureSettings packet passed into Abilities with a
+// single base layer.
+func abilitiesFromAdventureSettings(pk *packet.AdventureSettings) Abilities {
+	layer := protocol.AbilityLayer{
+		Type:      protocol.AbilityLayerTypeBase,
+		FlySpeed:  protocol.AbilityBaseFlySpeed,
+		WalkSpeed: protocol.AbilityBaseWalkSpeed,
+	}
+	for _, a := range legacyAbilities {
+		layer.Abilities |= a.ability
+		if pk.Flags&a.flag != 0 || pk.ActionPermissions&a.actionPermission != 0 {
+			layer.Values |= a.ability
+		}
+	}
+	return Abilities{
+		PlayerPermissions:  byte(pk.PermissionLevel),
+		CommandPermissions: byte(pk.CommandPermissionLevel),
+		Layers:             []protocol.AbilityLayer{layer},
+	}
+}
+
+// abilityState holds the last Abilities sent by the server.
+type abilityState struct {
+	mu        sync.Mutex
+	abilities Abilities
+	received  bool
+}
+
+// Abilities returns the abilities of the player of the Conn as last sent by the server in an UpdateAbilities or
+// AdventureSettings packet. False is returned if the server has not yet sent either packet.
+func (conn *Conn) Abilities() (Abilities, bool) {
+	conn.abilities.mu.Lock()
+	defer conn.abilities.mu.Unlock()
+	a := conn.abilities.abilities
+	a.Layers = append([]protocol.AbilityLayer(nil), a.Layers...)
+	return a, conn.abilities.received
+}
+
+// RequestAbility requests the server to change the value of an ability of the player by sending a
+// RequestAbility packet, for example to start flying on servers with server authoritative movement. The
+// ability is one of the packet.Ability constants, which, unlike the protocol.Ability constants, are not bit
+// flags. The value must be either a bool or a float32.
+// If the server accepts the request, it sends an UpdateAbilities packet with the new abilities.
+func (conn *Conn) RequestAbility(ability int32, value any) error {
+	if ability < 0 || ability >= packet.AbilityCount {
+		return conn.wrap(fmt.Errorf("unknown ability %v", ability), "request ability")
+	}
+	switch value.(type) {
+	case bool, float32:
+	default:
+		return conn.wrap(fmt.Errorf("ability value must be bool or float32, got %T", value), "request ability")
+	}
+	return conn.WritePacket(&packet.RequestAbility{Ability: ability, Value: value})
+}
+
+// handleUpdateAbilities stores the abilities held in the UpdateAbilities packet passed.
+func (conn *Conn) handleUpdateAbilities(pk *packet.UpdateAbilities) {
+	conn.abilities.mu.Lock()
+	defer conn.abilities.mu.Unlock()
+	conn.abilities.abilities = Abilities{
+		PlayerPermissions:  pk.AbilityData.PlayerPermissions,
+		CommandPermissions: pk.AbilityData.CommandPermissions,
+		Layers:             pk.AbilityData.Layers,
+	}
+	conn.abilities.received = true
+}
+
+// handleAdventureSettings stores the abilities held in the legacy AdventureSettings packet passed.
+func (conn *Conn) handleAdventureSettings(pk *packet.AdventureSettings) {
+	conn.abilities.mu.Lock()
+	defer conn.abilities.mu.Unlock()
+	conn.abilities.abilities, conn.abilities.received = abilitiesFromAdventureSettings(pk), true
+}
@@ -0,0 +1,52 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// attributeState tracks the attributes of the player of a Conn, such as its health and hunger, as sent by the
+// server in UpdateAttributes packets.
+type attributeState struct {
+	mu         sync.Mutex
+	attributes map[string]protocol.Attribute
+}
+
+// Attribute returns the attribute of the player of the Conn with the name passed, such as 'minecraft:health'
+// or 'minecraft:player.hunger', holding its current, minimum, maximum and default values. If the server has
+// not sent the attribute, false is returned.
+func (conn *Conn) Attribute(name string) (protocol.Attribute, bool) {
+	conn.attributes.mu.Lock()
+	defer conn.attributes.mu.Unlock()
+	attr, ok := conn.attributes.attributes[name]
+	return attr, ok
+}
+
+// Attributes returns all attributes of the player of the Conn sent by the server so far, keyed by their
+// name. The map returned is a copy and may be modified freely.
+func (conn *Conn) Attributes() map[string]protocol.Attribute {
+	conn.attributes.mu.Lock()
+	defer conn.attributes.mu.Unlock()
+	m := make(map[string]protocol.Attribute, len(conn.attributes.attributes))
+	for name, attr := range conn.attributes.attributes {
+		m[name] = attr
+	}
+	return m
+}
+
+// handleUpdateAttributes stores the attributes held in the UpdateAttributes packet passed if they were sent
+// for the entity with the runtime ID passed. Attributes not present in the packet keep their previous values.
+func (s *attributeState) handleUpdateAttributes(pk *packet.UpdateAttributes, entityRuntimeID uint64) {
+	if pk.EntityRuntimeID != entityRuntimeID {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]protocol.Attribute, len(pk.Attributes))
+	}
+	for _, attr := range pk.Attributes {
+		s.attributes[attr.Name] = attr
+	}
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"context"
+	"golang.org/x/oauth2"
+	"net/http"
+)
+
+// WithHTTPClient returns a copy of the context passed that makes the request functions of the auth package,
+// such as RequestXBLToken and RequestMinecraftChain, send their requests using the *http.Client passed. This
+// may be used to send requests through a proxy or to set custom timeouts. The client is stored under the
+// oauth2.HTTPClient key, so that golang.org/x/oauth2 uses it too.
+// If no client is set, http.DefaultClient is used.
+func WithHTTPClient(ctx context.Context, c *http.Client) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, c)
+}
+
+// httpClient returns the *http.Client set in the context passed using WithHTTPClient, or def if no client
+// was set.
+func httpClient(ctx context.Context, def *http.Client) *http.Client {
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && c != nil {
+		return c
+	}
+	return def
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"golang.org/x/oauth2"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -27,18 +29,24 @@ func WriterTokenSource(w io.Writer) oauth2.TokenSource {
 // tokenSource implements the oauth2.TokenSource interface. It provides a method to get an oauth2.Token using
 // device auth through a call to RequestLiveToken.
 type tokenSource struct {
-	w io.Writer
-	t *oauth2.Token
+	// ctx is the context used for the requests made, or nil to use context.Background().
+	ctx context.Context
+	w   io.Writer
+	t   *oauth2.Token
 }
 
 // Token attempts to return a Live Connect token using the RequestLiveToken function.
 func (src *tokenSource) Token() (*oauth2.Token, error) {
+	ctx := src.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if src.t == nil {
-		t, err := RequestLiveTokenWriter(src.w)
+		t, err := RequestLiveTokenContext(ctx, src.w)
 		src.t = t
 		return t, err
 	}
-	tok, err := refreshToken(src.t)
+	tok, err := refreshToken(ctx, src.t)
 	if err != nil {
 		return nil, err
 	}
@@ -62,6 +70,15 @@ func RefreshTokenSourceWriter(t *oauth2.Token, w io.Writer) oauth2.TokenSource {
 	return oauth2.ReuseTokenSource(t, &tokenSource{w: w, t: t})
 }
 
+// ContextTokenSource returns a new oauth2.TokenSource that, like RefreshTokenSourceWriter, refreshes the
+// oauth2.Token passed every time it expires. If t is nil, a token is first requested using device auth, of
+// which the URL and code are printed to the io.Writer passed. All requests made by the oauth2.TokenSource use
+// the context passed, so that they are cancelled once it is done and are sent using the *http.Client set using
+// WithHTTPClient, if any.
+func ContextTokenSource(ctx context.Context, t *oauth2.Token, w io.Writer) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(t, &tokenSource{ctx: ctx, w: w, t: t})
+}
+
 // RequestLiveToken does a login request for Microsoft Live Connect using device auth. A login URL will be
 // printed to the stdout with a user code which the user must use to submit.
 // RequestLiveToken is the equivalent of RequestLiveTokenWriter(os.Stdout).
@@ -73,7 +90,15 @@ func RequestLiveToken() (*oauth2.Token, error) {
 // be printed to the io.Writer passed with a user code which the user must use to submit.
 // Once fully authenticated, an oauth2 token is returned which may be used to login to XBOX Live.
 func RequestLiveTokenWriter(w io.Writer) (*oauth2.Token, error) {
-	d, err := startDeviceAuth()
+	return RequestLiveTokenContext(context.Background(), w)
+}
+
+// RequestLiveTokenContext does a login request for Microsoft Live Connect using device auth, like
+// RequestLiveTokenWriter. Unlike RequestLiveTokenWriter, it stops waiting for the user to authenticate and
+// returns an error once the context passed is done. The requests made are sent using the *http.Client set in
+// the context using WithHTTPClient, or http.DefaultClient if none is set.
+func RequestLiveTokenContext(ctx context.Context, w io.Writer) (*oauth2.Token, error) {
+	d, err := startDeviceAuth(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -81,8 +106,13 @@ func RequestLiveTokenWriter(w io.Writer) (*oauth2.Token, error) {
 	ticker := time.NewTicker(time.Second * time.Duration(d.Interval))
 	defer ticker.Stop()
 
-	for range ticker.C {
-		t, err := pollDeviceAuth(d.DeviceCode)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error waiting for device auth: %w", ctx.Err())
+		case <-ticker.C:
+		}
+		t, err := pollDeviceAuth(ctx, d.DeviceCode)
 		if err != nil {
 			return nil, fmt.Errorf("error polling for device auth: %w", err)
 		}
@@ -93,13 +123,12 @@ func RequestLiveTokenWriter(w io.Writer) (*oauth2.Token, error) {
 			return t, nil
 		}
 	}
-	panic("unreachable")
 }
 
 // startDeviceAuth starts the device auth, retrieving a login URI for the user and a code the user needs to
 // enter.
-func startDeviceAuth() (*deviceAuthConnect, error) {
-	resp, err := http.PostForm("https://login.live.com/oauth20_connect.srf", url.Values{
+func startDeviceAuth(ctx context.Context) (*deviceAuthConnect, error) {
+	resp, err := postForm(ctx, "https://login.live.com/oauth20_connect.srf", url.Values{
 		"client_id":     {"0000000048183522"},
 		"scope":         {"service::user.auth.xboxlive.com::MBI_SSL"},
 		"response_type": {"device_code"},
@@ -119,8 +148,8 @@ func startDeviceAuth() (*deviceAuthConnect, error) {
 
 // pollDeviceAuth polls the token endpoint for the device code. A token is returned if the user authenticated
 // successfully. If the user has not yet authenticated, err is nil but the token is nil too.
-func pollDeviceAuth(deviceCode string) (t *oauth2.Token, err error) {
-	resp, err := http.PostForm(microsoft.LiveConnectEndpoint.TokenURL, url.Values{
+func pollDeviceAuth(ctx context.Context, deviceCode string) (t *oauth2.Token, err error) {
+	resp, err := postForm(ctx, microsoft.LiveConnectEndpoint.TokenURL, url.Values{
 		"client_id":   {"0000000048183522"},
 		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
 		"device_code": {deviceCode},
@@ -148,10 +177,10 @@ func pollDeviceAuth(deviceCode string) (t *oauth2.Token, err error) {
 
 // refreshToken refreshes the oauth2.Token passed and returns a new oauth2.Token. An error is returned if
 // refreshing was not successful.
-func refreshToken(t *oauth2.Token) (*oauth2.Token, error) {
+func refreshToken(ctx context.Context, t *oauth2.Token) (*oauth2.Token, error) {
 	// This function unfortunately needs to exist because golang.org/x/oauth2 does not pass the scope to this
 	// request, which Microsoft Connect enforces.
-	resp, err := http.PostForm(microsoft.LiveConnectEndpoint.TokenURL, url.Values{
+	resp, err := postForm(ctx, microsoft.LiveConnectEndpoint.TokenURL, url.Values{
 		"client_id":     {"0000000048183522"},
 		"scope":         {"service::user.auth.xboxlive.com::MBI_SSL"},
 		"grant_type":    {"refresh_token"},
@@ -176,6 +205,17 @@ func refreshToken(t *oauth2.Token) (*oauth2.Token, error) {
 	}, nil
 }
 
+// postForm sends a POST request with the form values passed to the endpoint passed, using the *http.Client
+// set in the context passed using WithHTTPClient or http.DefaultClient.
+func postForm(ctx context.Context, endpoint string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return httpClient(ctx, http.DefaultClient).Do(req)
+}
+
 type deviceAuthConnect struct {
 	UserCode        string `json:"user_code"`
 	DeviceCode      string `json:"device_code"`
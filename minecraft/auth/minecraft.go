@@ -18,6 +18,8 @@ const minecraftAuthURL = `https://multiplayer.minecraft.net/authentication`
 // RequestMinecraftChain requests a fully processed Minecraft JWT chain using the XSTS token passed, and the
 // ECDSA private key of the client. This key will later be used to initialise encryption, and must be saved
 // for when packets need to be decrypted/encrypted.
+// The request is sent using the *http.Client set in the context using WithHTTPClient, or http.DefaultClient
+// if none is set.
 func RequestMinecraftChain(ctx context.Context, token *XBLToken, key *ecdsa.PrivateKey) (string, error) {
 	data, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
 
@@ -33,16 +35,16 @@ func RequestMinecraftChain(ctx context.Context, token *XBLToken, key *ecdsa.Priv
 	request.Header.Set("User-Agent", "MCPE/Android")
 	request.Header.Set("Client-Version", protocol.CurrentVersion)
 
-	c := &http.Client{}
-	resp, err := c.Do(request)
+	resp, err := httpClient(ctx, http.DefaultClient).Do(request)
 	if err != nil {
 		return "", fmt.Errorf("POST %v: %v", minecraftAuthURL, err)
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 	if resp.StatusCode != 200 {
 		return "", fmt.Errorf("POST %v: %v", minecraftAuthURL, resp.Status)
 	}
 	data, err = io.ReadAll(resp.Body)
-	_ = resp.Body.Close()
-	c.CloseIdleConnections()
 	return string(data), err
 }
@@ -0,0 +1,103 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// BlobCache is an in-memory cache of blobs used in the client blob cache protocol. Servers that support the
+// protocol send the hashes of the blobs that make up a chunk, rather than the chunk data itself, and only send
+// the blobs that the client does not yet have.
+// A BlobCache may be shared between multiple connections by setting it in Dialer.BlobCache, so that blobs
+// received over one connection are not sent again when reconnecting. The zero value of a BlobCache is ready
+// for use.
+type BlobCache struct {
+	mu    sync.RWMutex
+	blobs map[uint64][]byte
+}
+
+// Blob looks up the payload of the blob with the hash passed. False is returned if the cache does not hold a
+// blob with the hash.
+func (c *BlobCache) Blob(hash uint64) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	payload, ok := c.blobs[hash]
+	return payload, ok
+}
+
+// Store stores the payload of the blob with the hash passed in the cache.
+func (c *BlobCache) Store(hash uint64, payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.blobs == nil {
+		c.blobs = make(map[uint64][]byte)
+	}
+	c.blobs[hash] = payload
+}
+
+// Len returns the amount of blobs held in the cache.
+func (c *BlobCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.blobs)
+}
+
+// BlobCache returns the BlobCache that holds the blobs received by the Conn. It is nil if the client blob cache
+// is not enabled, or if the Conn was obtained from a Listener. Blobs referenced in LevelChunk and SubChunk
+// packets may be looked up in the cache after the server sends them in a ClientCacheMissResponse packet.
+func (conn *Conn) BlobCache() *BlobCache {
+	return conn.blobCache
+}
+
+// handleLevelChunkBlobs requests the blobs referenced in the LevelChunk packet passed that are not yet held in
+// the BlobCache of the Conn.
+func (conn *Conn) handleLevelChunkBlobs(pk *packet.LevelChunk) {
+	if conn.blobCache == nil || !pk.CacheEnabled {
+		return
+	}
+	conn.requestBlobs(pk.BlobHashes)
+}
+
+// handleSubChunkBlobs requests the blobs referenced in the SubChunk packet passed that are not yet held in the
+// BlobCache of the Conn.
+func (conn *Conn) handleSubChunkBlobs(pk *packet.SubChunk) {
+	if conn.blobCache == nil || !pk.CacheEnabled {
+		return
+	}
+	hashes := make([]uint64, 0, len(pk.SubChunkEntries))
+	for _, entry := range pk.SubChunkEntries {
+		if entry.Result == protocol.SubChunkResultSuccess {
+			hashes = append(hashes, entry.BlobHash)
+		}
+	}
+	conn.requestBlobs(hashes)
+}
+
+// requestBlobs sends a ClientCacheBlobStatus packet to the server that acknowledges the blobs with the hashes
+// passed that are held in the BlobCache of the Conn and requests the ones that are not.
+func (conn *Conn) requestBlobs(hashes []uint64) {
+	if len(hashes) == 0 {
+		return
+	}
+	status := &packet.ClientCacheBlobStatus{}
+	for _, hash := range hashes {
+		if _, ok := conn.blobCache.Blob(hash); ok {
+			status.HitHashes = append(status.HitHashes, hash)
+			continue
+		}
+		status.MissHashes = append(status.MissHashes, hash)
+	}
+	_ = conn.WritePacket(status)
+}
+
+// handleClientCacheMissResponse stores the blobs sent in the ClientCacheMissResponse packet passed in the
+// BlobCache of the Conn.
+func (conn *Conn) handleClientCacheMissResponse(pk *packet.ClientCacheMissResponse) {
+	if conn.blobCache == nil {
+		return
+	}
+	for _, blob := range pk.Blobs {
+		conn.blobCache.Store(blob.Hash, blob.Payload)
+	}
+}
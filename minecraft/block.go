@@ -0,0 +1,337 @@
+package minecraft
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+const (
+	// BlockLayerNormal is the layer that blocks are placed on by default, used in UpdateBlock packets.
+	BlockLayerNormal = iota
+	// BlockLayerLiquid is the layer that holds liquids inside other blocks, such as water in a waterlogged
+	// fence.
+	BlockLayerLiquid
+)
+
+// BlockState is a block with a specific set of states, such as 'minecraft:oak_stairs' facing a particular
+// direction. Every block state has its own runtime ID.
+type BlockState struct {
+	// Name is the name of the block, such as 'minecraft:oak_stairs'.
+	Name string
+	// Properties holds the states of the block, such as 'weirdo_direction'. The values are either a uint8, an
+	// int32 or a string.
+	Properties map[string]any
+}
+
+// NetworkHash returns the hash of the block state that is used as its runtime ID by servers that set
+// GameData.UseBlockNetworkIDHashes. It is the 32-bit FNV-1a hash of the state encoded as little endian NBT, with
+// the properties sorted by name.
+func (b BlockState) NetworkHash() uint32 {
+	if b.Name == "minecraft:unknown" {
+		// The unknown block has a fixed hash rather than one computed from its name.
+		return 0xfffffffe
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	buf.Write([]byte{10, 0, 0})
+	writeHashTag(buf, 8, "name")
+	writeHashString(buf, b.Name)
+	writeHashTag(buf, 10, "states")
+
+	keys := make([]string, 0, len(b.Properties))
+	for k := range b.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := b.Properties[k].(type) {
+		case bool:
+			writeHashTag(buf, 1, k)
+			if v {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		case uint8:
+			writeHashTag(buf, 1, k)
+			buf.WriteByte(v)
+		case int16:
+			writeHashTag(buf, 2, k)
+			_ = binary.Write(buf, binary.LittleEndian, v)
+		case int32:
+			writeHashTag(buf, 3, k)
+			_ = binary.Write(buf, binary.LittleEndian, v)
+		case int64:
+			writeHashTag(buf, 4, k)
+			_ = binary.Write(buf, binary.LittleEndian, v)
+		case float32:
+			writeHashTag(buf, 5, k)
+			_ = binary.Write(buf, binary.LittleEndian, math.Float32bits(v))
+		case string:
+			writeHashTag(buf, 8, k)
+			writeHashString(buf, v)
+		}
+	}
+	buf.Write([]byte{0, 0})
+
+	h := fnv.New32a()
+	_, _ = h.Write(buf.Bytes())
+	return h.Sum32()
+}
+
+// String returns the block state in a readable form, such as 'minecraft:oak_stairs[upside_down_bit=0]'.
+func (b BlockState) String() string {
+	if len(b.Properties) == 0 {
+		return b.Name
+	}
+	keys := make([]string, 0, len(b.Properties))
+	for k := range b.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := b.Name + "["
+	for i, k := range keys {
+		if i != 0 {
+			s += ","
+		}
+		s += fmt.Sprintf("%v=%v", k, b.Properties[k])
+	}
+	return s + "]"
+}
+
+// writeHashTag writes the type and name of an NBT tag in little endian NBT encoding.
+func writeHashTag(buf *bytes.Buffer, tagType byte, name string) {
+	buf.WriteByte(tagType)
+	writeHashString(buf, name)
+}
+
+// writeHashString writes a string in little endian NBT encoding.
+func writeHashString(buf *bytes.Buffer, s string) {
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+// BlockPalette maps block states to their runtime IDs and back. The runtime IDs of vanilla blocks are not sent
+// by the server, so the palette must be created from the block states of the protocol version used, for
+// example using NewBlockPalette.
+type BlockPalette struct {
+	states     map[uint32]BlockState
+	runtimeIDs map[uint32]uint32
+	hashed     bool
+}
+
+// NewBlockPalette creates a BlockPalette holding the block states passed. If hashed is false, the runtime ID
+// of a block state is its index in the slice passed, which must therefore be sorted in the same way as the
+// palette of the client. If hashed is true, as is the case if GameData.UseBlockNetworkIDHashes is set, the
+// runtime ID of a block state is its NetworkHash and the order of the states does not matter.
+func NewBlockPalette(states []BlockState, hashed bool) *BlockPalette {
+	p := &BlockPalette{
+		states:     make(map[uint32]BlockState, len(states)),
+		runtimeIDs: make(map[uint32]uint32, len(states)),
+		hashed:     hashed,
+	}
+	for i, state := range states {
+		hash, rid := state.NetworkHash(), uint32(i)
+		if hashed {
+			rid = hash
+		}
+		p.states[rid], p.runtimeIDs[hash] = state, rid
+	}
+	return p
+}
+
+// RuntimeID looks up the runtime ID of the block state passed. If the palette uses hashed runtime IDs, the
+// runtime ID is always returned, even if the state is not in the palette. Otherwise, false is returned if the
+// state is not in the palette.
+func (p *BlockPalette) RuntimeID(state BlockState) (uint32, bool) {
+	hash := state.NetworkHash()
+	if p.hashed {
+		return hash, true
+	}
+	rid, ok := p.runtimeIDs[hash]
+	return rid, ok
+}
+
+// State looks up the block state with the runtime ID passed. False is returned if no state with the runtime ID
+// is in the palette.
+func (p *BlockPalette) State(runtimeID uint32) (BlockState, bool) {
+	state, ok := p.states[runtimeID]
+	return state, ok
+}
+
+// UpdateBlock creates an UpdateBlock packet that places the block state passed at a position on a layer, which
+// is either BlockLayerNormal or BlockLayerLiquid. The flags are a combination of the packet.BlockUpdate
+// constants, of which packet.BlockUpdateNetwork is generally sufficient. An error is returned if the block
+// state is not in the palette or if the layer is unknown.
+func (p *BlockPalette) UpdateBlock(pos protocol.BlockPos, state BlockState, layer, flags uint32) (*packet.UpdateBlock, error) {
+	if layer != BlockLayerNormal && layer != BlockLayerLiquid {
+		return nil, fmt.Errorf("unknown block layer %v", layer)
+	}
+	rid, ok := p.RuntimeID(state)
+	if !ok {
+		return nil, fmt.Errorf("block state %v not in palette", state)
+	}
+	return &packet.UpdateBlock{Position: pos, NewBlockRuntimeID: rid, Flags: flags, Layer: layer}, nil
+}
+
+// UpdatedBlock looks up the block state placed by the UpdateBlock packet passed. False is returned if the
+// runtime ID in the packet is not in the palette.
+func (p *BlockPalette) UpdatedBlock(pk *packet.UpdateBlock) (BlockState, bool) {
+	return p.State(pk.NewBlockRuntimeID)
+}
+
+// BlockChange is the change of a single block in a sub-chunk, as held in an UpdateSubChunkBlocks packet.
+type BlockChange struct {
+	// Position is the position of the block changed.
+	Position protocol.BlockPos
+	// State is the block state placed at the position.
+	State BlockState
+	// Layer is the layer that the block state is placed on. It is either BlockLayerNormal or
+	// BlockLayerLiquid.
+	Layer uint32
+	// Flags is a combination of the packet.BlockUpdate constants, of which packet.BlockUpdateNetwork is
+	// generally sufficient.
+	Flags uint32
+}
+
+// UpdateSubChunkBlocks creates an UpdateSubChunkBlocks packet that applies all block changes passed to the
+// sub-chunk at the position passed. Changes on BlockLayerNormal are held in the Blocks of the packet and
+// changes on BlockLayerLiquid in its Extra blocks. An error is returned if any of the block states is not in
+// the palette, if a position is not in the sub-chunk or if a layer is unknown.
+func (p *BlockPalette) UpdateSubChunkBlocks(pos protocol.SubChunkPos, changes []BlockChange) (*packet.UpdateSubChunkBlocks, error) {
+	pk := &packet.UpdateSubChunkBlocks{Position: pos}
+	for _, change := range changes {
+		if (protocol.SubChunkPos{change.Position[0] >> 4, change.Position[1] >> 4, change.Position[2] >> 4}) != pos {
+			return nil, fmt.Errorf("block position %v not in sub-chunk %v", change.Position, pos)
+		}
+		rid, ok := p.RuntimeID(change.State)
+		if !ok {
+			return nil, fmt.Errorf("block state %v not in palette", change.State)
+		}
+		entry := protocol.BlockChangeEntry{BlockPos: change.Position, BlockRuntimeID: rid, Flags: change.Flags}
+		switch change.Layer {
+		case BlockLayerNormal:
+			pk.Blocks = append(pk.Blocks, entry)
+		case BlockLayerLiquid:
+			pk.Extra = append(pk.Extra, entry)
+		default:
+			return nil, fmt.Errorf("unknown block layer %v", change.Layer)
+		}
+	}
+	return pk, nil
+}
+
+// UpdatedSubChunkBlocks looks up the block states placed by the UpdateSubChunkBlocks packet passed. The
+// changes of the Blocks of the packet are returned first, followed by those of its Extra blocks on
+// BlockLayerLiquid. False is returned if any of the runtime IDs in the packet is not in the palette.
+func (p *BlockPalette) UpdatedSubChunkBlocks(pk *packet.UpdateSubChunkBlocks) ([]BlockChange, bool) {
+	changes := make([]BlockChange, 0, len(pk.Blocks)+len(pk.Extra))
+	for layer, entries := range [][]protocol.BlockChangeEntry{BlockLayerNormal: pk.Blocks, BlockLayerLiquid: pk.Extra} {
+		for _, entry := range entries {
+			state, ok := p.State(entry.BlockRuntimeID)
+			if !ok {
+				return nil, false
+			}
+			changes = append(changes, BlockChange{Position: entry.BlockPos, State: state, Layer: uint32(layer), Flags: entry.Flags})
+		}
+	}
+	return changes, true
+}
+
+// BlockPaletteEntry is a block state in a block palette together with its runtime ID.
+type BlockPaletteEntry struct {
+	// Name is the name of the block, such as 'minecraft:oak_stairs'.
+	Name string
+	// States holds the states of the block, such as 'weirdo_direction'. The values are either a uint8, an int32
+	// or a string.
+	States map[string]any
+	// RuntimeID is the runtime ID of the block state, as found in chunks and UpdateBlock packets.
+	RuntimeID uint32
+}
+
+// State returns the BlockState of the BlockPaletteEntry.
+func (entry BlockPaletteEntry) State() BlockState {
+	return BlockState{Name: entry.Name, Properties: entry.States}
+}
+
+// Entries returns all block states in the palette together with their runtime IDs, sorted by runtime ID.
+func (p *BlockPalette) Entries() []BlockPaletteEntry {
+	entries := make([]BlockPaletteEntry, 0, len(p.states))
+	for rid, state := range p.states {
+		entries = append(entries, BlockPaletteEntry{Name: state.Name, States: state.Properties, RuntimeID: rid})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].RuntimeID < entries[j].RuntimeID
+	})
+	return entries
+}
+
+// ParseBlockPalette parses a block palette encoded as a list of compounds in the network little endian NBT
+// encoding, as sent in the StartGame packet by servers of versions before v1.16.100. Each compound holds the
+// 'name' and 'states' of a block, either directly or in a nested 'block' compound, and the runtime ID of
+// each block state is its index in the list.
+func ParseBlockPalette(data []byte) ([]BlockPaletteEntry, error) {
+	var list []map[string]any
+	if err := nbt.UnmarshalEncoding(data, &list, nbt.NetworkLittleEndian); err != nil {
+		return nil, fmt.Errorf("parse block palette: %w", err)
+	}
+	entries := make([]BlockPaletteEntry, len(list))
+	for i, m := range list {
+		if block, ok := m["block"].(map[string]any); ok {
+			m = block
+		}
+		name, ok := m["name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("parse block palette: entry %v has no name", i)
+		}
+		states, _ := m["states"].(map[string]any)
+		if states == nil {
+			states = map[string]any{}
+		}
+		entries[i] = BlockPaletteEntry{Name: name, States: states, RuntimeID: uint32(i)}
+	}
+	return entries, nil
+}
+
+// CustomBlockStates returns every block state of the custom blocks passed, such as those in
+// GameData.CustomBlocks. A state is produced for every combination of the values of the properties that a
+// custom block defines. Because the runtime IDs of these states depend on the vanilla block palette unless
+// GameData.UseBlockNetworkIDHashes is set, the states should be passed to NewBlockPalette together with the
+// vanilla block states to find their runtime IDs.
+func CustomBlockStates(blocks []protocol.BlockEntry) []BlockState {
+	var states []BlockState
+	for _, block := range blocks {
+		permutations := []map[string]any{{}}
+		properties, _ := block.Properties["properties"].([]any)
+		for _, p := range properties {
+			property, _ := p.(map[string]any)
+			name, _ := property["name"].(string)
+			values, _ := property["enum"].([]any)
+			if name == "" || len(values) == 0 {
+				continue
+			}
+			next := make([]map[string]any, 0, len(permutations)*len(values))
+			for _, permutation := range permutations {
+				for _, v := range values {
+					m := make(map[string]any, len(permutation)+1)
+					for k, pv := range permutation {
+						m[k] = pv
+					}
+					m[name] = v
+					next = append(next, m)
+				}
+			}
+			permutations = next
+		}
+		for _, permutation := range permutations {
+			states = append(states, BlockState{Name: block.Name, Properties: permutation})
+		}
+	}
+	return states
+}
@@ -0,0 +1,174 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// BossBar is a boss bar shown at the top of the screen of a player. Each boss bar is tied to the unique ID of
+// an entity.
+type BossBar struct {
+	// Title is the title shown above the boss bar.
+	Title string
+	// Health is the percentage of the boss bar that is filled, ranging from 0 to 1.
+	Health float32
+	// Colour is the colour of the boss bar. It is one of the packet.BossEventColour constants.
+	Colour uint32
+	// Overlay is the overlay shown on top of the boss bar. The client currently does not display it.
+	Overlay uint32
+	// ScreenDarkening is the value sent to darken the sky while the boss bar is shown. The client currently
+	// does not appear to act on it.
+	ScreenDarkening uint16
+}
+
+// validate checks if the health and colour of the BossBar are within their bounds.
+func (bar BossBar) validate() error {
+	if bar.Health < 0 || bar.Health > 1 {
+		return fmt.Errorf("boss bar health must be between 0 and 1, got %v", bar.Health)
+	}
+	if bar.Colour > packet.BossEventColourWhite {
+		return fmt.Errorf("unknown boss bar colour %v", bar.Colour)
+	}
+	return nil
+}
+
+// ShowBossBar shows the BossBar passed to the player of the Conn, tied to the entity with the unique ID
+// passed. If the unique ID is not that of the player itself, the title and health of the boss bar follow the
+// name tag and health of the entity on the client. An error is returned if the Conn was not obtained from a
+// Listener or if the health or colour of the boss bar are out of bounds.
+func (conn *Conn) ShowBossBar(entityUniqueID int64, bar BossBar) error {
+	if err := conn.listenerOnly("show boss bar"); err != nil {
+		return err
+	}
+	if err := bar.validate(); err != nil {
+		return conn.wrap(err, "show boss bar")
+	}
+	return conn.WritePacket(&packet.BossEvent{
+		BossEntityUniqueID: entityUniqueID,
+		EventType:          packet.BossEventShow,
+		BossBarTitle:       bar.Title,
+		HealthPercentage:   bar.Health,
+		ScreenDarkening:    bar.ScreenDarkening,
+		Colour:             bar.Colour,
+		Overlay:            bar.Overlay,
+	})
+}
+
+// SetBossBarHealth changes the health, ranging from 0 to 1, of the boss bar tied to the entity with the unique
+// ID passed. An error is returned if the Conn was not obtained from a Listener or if the health is out of
+// bounds.
+func (conn *Conn) SetBossBarHealth(entityUniqueID int64, health float32) error {
+	if err := conn.listenerOnly("set boss bar health"); err != nil {
+		return err
+	}
+	if err := (BossBar{Health: health}).validate(); err != nil {
+		return conn.wrap(err, "set boss bar health")
+	}
+	return conn.WritePacket(&packet.BossEvent{BossEntityUniqueID: entityUniqueID, EventType: packet.BossEventHealthPercentage, HealthPercentage: health})
+}
+
+// SetBossBarTitle changes the title of the boss bar tied to the entity with the unique ID passed. An error is
+// returned if the Conn was not obtained from a Listener.
+func (conn *Conn) SetBossBarTitle(entityUniqueID int64, title string) error {
+	if err := conn.listenerOnly("set boss bar title"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.BossEvent{BossEntityUniqueID: entityUniqueID, EventType: packet.BossEventTitle, BossBarTitle: title})
+}
+
+// SetBossBarAppearance changes the colour, overlay and screen darkening of the boss bar tied to the entity
+// with the unique ID passed to those of the BossBar passed. The title and health of the BossBar are ignored.
+// An error is returned if the Conn was not obtained from a Listener or if the colour is unknown.
+func (conn *Conn) SetBossBarAppearance(entityUniqueID int64, bar BossBar) error {
+	if err := conn.listenerOnly("set boss bar appearance"); err != nil {
+		return err
+	}
+	bar.Health = 0
+	if err := bar.validate(); err != nil {
+		return conn.wrap(err, "set boss bar appearance")
+	}
+	return conn.WritePacket(&packet.BossEvent{
+		BossEntityUniqueID: entityUniqueID,
+		EventType:          packet.BossEventAppearanceProperties,
+		ScreenDarkening:    bar.ScreenDarkening,
+		Colour:             bar.Colour,
+		Overlay:            bar.Overlay,
+	})
+}
+
+// HideBossBar removes the boss bar tied to the entity with the unique ID passed. An error is returned if the
+// Conn was not obtained from a Listener.
+func (conn *Conn) HideBossBar(entityUniqueID int64) error {
+	if err := conn.listenerOnly("hide boss bar"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.BossEvent{BossEntityUniqueID: entityUniqueID, EventType: packet.BossEventHide})
+}
+
+// bossBarState holds the boss bars currently shown by the server, keyed by the unique ID of the entity they
+// are tied to, if Dialer.TrackBossBars is set.
+type bossBarState struct {
+	mu      sync.Mutex
+	enabled bool
+	bars    map[int64]BossBar
+}
+
+// BossBars returns the boss bars currently shown to the client by the server, keyed by the unique ID of the
+// entity they are tied to. It always returns nil unless the Conn was dialed with Dialer.TrackBossBars set.
+func (conn *Conn) BossBars() map[int64]BossBar {
+	conn.bossBars.mu.Lock()
+	defer conn.bossBars.mu.Unlock()
+	if len(conn.bossBars.bars) == 0 {
+		return nil
+	}
+	bars := make(map[int64]BossBar, len(conn.bossBars.bars))
+	for id, bar := range conn.bossBars.bars {
+		bars[id] = bar
+	}
+	return bars
+}
+
+// handleBossEvent updates the boss bars shown according to the BossEvent packet passed.
+func (s *bossBarState) handleBossEvent(pk *packet.BossEvent) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pk.EventType == packet.BossEventShow {
+		if s.bars == nil {
+			s.bars = make(map[int64]BossBar)
+		}
+		s.bars[pk.BossEntityUniqueID] = BossBar{
+			Title:           pk.BossBarTitle,
+			Health:          pk.HealthPercentage,
+			Colour:          pk.Colour,
+			Overlay:         pk.Overlay,
+			ScreenDarkening: pk.ScreenDarkening,
+		}
+		return
+	}
+	bar, ok := s.bars[pk.BossEntityUniqueID]
+	if !ok {
+		return
+	}
+	switch pk.EventType {
+	case packet.BossEventHide:
+		delete(s.bars, pk.BossEntityUniqueID)
+		return
+	case packet.BossEventHealthPercentage:
+		bar.Health = pk.HealthPercentage
+	case packet.BossEventTitle:
+		bar.Title = pk.BossBarTitle
+	case packet.BossEventAppearanceProperties:
+		bar.ScreenDarkening, bar.Colour, bar.Overlay = pk.ScreenDarkening, pk.Colour, pk.Overlay
+	case packet.BossEventTexture:
+		bar.Colour, bar.Overlay = pk.Colour, pk.Overlay
+	default:
+		// BossEventRegisterPlayer and BossEventUnregisterPlayer do not change the boss bar itself, and
+		// BossEventRequest is only sent by the client.
+		return
+	}
+	s.bars[pk.BossEntityUniqueID] = bar
+}
@@ -0,0 +1,74 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"image/color"
+	"time"
+)
+
+// Camera is a camera that the view of a player is moved to using Conn.SetCamera.
+type Camera struct {
+	// Preset is the index of the camera preset in the CameraPresets packet last sent to the player. The
+	// position and rotation of the preset are used unless Position or Rotation are set.
+	Preset uint32
+	// Position is the position of the camera in the world.
+	Position protocol.Optional[mgl32.Vec3]
+	// Rotation is the rotation of the camera, holding its pitch and yaw in degrees.
+	Rotation protocol.Optional[mgl32.Vec2]
+	// Facing is a position that the camera keeps facing towards, regardless of its rotation.
+	Facing protocol.Optional[mgl32.Vec3]
+	// Ease is the easing function used to move the camera from its current position and rotation to the new
+	// one. It is one of the protocol.EasingType constants, such as protocol.EasingTypeInOutSine, and is only
+	// used if EaseDuration is not 0.
+	Ease uint8
+	// EaseDuration is the time that moving the camera takes. If 0, the camera is moved immediately.
+	EaseDuration time.Duration
+}
+
+// SetCamera moves the view of the player of the Conn to the Camera passed, using a CameraInstruction packet.
+// The camera remains until it is cleared using ClearCamera. An error is returned if the Conn was not obtained
+// from a Listener or if the EaseDuration of the camera is negative.
+func (conn *Conn) SetCamera(c Camera) error {
+	if err := conn.listenerOnly("set camera"); err != nil {
+		return err
+	}
+	if c.EaseDuration < 0 {
+		return conn.wrap(fmt.Errorf("camera ease duration must not be negative, got %v", c.EaseDuration), "set camera")
+	}
+	set := protocol.CameraInstructionSet{Preset: c.Preset, Position: c.Position, Rotation: c.Rotation, Facing: c.Facing}
+	if c.EaseDuration != 0 {
+		set.Ease = protocol.Option(protocol.CameraEase{Type: c.Ease, Duration: float32(c.EaseDuration.Seconds())})
+	}
+	return conn.WritePacket(&packet.CameraInstruction{Set: protocol.Option(set)})
+}
+
+// ClearCamera removes all camera instructions sent to the player of the Conn, moving its view back to the
+// player itself. An error is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) ClearCamera() error {
+	if err := conn.listenerOnly("clear camera"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.CameraInstruction{Clear: protocol.Option(true)})
+}
+
+// FadeCamera fades the screen of the player of the Conn to the colour passed, of which the alpha component is
+// ignored. The screen fades in during fadeIn, remains coloured for the wait duration and fades out during
+// fadeOut. An error is returned if the Conn was not obtained from a Listener or if any of the durations are
+// negative.
+func (conn *Conn) FadeCamera(fadeIn, wait, fadeOut time.Duration, colour color.RGBA) error {
+	if err := conn.listenerOnly("fade camera"); err != nil {
+		return err
+	}
+	if fadeIn < 0 || wait < 0 || fadeOut < 0 {
+		return conn.wrap(fmt.Errorf("camera fade durations must not be negative"), "fade camera")
+	}
+	return conn.WritePacket(&packet.CameraInstruction{Fade: protocol.Option(protocol.CameraInstructionFade{
+		FadeInDuration:  float32(fadeIn.Seconds()),
+		WaitDuration:    float32(wait.Seconds()),
+		FadeOutDuration: float32(fadeOut.Seconds()),
+		Colour:          colour,
+	})})
+}
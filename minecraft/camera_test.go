@@ -0,0 +1,105 @@
+package minecraft_test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// TestCamera tests that the camera helpers of a Conn obtained from a Listener send the CameraInstruction
+// packets expected by the client, and that they return errors for invalid arguments and client connections.
+func TestCamera(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		conn, err := minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{})
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+		if err := conn.SetCamera(minecraft.Camera{EaseDuration: -time.Second}); err == nil {
+			t.Errorf("expected negative ease duration to be rejected")
+		}
+		if err := conn.FadeCamera(-time.Second, 0, 0, color.RGBA{}); err == nil {
+			t.Errorf("expected negative fade duration to be rejected")
+		}
+		if err := conn.SetCamera(minecraft.Camera{
+			Preset:       1,
+			Position:     protocol.Option(mgl32.Vec3{1, 2, 3}),
+			Ease:         protocol.EasingTypeInOutSine,
+			EaseDuration: time.Second * 2,
+		}); err != nil {
+			errs <- err
+			return
+		}
+		if err := conn.FadeCamera(time.Second, time.Second*2, time.Second*3, color.RGBA{R: 255}); err != nil {
+			errs <- err
+			return
+		}
+		if err := conn.ClearCamera(); err != nil {
+			errs <- err
+			return
+		}
+		errs <- conn.Flush()
+		// Keep the connection open until the client has read the packets.
+		<-ctx.Done()
+	}()
+
+	conn, err := minecraft.Dialer{}.DialContext(ctx, "raknet", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.DoSpawnContext(ctx); err != nil {
+		t.Fatalf("error spawning: %v", err)
+	}
+	if err := conn.SetCamera(minecraft.Camera{}); err == nil {
+		t.Fatalf("expected SetCamera to fail for a Conn obtained using a Dialer")
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("error sending camera instructions: %v", err)
+	}
+
+	var instructions []*packet.CameraInstruction
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	for len(instructions) < 3 {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading packet: %v", err)
+		}
+		if instruction, ok := pk.(*packet.CameraInstruction); ok {
+			instructions = append(instructions, instruction)
+		}
+	}
+
+	set, ok := instructions[0].Set.Value()
+	if !ok {
+		t.Fatalf("expected first instruction to set the camera")
+	}
+	pos, _ := set.Position.Value()
+	ease, _ := set.Ease.Value()
+	if set.Preset != 1 || pos != (mgl32.Vec3{1, 2, 3}) || ease.Type != protocol.EasingTypeInOutSine || ease.Duration != 2 {
+		t.Fatalf("unexpected camera set instruction: %+v", set)
+	}
+	fade, ok := instructions[1].Fade.Value()
+	if !ok || fade.FadeInDuration != 1 || fade.WaitDuration != 2 || fade.FadeOutDuration != 3 || fade.Colour.R != 255 {
+		t.Fatalf("unexpected camera fade instruction: %+v", fade)
+	}
+	if clear, ok := instructions[2].Clear.Value(); !ok || !clear {
+		t.Fatalf("expected last instruction to clear the camera")
+	}
+}
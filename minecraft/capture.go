@@ -0,0 +1,175 @@
+package minecraft
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"sync"
+	"time"
+)
+
+// A capture is a binary file holding every packet read from and written to a Conn, in the order they were
+// processed. Packets in a capture are stored in cleartext: they are recorded after decryption and
+// decompression when read, and before compression and encryption when written.
+//
+// A capture starts with the following header:
+//
+//	magic      [6]byte  "GTCAPT"
+//	version    uint8    the capture format version, currently 1
+//	server     uint8    1 if the capture was made on the server side of a connection, 0 otherwise
+//
+// The header is followed by zero or more records until the end of the file. All integers are little endian:
+//
+//	time       int64    the time at which the packet was processed, in nanoseconds since the Unix epoch
+//	sent       uint8    1 if the packet was written to the Conn, 0 if it was read from it
+//	id         uint32   the packet ID found in the packet.Header
+//	sender     uint8    the SenderSubClient found in the packet.Header
+//	target     uint8    the TargetSubClient found in the packet.Header
+//	length     uint32   the length of the payload that follows
+//	payload    []byte   the payload of the packet, excluding its header
+const (
+	captureMagic   = "GTCAPT"
+	captureVersion = 1
+)
+
+// captureWriter writes packets to an io.Writer in the capture format.
+type captureWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// newCaptureWriter returns a captureWriter that writes to the io.Writer passed, writing the capture header
+// immediately. server specifies if the capture is made on the server side of a connection.
+func newCaptureWriter(w io.Writer, server bool) *captureWriter {
+	c := &captureWriter{w: w}
+	hdr := append([]byte(captureMagic), captureVersion, 0)
+	if server {
+		hdr[len(hdr)-1] = 1
+	}
+	_, c.err = w.Write(hdr)
+	return c
+}
+
+// write records a packet with the header and payload passed. sent specifies if the packet was written to the
+// Conn rather than read from it. Once writing to the underlying io.Writer fails, write returns the same error
+// for all subsequent calls.
+func (c *captureWriter) write(sent bool, header packet.Header, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	b := make([]byte, 0, 19+len(payload))
+	b = binary.LittleEndian.AppendUint64(b, uint64(time.Now().UnixNano()))
+	if sent {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = binary.LittleEndian.AppendUint32(b, header.PacketID)
+	b = append(b, header.SenderSubClient, header.TargetSubClient)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(payload)))
+	b = append(b, payload...)
+	if _, err := c.w.Write(b); err != nil {
+		c.err = fmt.Errorf("write capture record: %w", err)
+	}
+	return c.err
+}
+
+// capture records a packet if the Conn has a capture set. If writing the record fails, the error is logged
+// and capturing is disabled for the rest of the connection.
+func (conn *Conn) capture(sent bool, header packet.Header, payload []byte) {
+	c := conn.captureWriter.Load()
+	if c == nil {
+		return
+	}
+	if err := c.write(sent, header, payload); err != nil {
+		conn.log.Error("capture packet", "err", err)
+		conn.captureWriter.Store(nil)
+	}
+}
+
+// CapturedPacket is a single packet read from a capture using a CaptureReader.
+type CapturedPacket struct {
+	// Time is the time at which the packet was read from or written to the Conn.
+	Time time.Time
+	// Sent is true if the packet was written to the Conn that made the capture, and false if it was read
+	// from it.
+	Sent bool
+	// Header is the header of the packet, holding its ID.
+	Header packet.Header
+	// Payload is the cleartext payload of the packet, excluding its header. It may be decoded by looking up
+	// the packet with the ID in Header in a packet.Pool and calling Marshal with a protocol.Reader.
+	Payload []byte
+}
+
+// ErrInvalidCapture is returned by NewCaptureReader if the data read does not start with a valid capture
+// header.
+var ErrInvalidCapture = errors.New("invalid packet capture")
+
+// CaptureReader reads packets from a capture previously recorded using the Capture field of a Dialer or
+// ListenConfig.
+type CaptureReader struct {
+	r      *bufio.Reader
+	server bool
+}
+
+// NewCaptureReader returns a CaptureReader that reads a capture from the io.Reader passed. The capture header
+// is read immediately: ErrInvalidCapture is returned if it is not valid.
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	br := bufio.NewReader(r)
+	hdr := make([]byte, len(captureMagic)+2)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, fmt.Errorf("read capture header: %w", err)
+	}
+	if !bytes.Equal(hdr[:len(captureMagic)], []byte(captureMagic)) {
+		return nil, ErrInvalidCapture
+	}
+	if v := hdr[len(captureMagic)]; v != captureVersion {
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrInvalidCapture, v)
+	}
+	return &CaptureReader{r: br, server: hdr[len(hdr)-1] == 1}, nil
+}
+
+// Server returns true if the capture was made on the server side of a connection, for example by a Conn
+// accepted by a Listener.
+func (r *CaptureReader) Server() bool {
+	return r.server
+}
+
+// ReadPacket reads the next packet from the capture. io.EOF is returned if no more packets are left in the
+// capture.
+func (r *CaptureReader) ReadPacket() (CapturedPacket, error) {
+	b := make([]byte, 19)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if errors.Is(err, io.EOF) {
+			return CapturedPacket{}, io.EOF
+		}
+		return CapturedPacket{}, fmt.Errorf("read capture record: %w", err)
+	}
+	pk := CapturedPacket{
+		Time: time.Unix(0, int64(binary.LittleEndian.Uint64(b))),
+		Sent: b[8] == 1,
+		Header: packet.Header{
+			PacketID:        binary.LittleEndian.Uint32(b[9:]),
+			SenderSubClient: b[13],
+			TargetSubClient: b[14],
+		},
+	}
+	// Copy the payload rather than allocating the full length upfront, so that a corrupted length does not
+	// lead to huge allocations.
+	payload := bytes.NewBuffer(nil)
+	if _, err := io.CopyN(payload, r.r, int64(binary.LittleEndian.Uint32(b[15:]))); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return CapturedPacket{}, fmt.Errorf("read capture payload: %w", err)
+	}
+	pk.Payload = payload.Bytes()
+	return pk, nil
+}
@@ -0,0 +1,288 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+	"time"
+)
+
+// Command is a command that the server made available to the client through the AvailableCommands packet. In
+// contrast with protocol.Command, all enums, suffixes and aliases are resolved.
+type Command struct {
+	// Name is the name of the command, without the leading slash.
+	Name string
+	// Description is the description of the command, as shown in the /help list.
+	Description string
+	// Aliases holds the aliases that the command may also be executed with.
+	Aliases []string
+	// PermissionLevel is the permission level required to execute the command.
+	PermissionLevel byte
+	// Overloads holds the different sets of parameters that the command may be executed with.
+	Overloads [][]CommandParameter
+}
+
+// CommandParameter is a single parameter of a Command overload.
+type CommandParameter struct {
+	// Name is the name of the parameter as shown in the usage of the command.
+	Name string
+	// Type is the basic argument type of the parameter, which is one of the protocol.CommandArgType
+	// constants. Type is 0 if the parameter is an enum.
+	Type uint32
+	// Optional specifies if the parameter may be omitted.
+	Optional bool
+	// Options holds a combination of the protocol.ParamOption constants.
+	Options byte
+	// Enum is the enum that holds the valid values for the parameter. Enum is nil if the parameter is not
+	// an enum.
+	Enum *CommandEnum
+	// Suffix is the suffix that values of the parameter must be followed by, such as 'L' for levels in
+	// the /xp command. Suffix is empty if the parameter has no suffix.
+	Suffix string
+}
+
+// CommandEnum is an enum of a CommandParameter, holding a limited set of valid values.
+type CommandEnum struct {
+	// Type is the name of the enum type.
+	Type string
+	// Options holds all valid values of the enum.
+	Options []string
+	// Dynamic specifies if the options of the enum may be changed by the server through an UpdateSoftEnum
+	// packet. Dynamic enums are kept up to date by the Conn.
+	Dynamic bool
+}
+
+// commandState holds the commands last sent to a Conn by the server.
+type commandState struct {
+	mu       sync.Mutex
+	pk       *packet.AvailableCommands
+	once     sync.Once
+	received chan struct{}
+}
+
+// AvailableCommands returns the commands the server made available to the client in the last
+// AvailableCommands packet, with any changes made to dynamic enums through UpdateSoftEnum packets applied.
+// If no AvailableCommands packet has been read yet, AvailableCommands blocks until one is read or until the
+// context passed is cancelled or the Conn is closed. Conn.ReadPacket must be called continuously for
+// AvailableCommands to ever return.
+func (conn *Conn) AvailableCommands(ctx context.Context) ([]Command, error) {
+	select {
+	case <-conn.close:
+		return nil, conn.closeErr("available commands")
+	case <-ctx.Done():
+		return nil, conn.wrap(ctx.Err(), "available commands")
+	case <-conn.commands.received:
+	}
+	conn.commands.mu.Lock()
+	defer conn.commands.mu.Unlock()
+	return resolveCommands(conn.commands.pk), nil
+}
+
+// handleAvailableCommands stores the AvailableCommands packet passed so that it may be returned by
+// AvailableCommands.
+func (s *commandState) handleAvailableCommands(pk *packet.AvailableCommands) {
+	s.mu.Lock()
+	s.pk = pk
+	s.mu.Unlock()
+
+	s.once.Do(func() {
+		close(s.received)
+	})
+}
+
+// handleUpdateSoftEnum applies the changes of an UpdateSoftEnum packet to the dynamic enum it targets.
+func (s *commandState) handleUpdateSoftEnum(pk *packet.UpdateSoftEnum) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pk == nil {
+		return
+	}
+	for i, enum := range s.pk.DynamicEnums {
+		if enum.Type != pk.EnumType {
+			continue
+		}
+		switch pk.ActionType {
+		case packet.SoftEnumActionAdd:
+			enum.Values = append(enum.Values, pk.Options...)
+		case packet.SoftEnumActionRemove:
+			values := make([]string, 0, len(enum.Values))
+			for _, v := range enum.Values {
+				if !containsString(pk.Options, v) {
+					values = append(values, v)
+				}
+			}
+			enum.Values = values
+		case packet.SoftEnumActionSet:
+			enum.Values = append([]string(nil), pk.Options...)
+		}
+		s.pk.DynamicEnums[i] = enum
+		return
+	}
+}
+
+// CommandResult is the result of a command sent using Conn.SendCommand.
+type CommandResult struct {
+	// SuccessCount is the amount of times the command was executed successfully. It is 0 if the command
+	// failed.
+	SuccessCount uint32
+	// Messages holds the output messages of the command. Each message specifies if it was a message of a
+	// successful execution, and may be a translation key such as 'commands.tp.success.coordinates' combined
+	// with the parameters it holds.
+	Messages []protocol.CommandOutputMessage
+	// Err is non-nil if no CommandOutput was received for the command before the timeout passed to
+	// SendCommand expired.
+	Err error
+}
+
+// Success checks if the command was executed successfully at least once.
+func (r CommandResult) Success() bool {
+	return r.Err == nil && r.SuccessCount > 0
+}
+
+// commandRequests keeps track of commands sent by a Conn that have not yet received a CommandOutput from the
+// server. The zero value is ready to use.
+type commandRequests struct {
+	mu      sync.Mutex
+	pending map[uuid.UUID]chan CommandResult
+}
+
+// next creates a new command origin UUID and returns it together with a channel that receives the result of
+// the command sent with that UUID.
+func (r *commandRequests) next() (uuid.UUID, chan CommandResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending == nil {
+		r.pending = make(map[uuid.UUID]chan CommandResult)
+	}
+	id := uuid.New()
+	c := make(chan CommandResult, 1)
+	r.pending[id] = c
+	return id, c
+}
+
+// resolve passes the output held in the CommandOutput packet passed to the channel of the command that it is
+// the output of. Output of commands not sent through SendCommand is ignored.
+func (r *commandRequests) resolve(pk *packet.CommandOutput) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.pending[pk.CommandOrigin.UUID]
+	if !ok {
+		return
+	}
+	delete(r.pending, pk.CommandOrigin.UUID)
+	c <- CommandResult{SuccessCount: pk.SuccessCount, Messages: pk.OutputMessages}
+}
+
+// expire passes the error passed to the channel of the command with the UUID passed if it has not yet
+// received its output.
+func (r *commandRequests) expire(id uuid.UUID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.pending[id]; ok {
+		delete(r.pending, id)
+		c <- CommandResult{Err: err}
+	}
+}
+
+// cancel stops tracking the command with the UUID passed, for example if it could not be sent.
+func (r *commandRequests) cancel(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+}
+
+// SendCommand sends a CommandRequest packet to the server to execute the command line passed, such as
+// '/say hello'. The channel returned receives the CommandResult of the CommandOutput packet that the server
+// sends for the command, which is matched with the request by the UUID of its command origin. If no
+// CommandOutput is received within the timeout passed, the channel receives a CommandResult with a non-nil
+// Err instead. Note that many servers do not send CommandOutput packets at all.
+// Output is only matched with its command when the CommandOutput packet holding it is read using
+// Conn.ReadPacket, so ReadPacket must be called continuously for the channel to receive the output. An error
+// is returned if the Conn was obtained from a Listener.
+func (conn *Conn) SendCommand(commandLine string, timeout time.Duration) (<-chan CommandResult, error) {
+	if err := conn.dialerOnly("send command"); err != nil {
+		return nil, err
+	}
+	id, c := conn.commandOutputs.next()
+	err := conn.WritePacket(&packet.CommandRequest{
+		CommandLine:   commandLine,
+		CommandOrigin: protocol.CommandOrigin{Origin: protocol.CommandOriginPlayer, UUID: id},
+	})
+	if err != nil {
+		conn.commandOutputs.cancel(id)
+		return nil, err
+	}
+	time.AfterFunc(timeout, func() {
+		conn.commandOutputs.expire(id, conn.wrap(fmt.Errorf("no command output received within %v", timeout), "send command"))
+	})
+	return c, nil
+}
+
+// resolveCommands resolves all commands in the AvailableCommands packet passed into a slice of Commands.
+func resolveCommands(pk *packet.AvailableCommands) []Command {
+	enums := make([]*CommandEnum, len(pk.Enums))
+	for i, enum := range pk.Enums {
+		e := &CommandEnum{Type: enum.Type}
+		for _, index := range enum.ValueIndices {
+			if int(index) < len(pk.EnumValues) {
+				e.Options = append(e.Options, pk.EnumValues[index])
+			}
+		}
+		enums[i] = e
+	}
+	dynamicEnums := make([]*CommandEnum, len(pk.DynamicEnums))
+	for i, enum := range pk.DynamicEnums {
+		dynamicEnums[i] = &CommandEnum{Type: enum.Type, Options: append([]string(nil), enum.Values...), Dynamic: true}
+	}
+
+	commands := make([]Command, 0, len(pk.Commands))
+	for _, c := range pk.Commands {
+		cmd := Command{Name: c.Name, Description: c.Description, PermissionLevel: c.PermissionLevel}
+		if int(c.AliasesOffset) < len(enums) {
+			cmd.Aliases = enums[c.AliasesOffset].Options
+		}
+		for _, overload := range c.Overloads {
+			params := make([]CommandParameter, 0, len(overload.Parameters))
+			for _, p := range overload.Parameters {
+				param := CommandParameter{Name: p.Name, Optional: p.Optional, Options: p.Options}
+				index := int(p.Type & 0xffff)
+				switch {
+				case p.Type&protocol.CommandArgSoftEnum != 0:
+					if index < len(dynamicEnums) {
+						param.Enum = dynamicEnums[index]
+					}
+				case p.Type&protocol.CommandArgEnum != 0:
+					if index < len(enums) {
+						param.Enum = enums[index]
+					}
+				case p.Type&protocol.CommandArgSuffixed != 0:
+					if index < len(pk.Suffixes) {
+						param.Suffix = pk.Suffixes[index]
+					}
+				default:
+					param.Type = uint32(index)
+				}
+				params = append(params, param)
+			}
+			cmd.Overloads = append(cmd.Overloads, params)
+		}
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// containsString checks if the string slice passed contains the string s.
+func containsString(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package minecraft
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+// TestCommandOutputCorrelation tests that CommandOutput packets are matched with the command that they are
+// the output of by the UUID of their command origin, and that commands without output expire.
+func TestCommandOutputCorrelation(t *testing.T) {
+	var r commandRequests
+	first, firstC := r.next()
+	second, secondC := r.next()
+
+	r.resolve(&packet.CommandOutput{CommandOrigin: protocol.CommandOrigin{UUID: uuid.New()}, SuccessCount: 3})
+	r.resolve(&packet.CommandOutput{
+		CommandOrigin:  protocol.CommandOrigin{UUID: second},
+		SuccessCount:   1,
+		OutputMessages: []protocol.CommandOutputMessage{{Success: true, Message: "commands.say.success", Parameters: []string{"hi"}}},
+	})
+	select {
+	case res := <-secondC:
+		if !res.Success() || len(res.Messages) != 1 || res.Messages[0].Parameters[0] != "hi" {
+			t.Fatalf("unexpected result %+v", res)
+		}
+	default:
+		t.Fatal("expected output of second command to be resolved")
+	}
+	select {
+	case res := <-firstC:
+		t.Fatalf("expected first command to be unresolved, got %+v", res)
+	default:
+	}
+
+	timeout := errors.New("timeout")
+	r.expire(first, timeout)
+	r.resolve(&packet.CommandOutput{CommandOrigin: protocol.CommandOrigin{UUID: first}, SuccessCount: 1})
+	if res := <-firstC; res.Err != timeout || res.Success() {
+		t.Fatalf("expected first command to expire, got %+v", res)
+	}
+}
@@ -0,0 +1,39 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+// TestNetworkSettingsCompression tests that the compression of a connection is selected from the algorithm and
+// threshold held in a NetworkSettings packet.
+func TestNetworkSettingsCompression(t *testing.T) {
+	for _, test := range []struct {
+		algorithm uint16
+		threshold uint16
+		want      packet.Compression
+		err       bool
+	}{
+		{algorithm: packet.CompressionAlgorithmFlate, threshold: 256, want: packet.FlateCompression},
+		{algorithm: packet.CompressionAlgorithmSnappy, threshold: 1, want: packet.SnappyCompression},
+		{algorithm: packet.CompressionAlgorithmNone, threshold: 256, want: packet.NopCompression},
+		// A threshold of 0 disables compression, regardless of the algorithm.
+		{algorithm: packet.CompressionAlgorithmFlate, threshold: 0, want: packet.NopCompression},
+		{algorithm: 0x1234, threshold: 256, err: true},
+	} {
+		c, threshold, err := networkSettingsCompression(&packet.NetworkSettings{CompressionAlgorithm: test.algorithm, CompressionThreshold: test.threshold})
+		if test.err {
+			if err == nil {
+				t.Errorf("algorithm %v: expected error", test.algorithm)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("algorithm %v: unexpected error: %v", test.algorithm, err)
+			continue
+		}
+		if c != test.want || threshold != int(test.threshold) {
+			t.Errorf("algorithm %v, threshold %v: expected %T with threshold %v, got %T with threshold %v", test.algorithm, test.threshold, test.want, test.threshold, c, threshold)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
@@ -20,8 +22,10 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"github.com/sandertv/gophertunnel/minecraft/text"
 	"io"
-	"log"
+	"log/slog"
 	"net"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -52,9 +56,12 @@ type Conn struct {
 	// closed multiple times.
 	once  sync.Once
 	close chan struct{}
+	// ctx is cancelled when the Conn is closed, with the reason for closing as its cause.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
 
 	conn        net.Conn
-	log         *log.Logger
+	log         *slog.Logger
 	authEnabled bool
 
 	proto         Protocol
@@ -66,10 +73,27 @@ type Conn struct {
 	readerLimits  bool
 
 	disconnectOnUnknownPacket bool
+	ignoreUnknownPacket       bool
 	disconnectOnInvalidPacket bool
+	// rawHandshake specifies if the Conn should only handle the packets in the login sequence that are
+	// required to enable compression and encryption, leaving the rest of the sequence to the user.
+	rawHandshake bool
+	// disableEncryption specifies if encryption is skipped during the login sequence. For a Conn obtained
+	// using a Dialer, it specifies if the server is allowed to skip encryption. encrypted is set once
+	// encryption is enabled.
+	disableEncryption bool
+	encrypted         bool
+	// exposeKey specifies if the key used for encryption is stored in encryptionKey, so that it may be
+	// obtained using UnsafeEncryptionKey.
+	exposeKey     bool
+	encryptionKey atomic.Pointer[[32]byte]
 
 	identityData login.IdentityData
 	clientData   login.ClientData
+	// authenticated specifies if the identity of the connection was authenticated through XBOX Live. For a
+	// Conn obtained from a Listener, it is set once the login chain of the client is verified. For a Conn
+	// obtained using a Dialer, it is set if a chain obtained from XBOX Live was sent.
+	authenticated bool
 
 	gameData         GameData
 	gameDataReceived atomic.Bool
@@ -77,6 +101,17 @@ type Conn struct {
 	// privateKey is the private key of this end of the connection. Each connection, regardless of which side
 	// the connection is on, server or client, has a unique private key generated.
 	privateKey *ecdsa.PrivateKey
+	// serverKey is the public key that the server must sign the ServerToClientHandshake with, as set in
+	// Dialer.ServerPublicKey. If nil, any key is accepted.
+	serverKey *ecdsa.PublicKey
+	// movementMode and maxRewindHistorySize are the player movement mode and maximum rewind history size
+	// that the player movement settings in the StartGame packet are checked against, as set in
+	// Dialer.PlayerMovementMode and Dialer.MaxRewindHistorySize.
+	movementMode         protocol.Optional[int32]
+	maxRewindHistorySize int32
+	// chainData is the Minecraft auth chain used to log in by a Conn obtained using Dial. It is empty if the
+	// Conn did not use authentication.
+	chainData string
 	// salt is a 16 byte long randomly generated byte slice which is only used if the Conn is a server sided
 	// connection. It is otherwise left unused.
 	salt []byte
@@ -91,12 +126,31 @@ type Conn struct {
 	// ReadPacket after being connected.
 	deferredPackets []*packetData
 	readDeadline    <-chan time.Time
+	// readDeadlineTime is the time passed to the last call to SetReadDeadline. It is set on the underlying
+	// connection when reading from it manually.
+	readDeadlineTime time.Time
+	// readerStopped is closed once the goroutine reading packets in the background stops, after which
+	// packets are read from the underlying connection directly by calls to ReadPacket. It is nil unless
+	// Dialer.ManualRead is set.
+	readerStopped chan struct{}
 
 	sendMu sync.Mutex
 	// bufferedSend is a slice of byte slices containing packets that are 'written'. They are buffered until
 	// they are sent each 20th of a second.
 	bufferedSend [][]byte
 	hdr          *packet.Header
+	// sendCond is signalled, using sendMu as lock, every time bufferedSend is flushed or the Conn is closed.
+	// Writes waiting for space in a full send queue wait on it.
+	sendCond *sync.Cond
+	// sendQueueSize is the maximum amount of packets held in bufferedSend. If 0, bufferedSend is unbounded.
+	sendQueueSize   int
+	sendQueuePolicy SendQueuePolicy
+	// readQueueSize is the maximum amount of packets held in packets. If 0, packets that do not fit in it are
+	// moved to deferredPackets, so that the read queue is unbounded. droppedPackets counts the packets dropped
+	// because the read queue was full.
+	readQueueSize   int
+	readQueuePolicy ReadQueuePolicy
+	droppedPackets  atomic.Uint64
 
 	// readyToLogin is a bool indicating if the connection is ready to login. This is used to ensure that the client
 	// has received the relevant network settings before the login sequence starts.
@@ -127,20 +181,75 @@ type Conn struct {
 	// downloadResourcePack is an optional function passed to a Dial() call. If set, each resource pack received
 	// from the server will call this function to see if it should be downloaded or not.
 	downloadResourcePack func(id uuid.UUID, version string, currentPack, totalPacks int) bool
+	// packsDownloadedFunc is an optional function passed to a Dial() call. It is called once all resource packs
+	// sent by the server are downloaded. packsDownloaded is set once it has been called.
+	packsDownloadedFunc func(packs []*resource.Pack)
+	packsDownloaded     atomic.Bool
 	// ignoredResourcePacks is a slice of resource packs that are not being downloaded due to the downloadResourcePack
 	// func returning false for the specific pack.
 	ignoredResourcePacks []exemptedResourcePack
 
 	cacheEnabled bool
+	// blobCache holds the blobs received by a Conn obtained using Dial with the client blob cache enabled. It
+	// is nil for any other Conn.
+	blobCache *BlobCache
 
 	// packetFunc is an optional function passed to a Dial() call. If set, each packet read from and written
 	// to this connection will call this function.
 	packetFunc func(header packet.Header, payload []byte, src, dst net.Addr)
+	// packetSizeFunc is an optional function called with the size of each packet read and written, as set in
+	// Dialer.PacketSizeFunc or ListenConfig.PacketSizeFunc.
+	packetSizeFunc func(header packet.Header, size, wireSize int, src, dst net.Addr)
+	// handshakeFunc is an optional function called for every HandshakeStage that the connection reaches.
+	// reachedStages holds a bit for every stage reached.
+	handshakeFunc func(conn *Conn, stage HandshakeStage)
+	reachedStages atomic.Uint32
+	// stageTimes records the times at which HandshakeStages were reached if the Conn is dialed with a
+	// DialTrace.
+	stageTimes *stageTimes
+	// emoteFunc is an optional function called for every Emote packet read.
+	emoteFunc func(conn *Conn, emote PlayerEmote)
+	// violationFunc is an optional function called for every PacketViolationWarning read from a connection
+	// obtained using a Listener.
+	violationFunc func(pk *packet.PacketViolationWarning)
+	// identityFunc is an optional function called with the identity of a connection of a Listener once its
+	// login request is parsed. If it returns false, the connection is disconnected with the message returned.
+	identityFunc func(identity login.IdentityData) (string, bool)
+	// captureWriter records all packets read and written if a capture was set on the Dialer or ListenConfig.
+	captureWriter atomic.Pointer[captureWriter]
 
 	disconnectMessage atomic.Pointer[string]
+	// disconnectPacket holds the Disconnect packet that the other end closed the connection with, if any.
+	disconnectPacket atomic.Pointer[packet.Disconnect]
+	// loginErr holds the reason that the login sequence of a Conn obtained using a Dialer failed, such as a
+	// LoginFailedError. serverProtocol is the protocol version of the server as found in its pong, or 0 if not
+	// known.
+	loginErr       atomic.Pointer[error]
+	serverProtocol int32
 
 	shieldID atomic.Int32
 
+	stackRequests  itemStackRequests
+	commands       commandState
+	commandOutputs commandRequests
+	itemComponents itemComponentState
+	definitions    definitionState
+	latency        latencyState
+	transfer       transferState
+	abilities      abilityState
+	entities       entityTracker
+	players        playerList
+	forms          formRequests
+	bossBars       bossBarState
+	scoreboards    scoreboardState
+	worldTime      worldTimeState
+	recipes        recipeState
+	respawn        respawnState
+	interaction    interactionState
+	structures     structureRequests
+	effects        effectState
+	attributes     attributeState
+
 	additional chan packet.Packet
 }
 
@@ -148,22 +257,34 @@ type Conn struct {
 // Minecraft packets to that net.Conn.
 // newConn accepts a private key which will be used to identify the connection. If a nil key is passed, the
 // key is generated.
-func newConn(netConn net.Conn, key *ecdsa.PrivateKey, log *log.Logger, proto Protocol, flushRate time.Duration, limits bool) *Conn {
+// readBufferSize and writeBufferSize set the sizes of the buffers of the packet.Decoder and packet.Encoder. If
+// 0, the defaults of the packet package are used.
+func newConn(netConn net.Conn, key *ecdsa.PrivateKey, log *slog.Logger, proto Protocol, flushRate time.Duration, readBufferSize, writeBufferSize int, limits bool) *Conn {
+	if readBufferSize == 0 {
+		readBufferSize = packet.DefaultReadBufferSize
+	}
+	enc := packet.NewEncoder(netConn)
+	if writeBufferSize != 0 {
+		enc = packet.NewEncoderSize(netConn, writeBufferSize)
+	}
 	conn := &Conn{
-		enc:          packet.NewEncoder(netConn),
-		dec:          packet.NewDecoder(netConn),
+		enc:          enc,
+		dec:          packet.NewDecoderSize(netConn, readBufferSize),
 		salt:         make([]byte, 16),
 		packets:      make(chan *packetData, 8),
 		additional:   make(chan packet.Packet, 16),
 		close:        make(chan struct{}),
 		spawn:        make(chan struct{}),
+		commands:     commandState{received: make(chan struct{})},
 		conn:         netConn,
 		privateKey:   key,
-		log:          log,
+		log:          log.With("raddr", netConn.RemoteAddr().String()),
 		hdr:          &packet.Header{},
 		proto:        proto,
 		readerLimits: limits,
 	}
+	conn.sendCond = sync.NewCond(&conn.sendMu)
+	conn.ctx, conn.cancel = context.WithCancelCause(context.Background())
 	var s string
 	conn.disconnectMessage.Store(&s)
 
@@ -204,9 +325,40 @@ func (conn *Conn) ClientData() login.ClientData {
 	return conn.clientData
 }
 
-// Authenticated returns true if the connection was authenticated through XBOX Live services.
+// Authenticated returns true if the connection was authenticated through XBOX Live services. For a Conn
+// obtained from a Listener, this means the login chain of the client was signed by Mojang, so that its
+// IdentityData may be trusted. For a Conn obtained using a Dialer, it means a chain obtained from XBOX Live was
+// sent, which is the case if Dialer.TokenSource was set. Authenticated returns false for a self-signed login,
+// even if its IdentityData holds an XUID.
 func (conn *Conn) Authenticated() bool {
-	return conn.IdentityData().XUID != ""
+	return conn.authenticated
+}
+
+// Protocol returns the protocol version used to communicate over the connection. For a Conn obtained using a
+// Dialer, this is the version of Dialer.Protocol. For a Conn obtained from a Listener, it is the version
+// that the client reported in its RequestNetworkSettings packet, which is one of the versions of
+// ListenConfig.AcceptedProtocols or protocol.CurrentProtocol. Packets are converted from and to the latest
+// version of the protocol automatically, but Protocol may be used to handle clients of older versions
+// differently.
+func (conn *Conn) Protocol() int32 {
+	return conn.proto.ID()
+}
+
+// UnsafeEncryptionKey returns the AES-256 key negotiated during the login sequence, which is used to encrypt
+// and decrypt all packets sent over the connection after the ServerToClientHandshake packet. It is meant for
+// debugging only, such as to decrypt captured traffic in external tools: Anyone holding the key is able to
+// read and forge packets on the connection, so it should never be logged or shared outside of a trusted
+// environment. An error is returned if Dialer.ExposeEncryptionKey or ListenConfig.ExposeEncryptionKey was
+// not set, or if encryption is not (yet) enabled on the connection.
+func (conn *Conn) UnsafeEncryptionKey() ([32]byte, error) {
+	if !conn.exposeKey {
+		return [32]byte{}, conn.wrap(fmt.Errorf("encryption key not exposed: Dialer.ExposeEncryptionKey or ListenConfig.ExposeEncryptionKey must be set"), "get encryption key")
+	}
+	key := conn.encryptionKey.Load()
+	if key == nil {
+		return [32]byte{}, conn.wrap(fmt.Errorf("encryption is not enabled"), "get encryption key")
+	}
+	return *key, nil
 }
 
 // GameData returns specific game data set to the connection for the player to be initialised with. If the
@@ -310,7 +462,10 @@ func (conn *Conn) DoSpawnContext(ctx context.Context) error {
 }
 
 // WritePacket encodes the packet passed and writes it to the Conn. The encoded data is buffered until the
-// next 20th of a second, after which the data is flushed and sent over the connection.
+// next 20th of a second, after which the data is flushed and sent over the connection. WritePacket may be
+// called from multiple goroutines simultaneously: each packet is encoded and buffered as a whole, so packets
+// written concurrently are never interleaved. The packet passed must not be modified until WritePacket
+// returns.
 func (conn *Conn) WritePacket(pk packet.Packet) error {
 	select {
 	case <-conn.close:
@@ -320,6 +475,13 @@ func (conn *Conn) WritePacket(pk packet.Packet) error {
 	conn.sendMu.Lock()
 	defer conn.sendMu.Unlock()
 
+	pks := conn.proto.ConvertFromLatest(pk, conn)
+	// Reserving space in the send queue may release conn.sendMu while waiting, so this must be done before
+	// the shared header is used.
+	if err := conn.reserveSendQueue(len(pks), "write packet"); err != nil {
+		return err
+	}
+
 	buf := internal.BufferPool.Get().(*bytes.Buffer)
 	defer func() {
 		// Reset the buffer, so we can return it to the buffer pool safely.
@@ -331,12 +493,13 @@ func (conn *Conn) WritePacket(pk packet.Packet) error {
 	_ = conn.hdr.Write(buf)
 	l := buf.Len()
 
-	for _, converted := range conn.proto.ConvertFromLatest(pk, conn) {
-		converted.Marshal(conn.proto.NewWriter(buf, conn.shieldID.Load()))
+	for _, converted := range pks {
+		converted.Marshal(conn.versioned(conn.proto.NewWriter(buf, conn.shieldID.Load())))
 
 		if conn.packetFunc != nil {
 			conn.packetFunc(*conn.hdr, buf.Bytes()[l:], conn.LocalAddr(), conn.RemoteAddr())
 		}
+		conn.capture(true, *conn.hdr, buf.Bytes()[l:])
 		conn.bufferedSend = append(conn.bufferedSend, append([]byte(nil), buf.Bytes()...))
 	}
 	return nil
@@ -348,43 +511,145 @@ func (conn *Conn) WritePacket(pk packet.Packet) error {
 //
 // If the packet read was not implemented, a *packet.Unknown is returned, containing the raw payload of the
 // packet read.
+//
+// State that the Conn keeps track of and exposes through its methods, such as the player list or the entities
+// spawned, is only updated as packets are read using ReadPacket, so ReadPacket must be called continuously for
+// it to stay up to date. State that may grow large, such as the entities, is only kept if enabled using the
+// Dialer, while small state of a fixed size, such as the time of the world and the attributes of the player,
+// is always kept.
 func (conn *Conn) ReadPacket() (pk packet.Packet, err error) {
+	if pk, err = conn.readPacket(); err != nil {
+		return nil, err
+	}
+	conn.observePacket(pk)
+	return pk, nil
+}
+
+// readPacket reads the next packet from the Conn, either from the additional packets produced by a previous
+// conversion, from the deferred packets or from the packets channel.
+func (conn *Conn) readPacket() (pk packet.Packet, err error) {
 	if len(conn.additional) > 0 {
 		return <-conn.additional, nil
 	}
-	if data, ok := conn.takeDeferredPacket(); ok {
-		pk, err := data.decode(conn)
-		if err != nil {
-			conn.log.Println(err)
-			return conn.ReadPacket()
+	data, err := conn.readPacketData("read packet")
+	if err != nil {
+		return nil, err
+	}
+	pks, err := data.decode(conn)
+	if err != nil {
+		if isFatal(err) {
+			// decode closed the connection, so the next read returns the error the Conn was closed with.
+			conn.log.Error("decode packet", "id", data.h.PacketID, "err", err)
+			return conn.readPacket()
 		}
-		if len(pk) == 0 {
-			return conn.ReadPacket()
+		// Packets with too many bytes are still returned, whereas packets with too few bytes are skipped.
+		conn.log.Warn("decode packet", "id", data.h.PacketID, "err", err)
+	}
+	if len(pks) == 0 {
+		return conn.readPacket()
+	}
+	for _, additional := range pks[1:] {
+		conn.additional <- additional
+	}
+	return pks[0], nil
+}
+
+// readPacketData reads the data of the next packet from the Conn without decoding it, either from the
+// deferred packets or from the packets channel. If the background reader of the Conn was stopped because
+// Dialer.ManualRead is set, a batch of packets is read from the underlying connection if neither holds a
+// packet. The op passed is used for errors returned.
+func (conn *Conn) readPacketData(op string) (*packetData, error) {
+	for {
+		if data, ok := conn.takeDeferredPacket(); ok {
+			return data, nil
 		}
-		for _, additional := range pk[1:] {
-			conn.additional <- additional
+		select {
+		case <-conn.close:
+			return nil, conn.closeErr(op)
+		case <-conn.readDeadline:
+			return nil, conn.wrap(context.DeadlineExceeded, op)
+		case data := <-conn.packets:
+			return data, nil
+		case <-conn.readerStopped:
+			select {
+			case data := <-conn.packets:
+				return data, nil
+			default:
+			}
+			if err := conn.readBatch(op); err != nil {
+				return nil, err
+			}
 		}
-		return pk[0], nil
 	}
+}
 
-	select {
-	case <-conn.close:
-		return nil, conn.closeErr("read packet")
-	case <-conn.readDeadline:
-		return nil, conn.wrap(context.DeadlineExceeded, "read packet")
-	case data := <-conn.packets:
-		pk, err := data.decode(conn)
-		if err != nil {
-			conn.log.Println(err)
-			return conn.ReadPacket()
+// readBatch reads a batch of packets from the underlying connection on the calling goroutine and receives
+// them, like the background reader does until it is stopped. The connection is closed if reading fails for
+// any reason other than the read deadline passing.
+func (conn *Conn) readBatch(op string) error {
+	_ = conn.conn.SetReadDeadline(conn.readDeadlineTime)
+	packets, err := conn.decode()
+	if err != nil {
+		if isDeadlineExceeded(err) {
+			return conn.wrap(context.DeadlineExceeded, op)
+		}
+		if !raknet.ErrConnectionClosed(err) {
+			conn.log.Error("read from connection", "err", err)
+		}
+		_ = conn.Close()
+		return conn.closeErr(op)
+	}
+	for _, data := range packets {
+		if err := conn.receive(data); err != nil {
+			if !isFatal(err) {
+				conn.log.Warn("handle packet", "err", err)
+				continue
+			}
+			conn.log.Error("handle packet", "err", err)
+			_ = conn.Close()
+			return conn.closeErr(op)
 		}
-		if len(pk) == 0 {
-			return conn.ReadPacket()
+	}
+	return nil
+}
+
+// readWakeupInterval is the interval at which the goroutine reading packets in the background wakes up if no
+// packets arrive, to check if the Conn was closed.
+const readWakeupInterval = time.Second
+
+// readBackground decodes the next batch of packets from the underlying connection for the goroutine that
+// reads packets in the background. A read deadline is set on the underlying connection so that reading stops
+// every readWakeupInterval while no packets arrive. If the Conn was closed by then, readBackground returns
+// net.ErrClosed, even if the underlying connection has not yet acknowledged the close. Otherwise, reading is
+// resumed, so that only errors other than the deadline passing are returned.
+func (conn *Conn) readBackground() ([][]byte, error) {
+	for {
+		_ = conn.conn.SetReadDeadline(time.Now().Add(readWakeupInterval))
+		packets, err := conn.decode()
+		if err == nil || !isDeadlineExceeded(err) {
+			return packets, err
 		}
-		for _, additional := range pk[1:] {
-			conn.additional <- additional
+		select {
+		case <-conn.close:
+			// net.ErrClosed is returned so that the reading goroutine stops without logging an error.
+			return nil, net.ErrClosed
+		default:
 		}
-		return pk[0], nil
+	}
+}
+
+// isDeadlineExceeded checks if the error passed was caused by a read deadline passing.
+func isDeadlineExceeded(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// spawned checks if the Conn has completed the spawn sequence.
+func (conn *Conn) spawned() bool {
+	select {
+	case <-conn.spawn:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -398,34 +663,100 @@ func (conn *Conn) ResourcePacks() []*resource.Pack {
 // Write writes a slice of serialised packet data to the Conn. The data is buffered until the next 20th of a
 // tick, after which it is flushed to the connection. Write returns the amount of bytes written n.
 func (conn *Conn) Write(b []byte) (n int, err error) {
+	select {
+	case <-conn.close:
+		return 0, conn.closeErr("write")
+	default:
+	}
 	conn.sendMu.Lock()
 	defer conn.sendMu.Unlock()
 
+	if err := conn.reserveSendQueue(1, "write"); err != nil {
+		return 0, err
+	}
 	conn.bufferedSend = append(conn.bufferedSend, b)
 	return len(b), nil
 }
 
+// WriteRaw writes a packet with the ID passed and an already serialised payload to the Conn, such as one
+// obtained using EncodePacket. Unlike WritePacket, the payload is written as is and is not converted for the
+// protocol of the Conn, so it must have been encoded for the protocol that the Conn uses. Only the
+// serialisation of the packet may be shared between connections: The compression and encryption of the
+// packet are still done separately for every Conn when it is flushed. The payload must not be modified after
+// the call.
+func (conn *Conn) WriteRaw(id uint32, payload []byte) error {
+	select {
+	case <-conn.close:
+		return conn.closeErr("write raw")
+	default:
+	}
+	conn.sendMu.Lock()
+	defer conn.sendMu.Unlock()
+
+	if err := conn.reserveSendQueue(1, "write raw"); err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(payload)+4))
+	conn.hdr.PacketID = id
+	_ = conn.hdr.Write(buf)
+	_, _ = buf.Write(payload)
+
+	if conn.packetFunc != nil {
+		conn.packetFunc(*conn.hdr, payload, conn.LocalAddr(), conn.RemoteAddr())
+	}
+	conn.capture(true, *conn.hdr, payload)
+	conn.bufferedSend = append(conn.bufferedSend, buf.Bytes())
+	return nil
+}
+
+// EncodePacket serialises the packet passed for the latest protocol and returns its payload, which may be
+// written to any number of connections using Conn.WriteRaw together with the ID of the packet. This way, a
+// packet broadcast to many connections only has to be encoded once. The shield ID is the runtime ID of the
+// 'minecraft:shield' item found in GameData.Items, which changes how item stacks are encoded, and must be the
+// same for all connections that the payload is written to.
+func EncodePacket(pk packet.Packet, shieldID int32) []byte {
+	buf := bytes.NewBuffer(nil)
+	pk.Marshal(protocol.NewWriter(buf, shieldID))
+	return buf.Bytes()
+}
+
+// Broadcast writes the packet passed to all connections passed. The packet is encoded only once for all
+// connections that use the latest protocol and the same shield ID, and is written to them using WriteRaw.
+// Connections that use an older protocol have the packet converted and written using WritePacket. The
+// compression and encryption of the packet are done separately for every connection when it is flushed.
+// Broadcast does not stop at connections that fail: The error returned for conns[i] is held in errs[i], which
+// is nil if writing to the connection succeeded.
+func Broadcast(conns []*Conn, pk packet.Packet) (errs []error) {
+	errs = make([]error, len(conns))
+	payloads := make(map[int32][]byte)
+	for i, conn := range conns {
+		if conn.proto.ID() != protocol.CurrentProtocol {
+			errs[i] = conn.WritePacket(pk)
+			continue
+		}
+		shieldID := conn.shieldID.Load()
+		payload, ok := payloads[shieldID]
+		if !ok {
+			payload = EncodePacket(pk, shieldID)
+			payloads[shieldID] = payload
+		}
+		errs[i] = conn.WriteRaw(pk.ID(), payload)
+	}
+	return errs
+}
+
 // Read reads a packet from the connection into the byte slice passed, provided the byte slice is big enough
 // to carry the full packet.
 // It is recommended to use ReadPacket() rather than Read() in cases where reading is done directly.
 func (conn *Conn) Read(b []byte) (n int, err error) {
-	if data, ok := conn.takeDeferredPacket(); ok {
-		if len(b) < len(data.full) {
-			return 0, conn.wrap(errBufferTooSmall, "read")
-		}
-		return copy(b, data.full), nil
+	data, err := conn.readPacketData("read")
+	if err != nil {
+		return 0, err
 	}
-	select {
-	case <-conn.close:
-		return 0, conn.closeErr("read")
-	case <-conn.readDeadline:
-		return 0, conn.wrap(context.DeadlineExceeded, "read")
-	case data := <-conn.packets:
-		if len(b) < len(data.full) {
-			return 0, conn.wrap(errBufferTooSmall, "read")
-		}
-		return copy(b, data.full), nil
+	if len(b) < len(data.full) {
+		return 0, conn.wrap(errBufferTooSmall, "read")
 	}
+	return copy(b, data.full), nil
 }
 
 // Flush flushes the packets currently buffered by the connections to the underlying net.Conn, so that they
@@ -438,11 +769,15 @@ func (conn *Conn) Flush() error {
 	}
 	conn.sendMu.Lock()
 	defer conn.sendMu.Unlock()
+	return conn.flush()
+}
 
+// flush encodes and sends all packets currently buffered. conn.sendMu must be held when calling flush.
+func (conn *Conn) flush() error {
 	if len(conn.bufferedSend) > 0 {
-		if err := conn.enc.Encode(conn.bufferedSend); err != nil && !raknet.ErrConnectionClosed(err) {
-			// Should never happen.
-			panic(fmt.Errorf("error encoding packet batch: %v", err))
+		err := conn.enc.Encode(conn.bufferedSend)
+		if err == nil {
+			conn.reportSizes(conn.bufferedSend, conn.enc.BatchSize(), conn.LocalAddr(), conn.RemoteAddr())
 		}
 		// First manually clear out conn.bufferedSend so that re-using the slice after resetting its length to
 		// 0 doesn't result in an 'invisible' memory leak.
@@ -452,6 +787,12 @@ func (conn *Conn) Flush() error {
 		// Slice the conn.bufferedSend to a length of 0 so we don't have to re-allocate space in this slice
 		// every time.
 		conn.bufferedSend = conn.bufferedSend[:0]
+		conn.sendCond.Broadcast()
+		if err != nil && !raknet.ErrConnectionClosed(err) {
+			// The underlying connection broke or was closed by the other end, so the packets buffered could
+			// not be sent and never will be.
+			return conn.wrap(fmt.Errorf("encode packet batch: %w", err), "flush")
+		}
 	}
 	return nil
 }
@@ -463,17 +804,72 @@ func (conn *Conn) Close() error {
 	conn.once.Do(func() {
 		err = conn.Flush()
 		close(conn.close)
+		conn.cancel(conn.closeErr("close"))
 		_ = conn.conn.Close()
+		conn.stackRequests.close()
+		conn.forms.close()
+		conn.structures.close()
+
+		// Wake up any writes waiting for the send queue to be flushed, so that they return.
+		conn.sendMu.Lock()
+		conn.sendCond.Broadcast()
+		conn.sendMu.Unlock()
 	})
 	return err
 }
 
-// LocalAddr returns the local address of the underlying connection.
+// Context returns a context.Context that is cancelled once the Conn is closed, either by a call to Close or
+// because the connection was closed by the other end. It may be used to bind goroutines or requests to the
+// lifetime of the Conn. After cancellation, context.Cause returns an error describing why the Conn was
+// closed, such as a DisconnectError if the other end sent a Disconnect packet.
+func (conn *Conn) Context() context.Context {
+	return conn.ctx
+}
+
+// CloseGracefully closes the Conn after making sure all packets written to it arrived at the other end. If
+// message is not empty, a packet.Disconnect with the message is sent before closing. CloseGracefully flushes
+// all buffered packets and closes the underlying connection, after which it waits for the connection to be
+// closed completely. For RakNet connections, this happens once all packets sent were acknowledged by the other
+// end, which may take several seconds.
+// If the connection is not closed within the timeout passed, it is closed forcefully and a CloseTimeoutError
+// wrapped in a net.OpError is returned.
+func (conn *Conn) CloseGracefully(message string, timeout time.Duration) error {
+	select {
+	case <-conn.close:
+		return conn.closeErr("close")
+	default:
+	}
+	if message != "" {
+		if err := conn.WritePacket(&packet.Disconnect{Message: message}); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	// Closing the underlying connection will eventually make the goroutine reading packets from it return,
+	// which in turn closes the Conn.
+	_ = conn.conn.Close()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-conn.close:
+		return nil
+	case <-t.C:
+		_ = conn.Close()
+		return conn.wrap(CloseTimeoutError{Timeout: timeout}, "close")
+	}
+}
+
+// LocalAddr returns the local address of the underlying connection. For RakNet connections, this is the
+// address of the UDP socket. LocalAddr may still be called after the Conn is closed.
 func (conn *Conn) LocalAddr() net.Addr {
 	return conn.conn.LocalAddr()
 }
 
-// RemoteAddr returns the remote address of the underlying connection.
+// RemoteAddr returns the remote address of the underlying connection. For RakNet connections, this is the
+// address of the RakNet peer. RemoteAddr may still be called after the Conn is closed.
 func (conn *Conn) RemoteAddr() net.Addr {
 	return conn.conn.RemoteAddr()
 }
@@ -487,6 +883,7 @@ func (conn *Conn) SetDeadline(t time.Time) error {
 // SetReadDeadline sets the read deadline of the Conn to the time passed. The time must be after time.Now().
 // Passing an empty time.Time to the method (time.Time{}) results in the read deadline being cleared.
 func (conn *Conn) SetReadDeadline(t time.Time) error {
+	conn.readDeadlineTime = t
 	empty := time.Time{}
 	if t == empty {
 		conn.readDeadline = make(chan time.Time)
@@ -514,6 +911,64 @@ func (conn *Conn) Latency() time.Duration {
 	panic(fmt.Sprintf("connection type %T has no Latency() time.Duration method", conn.conn))
 }
 
+// Compression returns the packet.Compression used to compress packets sent over the Conn. For a Conn obtained
+// using a Listener, this is the compression set in the ListenConfig. For a Conn obtained using a Dialer, this
+// is the compression that the server selected in its NetworkSettings packet. Compression returns nil if
+// compression has not yet been negotiated.
+func (conn *Conn) Compression() packet.Compression {
+	conn.sendMu.Lock()
+	defer conn.sendMu.Unlock()
+	return conn.compression
+}
+
+// SetCompression changes the compression used for packets sent over the Conn after compression was
+// negotiated. Batches smaller than threshold bytes are sent uncompressed. Packets written before the call to
+// SetCompression are first flushed using the previous compression. Compressed packets received are always
+// decompressed using the algorithm they were compressed with, so SetCompression does not affect reading.
+// A Conn obtained using a Dialer calls SetCompression by itself when it reads a NetworkSettings packet after
+// logging in. For a Conn obtained using a Listener, SetCompression should be called directly after writing a
+// NetworkSettings packet that holds the new compression, such as when relaying one as a proxy.
+func (conn *Conn) SetCompression(compression packet.Compression, threshold int) error {
+	if compression == nil {
+		return conn.wrap(fmt.Errorf("compression must not be nil"), "set compression")
+	}
+	if threshold < 0 {
+		return conn.wrap(fmt.Errorf("compression threshold must not be negative, got %v", threshold), "set compression")
+	}
+	select {
+	case <-conn.close:
+		return conn.closeErr("set compression")
+	default:
+	}
+	conn.sendMu.Lock()
+	defer conn.sendMu.Unlock()
+
+	// Packets that are still buffered were written expecting the previous compression, so they must be sent
+	// before switching.
+	if err := conn.flush(); err != nil {
+		return err
+	}
+	conn.compression = compression
+	conn.enc.EnableCompression(compression)
+	conn.enc.SetCompressionThreshold(threshold)
+	conn.dec.EnableCompression()
+	return nil
+}
+
+// networkSettingsCompression returns the compression and threshold held in the NetworkSettings packet
+// passed. A threshold of 0 means that compression is disabled altogether.
+func networkSettingsCompression(pk *packet.NetworkSettings) (packet.Compression, int, error) {
+	alg, ok := packet.CompressionByID(pk.CompressionAlgorithm)
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown compression algorithm: %v", pk.CompressionAlgorithm)
+	}
+	if pk.CompressionThreshold == 0 {
+		// A threshold of 0 means the server disabled compression altogether.
+		alg = packet.NopCompression
+	}
+	return alg, int(pk.CompressionThreshold), nil
+}
+
 // ClientCacheEnabled checks if the connection has the client blob cache enabled. If true, the server may send
 // blobs to the client to reduce network transmission, but if false, the client does not support it, and the
 // server must send chunks as usual.
@@ -566,23 +1021,14 @@ func (conn *Conn) receive(data []byte) error {
 		if err != nil {
 			return err
 		}
-		conn.disconnectMessage.Store(&pks[0].(*packet.Disconnect).Message)
+		pk := pks[0].(*packet.Disconnect)
+		conn.disconnectPacket.Store(pk)
+		conn.disconnectMessage.Store(&pk.Message)
 		_ = conn.Close()
 		return nil
 	}
 	if conn.loggedIn && !conn.waitingForSpawn.Load() {
-		select {
-		case <-conn.close:
-		case previous := <-conn.packets:
-			// There was already a packet in this channel, so take it out and defer it so that it is read
-			// next.
-			conn.deferPacket(previous)
-		default:
-		}
-		select {
-		case <-conn.close:
-		case conn.packets <- pkData:
-		}
+		conn.queuePacket(pkData)
 		return nil
 	}
 	return conn.handle(pkData)
@@ -595,7 +1041,14 @@ func (conn *Conn) handle(pkData *packetData) error {
 			// If the packet was expected, so we handle it right now.
 			pks, err := pkData.decode(conn)
 			if err != nil {
-				return err
+				if len(pks) == 0 {
+					// The packet is part of the login sequence, which cannot be continued without it, so the
+					// error is always fatal.
+					return fmt.Errorf("decode packet %v: %v", pkData.h.PacketID, err)
+				}
+				// The packet had bytes left over, but was decoded otherwise, and invalid packets are
+				// allowed, so we log the error and continue handling it.
+				conn.log.Warn("decode packet", "id", pkData.h.PacketID, "err", err)
 			}
 			return conn.handleMultiple(pks)
 		}
@@ -666,6 +1119,109 @@ func (conn *Conn) handlePacket(pk packet.Packet) error {
 	return nil
 }
 
+// observePacket is called for every packet returned by ReadPacket. It updates any state maintained by the
+// Conn that depends on packets sent after the login sequence.
+func (conn *Conn) observePacket(pk packet.Packet) {
+	switch pk := pk.(type) {
+	case *packet.ItemStackResponse:
+		conn.stackRequests.resolve(pk.Responses)
+	case *packet.StructureTemplateDataResponse:
+		conn.structures.resolve(pk)
+	case *packet.AvailableCommands:
+		conn.commands.handleAvailableCommands(pk)
+	case *packet.UpdateSoftEnum:
+		conn.commands.handleUpdateSoftEnum(pk)
+	case *packet.ItemComponent:
+		conn.handleItemComponent(pk)
+	case *packet.BiomeDefinitionList:
+		conn.handleBiomeDefinitionList(pk)
+	case *packet.AvailableActorIdentifiers:
+		conn.handleAvailableActorIdentifiers(pk)
+	case *packet.PlayerSkin:
+		conn.players.handlePlayerSkin(pk)
+	case *packet.PlayerList:
+		conn.players.handlePlayerList(pk)
+	case *packet.NetworkStackLatency:
+		conn.handleNetworkStackLatency(pk)
+	case *packet.LevelChunk:
+		conn.handleLevelChunkBlobs(pk)
+	case *packet.SubChunk:
+		conn.handleSubChunkBlobs(pk)
+	case *packet.ClientCacheMissResponse:
+		conn.handleClientCacheMissResponse(pk)
+	case *packet.Transfer:
+		conn.handleTransfer(pk)
+	case *packet.PacketViolationWarning:
+		conn.handlePacketViolationWarning(pk)
+	case *packet.UpdateAbilities:
+		conn.handleUpdateAbilities(pk)
+	case *packet.AdventureSettings:
+		conn.handleAdventureSettings(pk)
+	case *packet.AddActor:
+		conn.entities.handleAddActor(pk)
+		conn.effects.handleAddEntity(pk.EntityUniqueID, pk.EntityRuntimeID)
+	case *packet.AddPlayer:
+		conn.effects.handleAddEntity(pk.AbilityData.EntityUniqueID, pk.EntityRuntimeID)
+	case *packet.MoveActorAbsolute:
+		conn.entities.handleMoveActorAbsolute(pk)
+	case *packet.MoveActorDelta:
+		conn.entities.handleMoveActorDelta(pk)
+	case *packet.RemoveActor:
+		conn.entities.handleRemoveActor(pk)
+		conn.effects.handleRemoveActor(pk)
+	case *packet.SetActorData:
+		conn.entities.handleSetActorData(pk)
+	case *packet.MobEffect:
+		conn.effects.handleMobEffect(pk)
+	case *packet.UpdateAttributes:
+		conn.attributes.handleUpdateAttributes(pk, conn.gameData.EntityRuntimeID)
+	case *packet.ModalFormResponse:
+		conn.forms.resolve(pk)
+	case *packet.CommandOutput:
+		conn.commandOutputs.resolve(pk)
+	case *packet.BossEvent:
+		conn.bossBars.handleBossEvent(pk)
+	case *packet.SetDisplayObjective:
+		conn.scoreboards.handleSetDisplayObjective(pk)
+	case *packet.SetScore:
+		conn.scoreboards.handleSetScore(pk)
+	case *packet.RemoveObjective:
+		conn.scoreboards.handleRemoveObjective(pk)
+	case *packet.SetScoreboardIdentity:
+		conn.scoreboards.handleSetScoreboardIdentity(pk)
+	case *packet.SetTime:
+		conn.worldTime.handleSetTime(pk)
+	case *packet.GameRulesChanged:
+		conn.worldTime.handleGameRulesChanged(pk)
+	case *packet.CraftingData:
+		conn.recipes.handleCraftingData(pk)
+	case *packet.Emote:
+		conn.handleEmote(pk)
+	case *packet.Respawn:
+		conn.handleRespawn(pk)
+	case *packet.MobEquipment:
+		conn.interaction.handleMobEquipment(pk, conn.gameData.EntityRuntimeID)
+	case *packet.MovePlayer:
+		conn.interaction.handleMovePlayer(pk, conn.gameData.EntityRuntimeID)
+	case *packet.NetworkSettings:
+		if !conn.readerLimits {
+			conn.handleRenegotiatedNetworkSettings(pk)
+		}
+	}
+}
+
+// handleRenegotiatedNetworkSettings switches to the compression held in a NetworkSettings packet sent by the
+// server after logging in.
+func (conn *Conn) handleRenegotiatedNetworkSettings(pk *packet.NetworkSettings) {
+	alg, threshold, err := networkSettingsCompression(pk)
+	if err == nil {
+		err = conn.SetCompression(alg, threshold)
+	}
+	if err != nil {
+		conn.log.Warn("renegotiate compression", "err", err)
+	}
+}
+
 // handleRequestNetworkSettings handles an incoming RequestNetworkSettings packet. It returns an error if the protocol
 // version is not supported, otherwise sending back a NetworkSettings packet.
 func (conn *Conn) handleRequestNetworkSettings(pk *packet.RequestNetworkSettings) error {
@@ -685,31 +1241,35 @@ func (conn *Conn) handleRequestNetworkSettings(pk *packet.RequestNetworkSettings
 			status = packet.PlayStatusLoginFailedServer
 		}
 		_ = conn.WritePacket(&packet.PlayStatus{Status: status})
-		return fmt.Errorf("%v connected with an incompatible protocol: expected protocol = %v, client protocol = %v", conn.identityData.DisplayName, protocol.CurrentProtocol, pk.ClientProtocol)
+		return fmt.Errorf("client connected with an incompatible protocol: %w", LoginFailedError{Status: status, ExpectedProtocol: protocol.CurrentProtocol, GotProtocol: pk.ClientProtocol})
 	}
 
 	conn.expect(packet.IDLogin)
+	const threshold = 512
 	if err := conn.WritePacket(&packet.NetworkSettings{
-		CompressionThreshold: 512,
+		CompressionThreshold: threshold,
 		CompressionAlgorithm: conn.compression.EncodeCompression(),
 	}); err != nil {
 		return fmt.Errorf("error sending network settings: %v", err)
 	}
-	_ = conn.Flush()
-	conn.enc.EnableCompression(conn.compression)
-	conn.dec.EnableCompression()
+	if err := conn.SetCompression(conn.compression, threshold); err != nil {
+		return err
+	}
+	conn.reachStage(HandshakeStageNetworkSettings)
 	return nil
 }
 
 // handleNetworkSettings handles an incoming NetworkSettings packet, enabling compression for future packets.
 func (conn *Conn) handleNetworkSettings(pk *packet.NetworkSettings) error {
-	alg, ok := packet.CompressionByID(pk.CompressionAlgorithm)
-	if !ok {
-		return fmt.Errorf("unknown compression algorithm: %v", pk.CompressionAlgorithm)
+	alg, threshold, err := networkSettingsCompression(pk)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetCompression(alg, threshold); err != nil {
+		return err
 	}
-	conn.enc.EnableCompression(alg)
-	conn.dec.EnableCompression()
 	conn.readyToLogin = true
+	conn.reachStage(HandshakeStageNetworkSettings)
 	return nil
 }
 
@@ -726,20 +1286,38 @@ func (conn *Conn) handleLogin(pk *packet.Login) error {
 	if err != nil {
 		return fmt.Errorf("parse login request: %w", err)
 	}
+	conn.authenticated = authResult.XBOXLiveAuthenticated
 
 	// Make sure the player is logged in with XBOX Live when necessary.
 	if !authResult.XBOXLiveAuthenticated && conn.authEnabled {
 		_ = conn.WritePacket(&packet.Disconnect{Message: text.Colourf("<red>You must be logged in with XBOX Live to join.</red>")})
 		return fmt.Errorf("connection %v was not authenticated to XBOX Live", conn.RemoteAddr())
 	}
+	if conn.identityFunc != nil {
+		if message, ok := conn.identityFunc(conn.identityData); !ok {
+			_ = conn.WritePacket(&packet.Disconnect{Message: message})
+			return fmt.Errorf("connection %v with XUID %q was rejected: %v", conn.RemoteAddr(), conn.identityData.XUID, message)
+		}
+	}
+	if conn.disableEncryption {
+		// Without encryption, the login sequence continues as if the client had already responded to the
+		// ServerToClientHandshake.
+		return conn.handleClientToServerHandshake()
+	}
 	if err := conn.enableEncryption(authResult.PublicKey); err != nil {
 		return fmt.Errorf("error enabling encryption: %v", err)
 	}
+	conn.reachStage(HandshakeStageEncryption)
 	return nil
 }
 
 // handleClientToServerHandshake handles an incoming ClientToServerHandshake packet.
 func (conn *Conn) handleClientToServerHandshake() error {
+	if conn.rawHandshake {
+		// Encryption is enabled, so the rest of the login sequence is left to the user.
+		conn.loggedIn = true
+		return nil
+	}
 	// The next expected packet is a resource pack client response.
 	conn.expect(packet.IDResourcePackClientResponse, packet.IDClientCacheStatus)
 	if err := conn.WritePacket(&packet.PlayStatus{Status: packet.PlayStatusLoginSuccess}); err != nil {
@@ -777,6 +1355,7 @@ func (conn *Conn) handleClientToServerHandshake() error {
 	if err := conn.WritePacket(pk); err != nil {
 		return fmt.Errorf("error sending resource packs info: %v", err)
 	}
+	conn.reachStage(HandshakeStagePacksStarted)
 	return nil
 }
 
@@ -789,9 +1368,67 @@ type saltClaims struct {
 // on the client side of the connection, using the hash and the public key from the server exposed in the
 // packet.
 func (conn *Conn) handleServerToClientHandshake(pk *packet.ServerToClientHandshake) error {
-	tok, err := jwt.ParseSigned(string(pk.JWT))
+	pub, salt, err := conn.verifyServerHandshake(pk.JWT)
+	if err != nil {
+		var handshakeErr error = HandshakeError{Err: err}
+		conn.loginErr.Store(&handshakeErr)
+		return handshakeErr
+	}
+
+	x, _ := pub.Curve.ScalarMult(pub.X, pub.Y, conn.privateKey.D.Bytes())
+	// Make sure to pad the shared secret up to 96 bytes.
+	sharedSecret := append(bytes.Repeat([]byte{0}, 48-len(x.Bytes())), x.Bytes()...)
+
+	keyBytes := sha256.Sum256(append(salt, sharedSecret...))
+
+	// Finally we enable encryption for the enc and dec using the secret pubKey bytes we produced. The server
+	// enabled encryption right after sending the ServerToClientHandshake, so any packets still buffered must
+	// be sent unencrypted first, and the encryption of the encoder must not be enabled in the middle of a
+	// flush on another goroutine.
+	conn.sendMu.Lock()
+	if err := conn.flush(); err != nil {
+		conn.sendMu.Unlock()
+		return err
+	}
+	conn.enc.EnableEncryption(keyBytes)
+	conn.sendMu.Unlock()
+	conn.dec.EnableEncryption(keyBytes)
+	conn.encrypted = true
+	if conn.exposeKey {
+		conn.encryptionKey.Store(&keyBytes)
+	}
+
+	// We write a ClientToServerHandshake packet (which has no payload) as a response. It is the first packet
+	// that is encrypted, confirming to the server that encryption was enabled. The server does not continue
+	// the login sequence until it receives it, so it is flushed immediately rather than on the next tick.
+	if err := conn.WritePacket(&packet.ClientToServerHandshake{}); err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	conn.reachStage(HandshakeStageEncryption)
+	if conn.rawHandshake {
+		// Encryption is enabled, so the rest of the login sequence is left to the user.
+		conn.expect()
+		conn.loggedIn = true
+	}
+	return nil
+}
+
+// verifyServerHandshake verifies the JWT held in a ServerToClientHandshake packet and returns the public key
+// of the server and the salt it holds. The JWT must be signed using ES384 with the key found in its x5u
+// header, which must be the key set in Dialer.ServerPublicKey, if any.
+func (conn *Conn) verifyServerHandshake(data []byte) (*ecdsa.PublicKey, []byte, error) {
+	tok, err := jwt.ParseSigned(string(data))
 	if err != nil {
-		return fmt.Errorf("parse server token: %w", err)
+		return nil, nil, fmt.Errorf("parse server token: %w", err)
+	}
+	if len(tok.Headers) != 1 {
+		return nil, nil, fmt.Errorf("server token must have exactly one signature, got %v", len(tok.Headers))
+	}
+	if alg := tok.Headers[0].Algorithm; alg != string(jose.ES384) {
+		return nil, nil, fmt.Errorf("server token must be signed using %v, got %v", jose.ES384, alg)
 	}
 	//lint:ignore S1005 Double assignment is done explicitly to prevent panics.
 	raw, _ := tok.Headers[0].ExtraHeaders["x5u"]
@@ -799,32 +1436,28 @@ func (conn *Conn) handleServerToClientHandshake(pk *packet.ServerToClientHandsha
 
 	pub := new(ecdsa.PublicKey)
 	if err := login.ParsePublicKey(kStr, pub); err != nil {
-		return fmt.Errorf("parse server public key: %w", err)
+		return nil, nil, fmt.Errorf("parse server public key: %w", err)
+	}
+	if pub.Curve != elliptic.P384() {
+		return nil, nil, fmt.Errorf("server public key must use curve P-384, got %v", pub.Curve.Params().Name)
+	}
+	if conn.serverKey != nil && !conn.serverKey.Equal(pub) {
+		return nil, nil, fmt.Errorf("server public key does not match the expected key")
 	}
 
 	var c saltClaims
 	if err := tok.Claims(pub, &c); err != nil {
-		return fmt.Errorf("verify claims: %w", err)
+		return nil, nil, fmt.Errorf("verify claims: %w", err)
 	}
 	c.Salt = strings.TrimRight(c.Salt, "=")
 	salt, err := base64.RawStdEncoding.DecodeString(c.Salt)
 	if err != nil {
-		return fmt.Errorf("error base64 decoding ServerToClientHandshake salt: %v", err)
+		return nil, nil, fmt.Errorf("error base64 decoding ServerToClientHandshake salt: %v", err)
 	}
-
-	x, _ := pub.Curve.ScalarMult(pub.X, pub.Y, conn.privateKey.D.Bytes())
-	// Make sure to pad the shared secret up to 96 bytes.
-	sharedSecret := append(bytes.Repeat([]byte{0}, 48-len(x.Bytes())), x.Bytes()...)
-
-	keyBytes := sha256.Sum256(append(salt, sharedSecret...))
-
-	// Finally we enable encryption for the enc and dec using the secret pubKey bytes we produced.
-	conn.enc.EnableEncryption(keyBytes)
-	conn.dec.EnableEncryption(keyBytes)
-
-	// We write a ClientToServerHandshake packet (which has no payload) as a response.
-	_ = conn.WritePacket(&packet.ClientToServerHandshake{})
-	return nil
+	if len(salt) == 0 {
+		return nil, nil, fmt.Errorf("server token has no salt")
+	}
+	return pub, salt, nil
 }
 
 // handleClientCacheStatus handles a ClientCacheStatus packet sent by the client. It specifies if the client
@@ -837,6 +1470,7 @@ func (conn *Conn) handleClientCacheStatus(pk *packet.ClientCacheStatus) error {
 // handleResourcePacksInfo handles a ResourcePacksInfo packet sent by the server. The client responds by
 // sending the packs it needs downloaded.
 func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
+	conn.reachStage(HandshakeStagePacksStarted)
 	// First create a new resource pack queue with the information in the packet so we can download them
 	// properly later.
 	totalPacks := len(pk.TexturePacks) + len(pk.BehaviourPacks)
@@ -844,12 +1478,13 @@ func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
 		packAmount:       totalPacks,
 		downloadingPacks: make(map[string]downloadingPack),
 		awaitingPacks:    make(map[string]*downloadingPack),
+		behaviourPacks:   make(map[string]bool),
 	}
 	packsToDownload := make([]string, 0, totalPacks)
 
 	for index, pack := range pk.TexturePacks {
 		if _, ok := conn.packQueue.downloadingPacks[pack.UUID]; ok {
-			conn.log.Printf("duplicate texture pack entry %v in resource pack info\n", pack.UUID)
+			conn.log.Warn("duplicate texture pack entry in resource pack info", "uuid", pack.UUID)
 			conn.packQueue.packAmount--
 			continue
 		}
@@ -866,13 +1501,13 @@ func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
 		conn.packQueue.downloadingPacks[pack.UUID] = downloadingPack{
 			size:       pack.Size,
 			buf:        bytes.NewBuffer(make([]byte, 0, pack.Size)),
-			newFrag:    make(chan []byte),
+			newFrag:    make(chan *packet.ResourcePackChunkData, 1),
 			contentKey: pack.ContentKey,
 		}
 	}
 	for index, pack := range pk.BehaviourPacks {
 		if _, ok := conn.packQueue.downloadingPacks[pack.UUID]; ok {
-			conn.log.Printf("duplicate behaviour pack entry %v in resource pack info\n", pack.UUID)
+			conn.log.Warn("duplicate behaviour pack entry in resource pack info", "uuid", pack.UUID)
 			conn.packQueue.packAmount--
 			continue
 		}
@@ -889,9 +1524,10 @@ func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
 		conn.packQueue.downloadingPacks[pack.UUID] = downloadingPack{
 			size:       pack.Size,
 			buf:        bytes.NewBuffer(make([]byte, 0, pack.Size)),
-			newFrag:    make(chan []byte),
+			newFrag:    make(chan *packet.ResourcePackChunkData, 1),
 			contentKey: pack.ContentKey,
 		}
+		conn.packQueue.behaviourPacks[pack.UUID] = true
 	}
 
 	if len(packsToDownload) != 0 {
@@ -902,10 +1538,23 @@ func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
 		})
 		return nil
 	}
-	conn.expect(packet.IDResourcePackStack)
+	conn.finishResourcePackDownloads()
+	return nil
+}
 
+// finishResourcePackDownloads tells the server that all resource packs were downloaded and calls the
+// function passed to Dialer.ResourcePacksDownloaded, if set. This function is only called the first time
+// finishResourcePackDownloads is called.
+func (conn *Conn) finishResourcePackDownloads() {
+	conn.expect(packet.IDResourcePackStack)
 	_ = conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseAllPacksDownloaded})
-	return nil
+
+	if conn.packsDownloadedFunc != nil && conn.packsDownloaded.CompareAndSwap(false, true) {
+		conn.packMu.Lock()
+		packs := slices.Clone(conn.resourcePacks)
+		conn.packMu.Unlock()
+		conn.packsDownloadedFunc(packs)
+	}
 }
 
 // handleResourcePackStack handles a ResourcePackStack packet sent by the server. The stack defines the order
@@ -918,7 +1567,7 @@ func (conn *Conn) handleResourcePackStack(pk *packet.ResourcePackStack) error {
 			if pack.UUID == behaviourPack.UUID {
 				// We had a behaviour pack with the same UUID as the texture pack, so we drop the texture
 				// pack and log it.
-				conn.log.Printf("dropping behaviour pack with UUID %v due to a texture pack with the same UUID\n", pack.UUID)
+				conn.log.Warn("dropping behaviour pack due to a texture pack with the same UUID", "uuid", pack.UUID)
 				pk.BehaviourPacks = append(pk.BehaviourPacks[:i], pk.BehaviourPacks[i+1:]...)
 			}
 		}
@@ -933,11 +1582,13 @@ func (conn *Conn) handleResourcePackStack(pk *packet.ResourcePackStack) error {
 	}
 	conn.expect(packet.IDStartGame)
 	_ = conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseCompleted})
+	conn.reachStage(HandshakeStagePacksFinished)
 	return nil
 }
 
 // hasPack checks if the connection has a resource pack downloaded with the UUID and version passed, provided
-// the pack either has or does not have behaviours in it.
+// the server sent the pack in the behaviour pack list or the texture pack list of the ResourcePacksInfo
+// packet, depending on hasBehaviours.
 func (conn *Conn) hasPack(uuid string, version string, hasBehaviours bool) bool {
 	for _, exempted := range exemptedPacks {
 		if exempted.uuid == uuid && exempted.version == version {
@@ -955,7 +1606,7 @@ func (conn *Conn) hasPack(uuid string, version string, hasBehaviours bool) bool
 		}
 	}
 	for _, pack := range conn.resourcePacks {
-		if pack.UUID() == uuid && pack.Version() == version && pack.HasBehaviours() == hasBehaviours {
+		if pack.UUID() == uuid && pack.Version() == version && conn.packQueue.behaviourPacks[uuid] == hasBehaviours {
 			return true
 		}
 	}
@@ -1006,6 +1657,7 @@ func (conn *Conn) handleResourcePackClientResponse(pk *packet.ResourcePackClient
 			return fmt.Errorf("error writing resource pack stack packet: %v", err)
 		}
 	case packet.PackResponseCompleted:
+		conn.reachStage(HandshakeStagePacksFinished)
 		conn.loggedIn = true
 	default:
 		return fmt.Errorf("unknown resource pack client response: %v", pk.Response)
@@ -1058,6 +1710,7 @@ func (conn *Conn) startGame() {
 	})
 	_ = conn.Flush()
 	conn.expect(packet.IDRequestChunkRadius, packet.IDSetLocalPlayerAsInitialised)
+	conn.reachStage(HandshakeStageStartGame)
 }
 
 // nextResourcePackDownload moves to the next resource pack to download and sends a resource pack data info
@@ -1083,13 +1736,13 @@ func (conn *Conn) handleResourcePackDataInfo(pk *packet.ResourcePackDataInfo) er
 	pack, ok := conn.packQueue.downloadingPacks[id]
 	if !ok {
 		// We either already downloaded the pack or we got sent an invalid UUID, that did not match any pack
-		// sent in the ResourcePacksInfo packet.
-		return fmt.Errorf("unknown pack to download with UUID %v", id)
+		// sent in the ResourcePacksInfo packet. Either way, the packs that we are downloading are not affected.
+		return nonFatalError{err: fmt.Errorf("unknown pack to download with UUID %v", id)}
 	}
 	if pack.size != pk.Size {
 		// Size mismatch: The ResourcePacksInfo packet had a size for the pack that did not match with the
 		// size sent here.
-		conn.log.Printf("pack %v had a different size in the ResourcePacksInfo packet than the ResourcePackDataInfo packet\n", id)
+		conn.log.Warn("pack had a different size in the ResourcePacksInfo packet than the ResourcePackDataInfo packet", "uuid", id)
 		pack.size = pk.Size
 	}
 
@@ -1106,43 +1759,107 @@ func (conn *Conn) handleResourcePackDataInfo(pk *packet.ResourcePackDataInfo) er
 		chunkCount++
 	}
 
-	idCopy := pk.UUID
-	go func() {
-		for i := uint32(0); i < chunkCount; i++ {
-			_ = conn.WritePacket(&packet.ResourcePackChunkRequest{
-				UUID:       idCopy,
-				ChunkIndex: i,
-			})
-			select {
-			case <-conn.close:
-				return
-			case frag := <-pack.newFrag:
-				// Write the fragment to the full buffer of the downloading resource pack.
-				_, _ = pack.buf.Write(frag)
-			}
-		}
-		conn.packMu.Lock()
-		defer conn.packMu.Unlock()
+	pack.hash = pk.Hash
+
+	go conn.downloadResourcePackChunks(id, pk.UUID, &pack, chunkCount)
+	return nil
+}
+
+// packChunkTimeout is the maximum duration to wait for a requested chunk of a resource pack. If the chunk
+// does not arrive in time, the download is resumed by requesting it again.
+var packChunkTimeout = time.Second * 10
 
-		if pack.buf.Len() != int(pack.size) {
-			conn.log.Printf("incorrect resource pack size: expected %v, but got %v\n", pack.size, pack.buf.Len())
+// packChunkRetries is the maximum amount of times in a row that the download of a resource pack is resumed
+// before it is given up on.
+const packChunkRetries = 3
+
+// downloadResourcePackChunks downloads the pack passed using receiveResourcePack and adds it to the resource
+// packs of the Conn. If the download fails, the Conn is closed and the error is returned by Dial.
+func (conn *Conn) downloadResourcePackChunks(id, fullID string, pack *downloadingPack, chunkCount uint32) {
+	newPack, err := conn.receiveResourcePack(id, fullID, pack, chunkCount)
+	if err != nil {
+		if errors.Is(err, net.ErrClosed) {
 			return
 		}
-		// First parse the resource pack from the total byte buffer we obtained.
-		newPack, err := resource.Read(pack.buf)
+		err = fmt.Errorf("download resource pack %v: %w", id, err)
+		conn.loginErr.Store(&err)
+		_ = conn.Close()
+		return
+	}
+	conn.packMu.Lock()
+	conn.packQueue.packAmount--
+	// Finally we add the resource to the resource packs slice.
+	conn.resourcePacks = append(conn.resourcePacks, newPack.WithContentKey(pack.contentKey))
+	done := conn.packQueue.packAmount == 0
+	conn.packMu.Unlock()
+
+	if done {
+		conn.finishResourcePackDownloads()
+	}
+}
+
+// receiveResourcePack downloads the chunks of the pack passed one by one, starting at the expectedIndex of
+// the pack. If a chunk does not arrive within packChunkTimeout, the download is resumed from the last chunk
+// received rather than restarted. Once all chunks are received, the content is checked against the size and
+// checksum sent by the server and parsed into a resource.Pack.
+func (conn *Conn) receiveResourcePack(id, fullID string, pack *downloadingPack, chunkCount uint32) (*resource.Pack, error) {
+	retries := 0
+	for pack.expectedIndex < chunkCount {
+		_ = conn.WritePacket(&packet.ResourcePackChunkRequest{
+			UUID:       fullID,
+			ChunkIndex: pack.expectedIndex,
+		})
+		received, err := conn.awaitResourcePackChunk(pack, chunkCount)
 		if err != nil {
-			conn.log.Printf("invalid full resource pack data for UUID %v: %v\n", id, err)
-			return
+			return nil, err
 		}
-		conn.packQueue.packAmount--
-		// Finally we add the resource to the resource packs slice.
-		conn.resourcePacks = append(conn.resourcePacks, newPack.WithContentKey(pack.contentKey))
-		if conn.packQueue.packAmount == 0 {
-			conn.expect(packet.IDResourcePackStack)
-			_ = conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseAllPacksDownloaded})
+		if received {
+			retries = 0
+			continue
 		}
-	}()
-	return nil
+		if retries++; retries > packChunkRetries {
+			return nil, fmt.Errorf("chunk %v not received after %v attempts", pack.expectedIndex, retries)
+		}
+		conn.log.Warn("resuming resource pack download", "uuid", id, "chunk", pack.expectedIndex)
+	}
+	if pack.buf.Len() != int(pack.size) {
+		return nil, fmt.Errorf("incorrect size: expected %v, got %v", pack.size, pack.buf.Len())
+	}
+	if checksum := sha256.Sum256(pack.buf.Bytes()); len(pack.hash) != 0 && !bytes.Equal(checksum[:], pack.hash) {
+		return nil, fmt.Errorf("checksum mismatch: expected %x, got %x", pack.hash, checksum)
+	}
+	newPack, err := resource.Read(pack.buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack data: %w", err)
+	}
+	return newPack, nil
+}
+
+// awaitResourcePackChunk waits for the chunk with the expectedIndex of the pack passed and writes it to the
+// buffer of the pack. Chunks with a different index, which may still arrive after the download was resumed,
+// are ignored. False is returned if the chunk did not arrive within packChunkTimeout.
+func (conn *Conn) awaitResourcePackChunk(pack *downloadingPack, chunkCount uint32) (bool, error) {
+	timeout := time.NewTimer(packChunkTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-conn.close:
+			return false, net.ErrClosed
+		case <-timeout.C:
+			return false, nil
+		case chunk := <-pack.newFrag:
+			if chunk.ChunkIndex != pack.expectedIndex {
+				continue
+			}
+			if expected := pack.chunkLen(chunk.ChunkIndex, chunkCount); len(chunk.Data) != expected {
+				return false, fmt.Errorf("chunk %v had a length of %v, but expected %v", chunk.ChunkIndex, len(chunk.Data), expected)
+			}
+			// Write the fragment to the full buffer of the downloading resource pack.
+			_, _ = pack.buf.Write(chunk.Data)
+			pack.expectedIndex++
+			return true, nil
+		}
+	}
 }
 
 // handleResourcePackChunkData handles a resource pack chunk data packet, which holds a fragment of a resource
@@ -1153,53 +1870,55 @@ func (conn *Conn) handleResourcePackChunkData(pk *packet.ResourcePackChunkData)
 	if !ok {
 		// We haven't received a ResourcePackDataInfo packet from the server, so we can't use this data to
 		// download a resource pack.
-		return fmt.Errorf("resource pack chunk data for resource pack that was not being downloaded")
+		return nonFatalError{err: fmt.Errorf("resource pack chunk data for resource pack %v that was not being downloaded", pk.UUID)}
 	}
-	lastData := pack.buf.Len()+int(pack.chunkSize) >= int(pack.size)
-	if !lastData && uint32(len(pk.Data)) != pack.chunkSize {
-		// The chunk data didn't have the full size and wasn't the last data to be sent for the resource pack,
-		// meaning we got too little data.
-		return fmt.Errorf("resource pack chunk data had a length of %v, but expected %v", len(pk.Data), pack.chunkSize)
-	}
-	if pk.ChunkIndex != pack.expectedIndex {
-		return fmt.Errorf("resource pack chunk data had chunk index %v, but expected %v", pk.ChunkIndex, pack.expectedIndex)
+	select {
+	case pack.newFrag <- pk:
+	default:
+		// The chunk was not requested, or the download already moved on after resuming. Either way, the
+		// chunk is of no use anymore.
+		conn.log.Debug("dropped unexpected resource pack chunk", "uuid", pk.UUID, "chunk", pk.ChunkIndex)
 	}
-	pack.expectedIndex++
-	pack.newFrag <- pk.Data
 	return nil
 }
 
 // handleResourcePackChunkRequest handles a resource pack chunk request, which requests a part of the resource
 // pack to be downloaded.
 func (conn *Conn) handleResourcePackChunkRequest(pk *packet.ResourcePackChunkRequest) error {
-	current := conn.packQueue.currentPack
-	if current.UUID() != pk.UUID {
-		return fmt.Errorf("resource pack chunk request had unexpected UUID: expected %v, but got %v", current.UUID(), pk.UUID)
+	pack, ok := conn.packQueue.Pack(pk.UUID)
+	if !ok {
+		return nonFatalError{err: fmt.Errorf("resource pack chunk request had unknown UUID %v", pk.UUID)}
 	}
-	if conn.packQueue.currentOffset != uint64(pk.ChunkIndex)*packChunkSize {
-		return fmt.Errorf("resource pack chunk request had unexpected chunk index: expected %v, but got %v", conn.packQueue.currentOffset/packChunkSize, pk.ChunkIndex)
+	// Any chunk of a pack may be requested, so that a client may resume an interrupted download from the
+	// last chunk it received.
+	offset := uint64(pk.ChunkIndex) * packChunkSize
+	if offset >= uint64(pack.Len()) {
+		return nonFatalError{err: fmt.Errorf("resource pack chunk request had chunk index %v out of range for pack %v", pk.ChunkIndex, pk.UUID)}
 	}
 	response := &packet.ResourcePackChunkData{
 		UUID:       pk.UUID,
 		ChunkIndex: pk.ChunkIndex,
-		DataOffset: conn.packQueue.currentOffset,
+		DataOffset: offset,
 		Data:       make([]byte, packChunkSize),
 	}
-	conn.packQueue.currentOffset += packChunkSize
 	// We read the data directly into the response's data.
-	if n, err := current.ReadAt(response.Data, int64(response.DataOffset)); err != nil {
+	if n, err := pack.ReadAt(response.Data, int64(response.DataOffset)); err != nil {
 		// If we hit an EOF, we don't need to return an error, as we've simply reached the end of the content
 		// AKA the last chunk.
 		if err != io.EOF {
 			return fmt.Errorf("error reading resource pack chunk: %v", err)
 		}
 		response.Data = response.Data[:n]
-
+	}
+	if pack == conn.packQueue.currentPack && offset+packChunkSize >= uint64(pack.Len()) {
+		// The last chunk of the current pack was requested, so we move on to the next pack. Chunks of the
+		// pack may still be requested again afterwards.
+		conn.packQueue.currentPack = nil
 		defer func() {
 			if !conn.packQueue.AllDownloaded() {
 				_ = conn.nextResourcePackDownload()
 			} else {
-				conn.expect(packet.IDResourcePackClientResponse)
+				conn.expect(packet.IDResourcePackClientResponse, packet.IDResourcePackChunkRequest)
 			}
 		}()
 	}
@@ -1213,6 +1932,11 @@ func (conn *Conn) handleResourcePackChunkRequest(pk *packet.ResourcePackChunkReq
 // handleStartGame handles an incoming StartGame packet. It is the signal that the player has been added to a
 // world, and it obtains most of its dedicated properties.
 func (conn *Conn) handleStartGame(pk *packet.StartGame) error {
+	if err := conn.checkMovementSettings(pk.PlayerMovementSettings); err != nil {
+		conn.loginErr.Store(&err)
+		_ = conn.Close()
+		return err
+	}
 	conn.gameData = GameData{
 		Difficulty:                   pk.Difficulty,
 		WorldName:                    pk.WorldName,
@@ -1246,6 +1970,7 @@ func (conn *Conn) handleStartGame(pk *packet.StartGame) error {
 		Experiments:                  pk.Experiments,
 		UseBlockNetworkIDHashes:      pk.UseBlockNetworkIDHashes,
 	}
+	conn.worldTime.reset(pk.Time, pk.GameRules)
 	for _, item := range pk.Items {
 		if item.Name == "minecraft:shield" {
 			conn.shieldID.Store(int32(item.RuntimeID))
@@ -1254,6 +1979,22 @@ func (conn *Conn) handleStartGame(pk *packet.StartGame) error {
 
 	_ = conn.WritePacket(&packet.RequestChunkRadius{ChunkRadius: 16})
 	conn.expect(packet.IDChunkRadiusUpdated, packet.IDPlayStatus)
+	conn.reachStage(HandshakeStageStartGame)
+	return nil
+}
+
+// checkMovementSettings checks if the player movement settings passed, as sent by the server in the
+// StartGame packet, are compatible with the movement mode and maximum rewind history size set in the
+// Dialer. A MovementModeError is returned if they are not.
+func (conn *Conn) checkMovementSettings(settings protocol.PlayerMovementSettings) error {
+	mode, ok := conn.movementMode.Value()
+	if ok && settings.MovementType != mode {
+		return MovementModeError{Settings: settings, Mode: mode}
+	}
+	withRewind := settings.MovementType == protocol.PlayerMovementModeServerWithRewind
+	if withRewind && conn.maxRewindHistorySize != 0 && settings.RewindHistorySize > conn.maxRewindHistorySize {
+		return MovementModeError{Settings: settings, Mode: settings.MovementType, MaxRewindHistorySize: conn.maxRewindHistorySize}
+	}
 	return nil
 }
 
@@ -1312,6 +2053,7 @@ func (conn *Conn) handleSetLocalPlayerAsInitialised(pk *packet.SetLocalPlayerAsI
 		return fmt.Errorf("entity runtime ID mismatch: entity runtime ID in StartGame and SetLocalPlayerAsInitialised packets should be equal")
 	}
 	if conn.waitingForSpawn.CompareAndSwap(true, false) {
+		conn.reachStage(HandshakeStageSpawned)
 		close(conn.spawn)
 	}
 	return nil
@@ -1322,18 +2064,31 @@ func (conn *Conn) handleSetLocalPlayerAsInitialised(pk *packet.SetLocalPlayerAsI
 func (conn *Conn) handlePlayStatus(pk *packet.PlayStatus) error {
 	switch pk.Status {
 	case packet.PlayStatusLoginSuccess:
+		if !conn.encrypted {
+			if !conn.disableEncryption {
+				err := fmt.Errorf("server did not enable encryption: set Dialer.DisableEncryption to allow this")
+				conn.loginErr.Store(&err)
+				_ = conn.Close()
+				return err
+			}
+			if conn.rawHandshake {
+				// There is no handshake to wait for, so the rest of the login sequence is left to the user.
+				conn.expect()
+				conn.loggedIn = true
+				return nil
+			}
+		}
 		if err := conn.WritePacket(&packet.ClientCacheStatus{Enabled: conn.cacheEnabled}); err != nil {
 			return fmt.Errorf("error sending client cache status: %v", err)
 		}
 		// The next packet we expect is the ResourcePacksInfo packet.
 		conn.expect(packet.IDResourcePacksInfo)
 		return conn.Flush()
-	case packet.PlayStatusLoginFailedClient:
-		_ = conn.Close()
-		return fmt.Errorf("client outdated")
-	case packet.PlayStatusLoginFailedServer:
+	case packet.PlayStatusLoginFailedClient, packet.PlayStatusLoginFailedServer:
+		var err error = LoginFailedError{Status: pk.Status, ExpectedProtocol: conn.serverProtocol, GotProtocol: conn.proto.ID()}
+		conn.loginErr.Store(&err)
 		_ = conn.Close()
-		return fmt.Errorf("server outdated")
+		return err
 	case packet.PlayStatusPlayerSpawn:
 		// We've spawned and can send the last packet in the spawn sequence.
 		conn.waitingForSpawn.Store(true)
@@ -1370,6 +2125,7 @@ func (conn *Conn) tryFinaliseClientConn() {
 		conn.waitingForSpawn.Store(false)
 		conn.gameDataReceived.Store(false)
 
+		conn.reachStage(HandshakeStageSpawned)
 		close(conn.spawn)
 		conn.loggedIn = true
 		_ = conn.WritePacket(&packet.SetLocalPlayerAsInitialised{EntityRuntimeID: conn.gameData.EntityRuntimeID})
@@ -1391,8 +2147,6 @@ func (conn *Conn) enableEncryption(clientPublicKey *ecdsa.PublicKey) error {
 	if err := conn.WritePacket(&packet.ServerToClientHandshake{JWT: []byte(serverJWT)}); err != nil {
 		return fmt.Errorf("error sending ServerToClientHandshake packet: %v", err)
 	}
-	// Flush immediately as we'll enable encryption after this.
-	_ = conn.Flush()
 
 	// We first compute the shared secret.
 	x, _ := clientPublicKey.Curve.ScalarMult(clientPublicKey.X, clientPublicKey.Y, conn.privateKey.D.Bytes())
@@ -1401,9 +2155,19 @@ func (conn *Conn) enableEncryption(clientPublicKey *ecdsa.PublicKey) error {
 
 	keyBytes := sha256.Sum256(append(conn.salt, sharedSecret...))
 
-	// Finally we enable encryption for the encoder and decoder using the secret key bytes we produced.
+	// Flush immediately, so that the ServerToClientHandshake is sent unencrypted, and enable encryption for
+	// the encoder right after without another goroutine flushing in between.
+	conn.sendMu.Lock()
+	_ = conn.flush()
 	conn.enc.EnableEncryption(keyBytes)
+	conn.sendMu.Unlock()
+
+	// Finally we enable encryption for the decoder using the secret key bytes we produced.
 	conn.dec.EnableEncryption(keyBytes)
+	conn.encrypted = true
+	if conn.exposeKey {
+		conn.encryptionKey.Store(&keyBytes)
+	}
 
 	return nil
 }
@@ -1413,11 +2177,23 @@ func (conn *Conn) expect(packetIDs ...uint32) {
 	conn.expectedIDs.Store(packetIDs)
 }
 
+// versioned sets the protocol version of the protocol.IO passed to the ID of the Protocol of the Conn if it
+// has a SetProtocolVersion method, so that packets read or write the fields present in that Protocol.
+func (conn *Conn) versioned(io protocol.IO) protocol.IO {
+	if v, ok := io.(interface{ SetProtocolVersion(v int32) }); ok {
+		v.SetProtocolVersion(conn.proto.ID())
+	}
+	return io
+}
+
 // closeErr returns an adequate connection closed error for the op passed. If the connection was closed
 // through a Disconnect packet, the message is contained.
 func (conn *Conn) closeErr(op string) error {
+	if err := conn.loginErr.Load(); err != nil {
+		return conn.wrap(*err, op)
+	}
 	if msg := *conn.disconnectMessage.Load(); msg != "" {
 		return conn.wrap(DisconnectError(msg), op)
 	}
-	return conn.wrap(errClosed, op)
+	return conn.wrap(ErrConnClosed, op)
 }
@@ -0,0 +1,181 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"slices"
+	"sync"
+)
+
+// CraftingRecipe is a recipe sent by the server in a CraftingData packet, in a form shared by all supported
+// types of recipes.
+type CraftingRecipe struct {
+	// Type is the type of the recipe. It is one of protocol.RecipeShapeless, protocol.RecipeShaped,
+	// protocol.RecipeFurnace, protocol.RecipeFurnaceData or protocol.RecipeSmithingTransform. Shulker box and
+	// chemistry recipes have the type of the shapeless or shaped recipe that they are based on.
+	Type int32
+	// RecipeID is the unique ID of the recipe, such as 'minecraft:stick'. It is empty for furnace recipes.
+	RecipeID string
+	// NetworkID is the ID that the recipe is referred to with in item stack requests. It is 0 for furnace
+	// recipes, which are not crafted using item stack requests.
+	NetworkID uint32
+	// Block is the name of the block that the recipe is crafted in, not prefixed with 'minecraft:', such as
+	// 'crafting_table' or 'furnace'.
+	Block string
+	// Width and Height are the dimensions of the shape of a shaped recipe. Both are 0 for other recipes.
+	Width, Height int
+	// Input holds the ingredients of the recipe. For shaped recipes, it holds Width*Height ingredients ordered
+	// row by row, which may be obtained by their position using Ingredient. For smithing transform recipes, it
+	// holds the template, the base and the addition, in that order. For furnace recipes, it holds the item
+	// that is smelted, of which the metadata value is ignored for protocol.RecipeFurnace.
+	Input []protocol.ItemDescriptorCount
+	// Output holds the items produced by the recipe.
+	Output []protocol.ItemStack
+}
+
+// Ingredient returns the ingredient of a shaped recipe at a position in its shape, where x is the column and
+// y the row, starting at the top left. False is returned if the recipe is not shaped or if the position is
+// outside the shape.
+func (r CraftingRecipe) Ingredient(x, y int) (protocol.ItemDescriptorCount, bool) {
+	if r.Type != protocol.RecipeShaped || x < 0 || y < 0 || x >= r.Width || y >= r.Height {
+		return protocol.ItemDescriptorCount{}, false
+	}
+	if i := y*r.Width + x; i < len(r.Input) {
+		return r.Input[i], true
+	}
+	return protocol.ItemDescriptorCount{}, false
+}
+
+// Produces checks if the recipe has an item with the network ID passed in its output.
+func (r CraftingRecipe) Produces(networkID int32) bool {
+	for _, output := range r.Output {
+		if output.NetworkID == networkID {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCraftingData returns the shaped, shapeless, furnace and smithing transform recipes held in the
+// CraftingData packet passed, in the order that they are sent in. Other types of recipes, such as multi
+// recipes and smithing trim recipes, which do not produce a specific output, are left out.
+func ParseCraftingData(pk *packet.CraftingData) []CraftingRecipe {
+	recipes := make([]CraftingRecipe, 0, len(pk.Recipes))
+	for _, recipe := range pk.Recipes {
+		if r, ok := parseRecipe(recipe); ok {
+			recipes = append(recipes, r)
+		}
+	}
+	return recipes
+}
+
+// parseRecipe converts the protocol.Recipe passed to a CraftingRecipe. False is returned if the type of the
+// recipe is not supported.
+func parseRecipe(recipe protocol.Recipe) (CraftingRecipe, bool) {
+	switch r := recipe.(type) {
+	case *protocol.ShapelessRecipe:
+		return shapelessRecipe(r), true
+	case *protocol.ShulkerBoxRecipe:
+		return shapelessRecipe(&r.ShapelessRecipe), true
+	case *protocol.ShapelessChemistryRecipe:
+		return shapelessRecipe(&r.ShapelessRecipe), true
+	case *protocol.ShapedRecipe:
+		return shapedRecipe(r), true
+	case *protocol.ShapedChemistryRecipe:
+		return shapedRecipe(&r.ShapedRecipe), true
+	case *protocol.FurnaceRecipe:
+		return furnaceRecipe(r, protocol.RecipeFurnace), true
+	case *protocol.FurnaceDataRecipe:
+		return furnaceRecipe(&r.FurnaceRecipe, protocol.RecipeFurnaceData), true
+	case *protocol.SmithingTransformRecipe:
+		return CraftingRecipe{
+			Type:      protocol.RecipeSmithingTransform,
+			RecipeID:  r.RecipeID,
+			NetworkID: r.RecipeNetworkID,
+			Block:     r.Block,
+			Input:     []protocol.ItemDescriptorCount{r.Template, r.Base, r.Addition},
+			Output:    []protocol.ItemStack{r.Result},
+		}, true
+	}
+	return CraftingRecipe{}, false
+}
+
+// shapelessRecipe converts a protocol.ShapelessRecipe to a CraftingRecipe.
+func shapelessRecipe(r *protocol.ShapelessRecipe) CraftingRecipe {
+	return CraftingRecipe{
+		Type:      protocol.RecipeShapeless,
+		RecipeID:  r.RecipeID,
+		NetworkID: r.RecipeNetworkID,
+		Block:     r.Block,
+		Input:     r.Input,
+		Output:    r.Output,
+	}
+}
+
+// shapedRecipe converts a protocol.ShapedRecipe to a CraftingRecipe.
+func shapedRecipe(r *protocol.ShapedRecipe) CraftingRecipe {
+	return CraftingRecipe{
+		Type:      protocol.RecipeShaped,
+		RecipeID:  r.RecipeID,
+		NetworkID: r.RecipeNetworkID,
+		Block:     r.Block,
+		Width:     int(r.Width),
+		Height:    int(r.Height),
+		Input:     r.Input,
+		Output:    r.Output,
+	}
+}
+
+// furnaceRecipe converts a protocol.FurnaceRecipe to a CraftingRecipe with the recipe type passed.
+func furnaceRecipe(r *protocol.FurnaceRecipe, recipeType int32) CraftingRecipe {
+	input := &protocol.DefaultItemDescriptor{NetworkID: int16(r.InputType.NetworkID), MetadataValue: int16(r.InputType.MetadataValue)}
+	return CraftingRecipe{
+		Type:   recipeType,
+		Block:  r.Block,
+		Input:  []protocol.ItemDescriptorCount{{Descriptor: input, Count: 1}},
+		Output: []protocol.ItemStack{r.Output},
+	}
+}
+
+// recipeState holds the recipes sent by the server in CraftingData packets if Dialer.TrackRecipes is set.
+type recipeState struct {
+	mu      sync.Mutex
+	enabled bool
+	recipes []CraftingRecipe
+}
+
+// Recipes returns all recipes sent by the server in CraftingData packets, which are generally sent once the
+// player has spawned. It always returns nil unless the Conn was dialed with Dialer.TrackRecipes set.
+func (conn *Conn) Recipes() []CraftingRecipe {
+	conn.recipes.mu.Lock()
+	defer conn.recipes.mu.Unlock()
+	return slices.Clone(conn.recipes.recipes)
+}
+
+// RecipesFor returns all recipes sent by the server that produce an item with the network ID passed. Like
+// Recipes, it always returns nil unless the Conn was dialed with Dialer.TrackRecipes set.
+func (conn *Conn) RecipesFor(networkID int32) []CraftingRecipe {
+	conn.recipes.mu.Lock()
+	defer conn.recipes.mu.Unlock()
+	var recipes []CraftingRecipe
+	for _, r := range conn.recipes.recipes {
+		if r.Produces(networkID) {
+			recipes = append(recipes, r)
+		}
+	}
+	return recipes
+}
+
+// handleCraftingData adds the recipes held in the CraftingData packet passed, after removing all recipes
+// previously sent if the packet clears them.
+func (s *recipeState) handleCraftingData(pk *packet.CraftingData) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pk.ClearRecipes {
+		s.recipes = nil
+	}
+	s.recipes = append(s.recipes, ParseCraftingData(pk)...)
+}
@@ -0,0 +1,170 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sort"
+	"sync"
+)
+
+// BiomeDefinition is the definition of a biome, as sent by the server in the BiomeDefinitionList packet.
+type BiomeDefinition struct {
+	// Name is the name of the biome, such as 'plains'.
+	Name string
+	// Temperature is the temperature of the biome, which influences things such as the colour of grass and
+	// whether it snows or rains.
+	Temperature float32
+	// Downfall is the amount of downfall in the biome.
+	Downfall float32
+	// Data holds the full NBT data of the biome definition, including any fields not found above.
+	Data map[string]any
+}
+
+// ParseBiomeDefinitions parses the biome definitions held in the BiomeDefinitionList packet passed. The
+// definitions returned are sorted by their name.
+func ParseBiomeDefinitions(pk *packet.BiomeDefinitionList) ([]BiomeDefinition, error) {
+	var m map[string]any
+	if err := nbt.UnmarshalEncoding(pk.SerialisedBiomeDefinitions, &m, nbt.NetworkLittleEndian); err != nil {
+		return nil, fmt.Errorf("parse biome definitions: %w", err)
+	}
+	biomes := make([]BiomeDefinition, 0, len(m))
+	for name, v := range m {
+		data, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("parse biome definitions: expected compound for biome %v, got %T", name, v)
+		}
+		biomes = append(biomes, BiomeDefinition{
+			Name:        name,
+			Temperature: nbtFloat(data["temperature"]),
+			Downfall:    nbtFloat(data["downfall"]),
+			Data:        data,
+		})
+	}
+	sort.Slice(biomes, func(i, j int) bool {
+		return biomes[i].Name < biomes[j].Name
+	})
+	return biomes, nil
+}
+
+// EntityIdentifier is an entity type that is available on the server, as sent by the server in the
+// AvailableActorIdentifiers packet.
+type EntityIdentifier struct {
+	// ID is the identifier of the entity, such as 'minecraft:pig'.
+	ID string
+	// RuntimeID is the numerical runtime ID of the entity type.
+	RuntimeID int32
+	// BaseID is the identifier of the entity that the entity is based on. It is generally empty.
+	BaseID string
+	// HasSpawnEgg specifies if a spawn egg exists for the entity.
+	HasSpawnEgg bool
+	// Summonable specifies if the entity may be summoned using the /summon command.
+	Summonable bool
+	// Experimental specifies if the entity is only available with experimental features enabled.
+	Experimental bool
+}
+
+// ParseEntityIdentifiers parses the entity identifiers held in the AvailableActorIdentifiers packet passed.
+func ParseEntityIdentifiers(pk *packet.AvailableActorIdentifiers) ([]EntityIdentifier, error) {
+	var m struct {
+		IDList []map[string]any `nbt:"idlist"`
+	}
+	if err := nbt.UnmarshalEncoding(pk.SerialisedEntityIdentifiers, &m, nbt.NetworkLittleEndian); err != nil {
+		return nil, fmt.Errorf("parse entity identifiers: %w", err)
+	}
+	identifiers := make([]EntityIdentifier, 0, len(m.IDList))
+	for _, data := range m.IDList {
+		id, _ := data["id"].(string)
+		bid, _ := data["bid"].(string)
+		identifiers = append(identifiers, EntityIdentifier{
+			ID:        id,
+			RuntimeID: int32(nbtInt(data["rid"])),
+			BaseID:    bid,
+			// Older versions do not write these fields at all.
+			HasSpawnEgg:  nbtInt(data["hasspawnegg"]) != 0,
+			Summonable:   nbtInt(data["summonable"]) != 0,
+			Experimental: nbtInt(data["experimental"]) != 0,
+		})
+	}
+	return identifiers, nil
+}
+
+// nbtFloat converts a numerical NBT value to a float32. Different versions of the game write some fields using
+// different types, so all numerical types are accepted. 0 is returned for any other value.
+func nbtFloat(v any) float32 {
+	switch v := v.(type) {
+	case float32:
+		return v
+	case float64:
+		return float32(v)
+	}
+	return float32(nbtInt(v))
+}
+
+// nbtInt converts a numerical NBT value to an int64. Different versions of the game write some fields using
+// different types, so all numerical types are accepted. 0 is returned for any other value.
+func nbtInt(v any) int64 {
+	switch v := v.(type) {
+	case byte:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// definitionState holds the biome definitions and entity identifiers sent by the server.
+type definitionState struct {
+	mu                sync.Mutex
+	biomes            []BiomeDefinition
+	entityIdentifiers []EntityIdentifier
+}
+
+// Biomes returns the biome definitions sent by the server in the last BiomeDefinitionList packet, sorted by
+// name.
+func (conn *Conn) Biomes() []BiomeDefinition {
+	conn.definitions.mu.Lock()
+	defer conn.definitions.mu.Unlock()
+	return append([]BiomeDefinition(nil), conn.definitions.biomes...)
+}
+
+// EntityIdentifiers returns the entity identifiers sent by the server in the last AvailableActorIdentifiers
+// packet.
+func (conn *Conn) EntityIdentifiers() []EntityIdentifier {
+	conn.definitions.mu.Lock()
+	defer conn.definitions.mu.Unlock()
+	return append([]EntityIdentifier(nil), conn.definitions.entityIdentifiers...)
+}
+
+// handleBiomeDefinitionList parses and stores the biome definitions in the BiomeDefinitionList packet passed.
+func (conn *Conn) handleBiomeDefinitionList(pk *packet.BiomeDefinitionList) {
+	biomes, err := ParseBiomeDefinitions(pk)
+	if err != nil {
+		conn.log.Warn("handle biome definition list", "err", err)
+		return
+	}
+	conn.definitions.mu.Lock()
+	conn.definitions.biomes = biomes
+	conn.definitions.mu.Unlock()
+}
+
+// handleAvailableActorIdentifiers parses and stores the entity identifiers in the AvailableActorIdentifiers
+// packet passed.
+func (conn *Conn) handleAvailableActorIdentifiers(pk *packet.AvailableActorIdentifiers) {
+	identifiers, err := ParseEntityIdentifiers(pk)
+	if err != nil {
+		conn.log.Warn("handle available actor identifiers", "err", err)
+		return
+	}
+	conn.definitions.mu.Lock()
+	conn.definitions.entityIdentifiers = identifiers
+	conn.definitions.mu.Unlock()
+}
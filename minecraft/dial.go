@@ -8,8 +8,11 @@ import (
 	"crypto/rand"
 	_ "embed"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
 	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/google/uuid"
 	"github.com/sandertv/go-raknet"
@@ -17,44 +20,120 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"golang.org/x/oauth2"
+	"io"
 	"log"
+	"log/slog"
 	rand2 "math/rand"
 	"net"
-	"os"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
 // Dialer allows specifying specific settings for connection to a Minecraft server.
-// The zero value of Dialer is used for the package level Dial function.
+// The zero value of Dialer is used for the package level Dial function. A Dialer may be created by setting
+// its fields directly, or using NewDialer with DialerOptions such as WithClientData, which is the
+// recommended way as it remains compatible as more fields are added.
 type Dialer struct {
-	// ErrorLog is a log.Logger that errors that occur during packet handling of servers are written to. By
-	// default, ErrorLog is set to one equal to the global logger.
+	// ErrorLog is a log.Logger that errors that occur during packet handling of servers are written to. If
+	// Logger is nil and ErrorLog is set, errors are logged to the writer of ErrorLog using a slog.TextHandler.
+	// Logger should be used instead where possible.
 	ErrorLog *log.Logger
+	// Logger is the slog.Logger that errors and warnings that occur during packet handling of servers are
+	// logged to. Log records carry the remote address of the connection and, if applicable, the ID of the
+	// packet that caused the error. By default, Logger is set to a Logger that writes to ErrorLog if set, or
+	// slog.Default() otherwise.
+	Logger *slog.Logger
 
 	// ClientData is the client data used to login to the server with. It includes fields such as the skin,
 	// locale and UUIDs unique to the client. If empty, a default is sent produced using defaultClientData().
 	ClientData login.ClientData
+	// DeviceSeed, if non-empty, is used to deterministically derive the DeviceID and SelfSignedID of the
+	// ClientData if these are left empty, so that the same device identity is presented to servers every
+	// time a connection is made with the same DeviceSeed. If DeviceSeed is empty, random UUIDs are used
+	// instead, which means a server sees a new device for every connection.
+	// If DeviceSeed is non-empty and the DeviceID or SelfSignedID of the ClientData are set explicitly, they
+	// must be valid UUIDs.
+	DeviceSeed string
+	// ClientDataSeed, if non-zero, is used to seed the source of the random values that are filled out in the
+	// ClientData if left empty, such as the ClientRandomID and the SkinID, so that the same ClientData is sent
+	// every time a connection is made with the same ClientDataSeed. This is mostly useful for reproducible
+	// tests. If ClientDataSeed is 0, the values are random for every connection.
+	ClientDataSeed int64
 	// IdentityData is the identity data used to login to the server with. It includes the username, UUID and
 	// XUID of the player.
 	// The IdentityData object is obtained using Minecraft auth if Email and Password are set. If not, the
 	// object provided here is used, or a default one if left empty.
 	IdentityData login.IdentityData
+	// ServerAddress, if non-empty, is sent in the login request as the address that the player joined with, in
+	// place of the address dialed. Some servers check that it matches the hostname they expect players to
+	// join with, so it may be set to the original domain when dialing a server by IP or through a reverse
+	// proxy. It must hold both a host and a port, such as 'play.example.com:19132'.
+	ServerAddress string
+	// ThirdPartyName, if non-empty, is sent in the login request as the third party name of the player, in
+	// place of the display name of the IdentityData.
+	ThirdPartyName string
+
+	// ServerPublicKey, if non-nil, is the public key that the server must sign the ServerToClientHandshake
+	// packet with. The signature of the handshake is always verified against the key held in the handshake
+	// itself, but only a known key protects the connection against a man-in-the-middle that signs the
+	// handshake with its own key. Dialing fails with a HandshakeError if the server uses a different key. For
+	// a gophertunnel Listener, the key is returned by Listener.PublicKey.
+	ServerPublicKey *ecdsa.PublicKey
 
 	// TokenSource is the source for Microsoft Live Connect tokens. If set to a non-nil oauth2.TokenSource,
 	// this field is used to obtain tokens which in turn are used to authenticate to XBOX Live.
 	// The minecraft/auth package provides an oauth2.TokenSource implementation (auth.tokenSource) to use
 	// device auth to login.
+	// DialContext stops waiting for a token once its context is done, but cannot stop the TokenSource itself
+	// from obtaining one, unless it was created using auth.ContextTokenSource with the same context.
 	// If TokenSource is nil, the connection will not use authentication.
 	TokenSource oauth2.TokenSource
+	// HTTPClient is the *http.Client used to send the requests to XBOX Live and Minecraft that authenticate
+	// the connection if TokenSource is set, for example to send them through a proxy. It is not used to
+	// obtain tokens from the TokenSource itself: auth.ContextTokenSource may be used with a context holding
+	// the client for this. If nil, the clients of the auth package are used.
+	HTTPClient *http.Client
 
 	// PacketFunc is called whenever a packet is read from or written to the connection returned when using
 	// Dialer.Dial(). It includes packets that are otherwise covered in the connection sequence, such as the
 	// Login packet. The function is called with the header of the packet and its raw payload, the address
 	// from which the packet originated, and the destination address.
 	PacketFunc func(header packet.Header, payload []byte, src, dst net.Addr)
+	// PacketSizeFunc is called for every packet read from or written to the connection returned when using
+	// Dialer.Dial(), like PacketFunc, but with the size of the packet rather than its payload. size is the
+	// length of the packet including its header before compression. wireSize is the share of the packet in
+	// the size of the compressed and encrypted batch that it was sent in, which is divided over the packets
+	// in the batch by their size, so that the wire sizes of all packets in a batch add up to the size of the
+	// batch. PacketSizeFunc may be used to measure bandwidth per packet type.
+	PacketSizeFunc func(header packet.Header, size, wireSize int, src, dst net.Addr)
+	// HandshakeFunc is called with the Conn whenever it reaches a new HandshakeStage of the login sequence,
+	// which may be used to find out where a connection that does not finish logging in is stuck. Stages are
+	// reached in order and at most once. HandshakeFunc is called on the goroutine that handles incoming
+	// packets, before DialContext returns for stages that precede it, so it should not block for long.
+	HandshakeFunc func(conn *Conn, stage HandshakeStage)
+	// EmoteFunc is called with the Conn for every emote performed by a player, as read from an Emote packet
+	// using Conn.ReadPacket. It is called on the goroutine reading the packet, before ReadPacket returns it.
+	EmoteFunc func(conn *Conn, emote PlayerEmote)
+	// DeathFunc is called when the player of the Conn dies, which the server marks by sending a Respawn packet
+	// that makes the client search for a spawn position. The position passed is the position held in that
+	// packet, which is not necessarily the position that the player respawns at.
+	// RespawnFunc is called when the server completes a respawn requested using Conn.Respawn, with the
+	// position that the player respawned at.
+	// Both functions are called on the goroutine reading the Respawn packet using Conn.ReadPacket, before
+	// ReadPacket returns it.
+	DeathFunc, RespawnFunc func(conn *Conn, pos mgl32.Vec3)
+	// AutoRespawn, if set to true, makes the Conn request the server to respawn its player directly after it
+	// dies, as if Conn.Respawn were called after DeathFunc.
+	AutoRespawn bool
+
+	// Capture is an io.Writer that every packet read from and written to the Conn is recorded to, so that the
+	// session may be inspected or replayed later using a CaptureReader. Packets are recorded in cleartext, with
+	// a timestamp and the direction in which they were sent. See capture.go for a description of the format.
+	Capture io.Writer
 
 	// DownloadResourcePack is called individually for every texture and behaviour pack sent by the connection when
 	// using Dialer.Dial(), and can be used to stop the pack from being downloaded. The function is called with the UUID
@@ -62,11 +141,24 @@ type Dialer struct {
 	// The boolean returned determines if the pack will be downloaded or not.
 	DownloadResourcePack func(id uuid.UUID, version string, current, total int) bool
 
+	// ResourcePacksDownloaded is called once all resource packs that the server sent, except for those that
+	// DownloadResourcePack returned false for, were downloaded and the server was told so, before the
+	// StartGame packet is received. It is called with all resource packs downloaded. ResourcePacksDownloaded
+	// is called at most once, even if the server sends its resource packs again, and it is called on the
+	// goroutine that handles incoming packets, so it should not block for long.
+	ResourcePacksDownloaded func(packs []*resource.Pack)
+
 	// DisconnectOnUnknownPackets specifies if the connection should disconnect if packets received are not present
 	// in the packet pool. If true, such packets lead to the connection being closed immediately.
 	// If set to false, the packets will be returned as a packet.Unknown.
 	DisconnectOnUnknownPackets bool
 
+	// IgnoreUnknownPackets specifies if packets received that are not present in the packet pool should be
+	// dropped rather than being returned as a packet.Unknown. The raw payload of these packets is still
+	// passed to the PacketFunc if set. IgnoreUnknownPackets has no effect if DisconnectOnUnknownPackets is
+	// set.
+	IgnoreUnknownPackets bool
+
 	// DisconnectOnInvalidPackets specifies if invalid packets (either too few bytes or too many bytes) should be
 	// allowed. If true, such packets lead to the connection being closed immediately. If false,
 	// packets with too many bytes will be returned while packets with too few bytes will be skipped.
@@ -86,10 +178,118 @@ type Dialer struct {
 	// calls to `(*Conn).Write()` or `(*Conn).WritePacket()` to send the packets over network.
 	FlushRate time.Duration
 
+	// ReadBufferSize is the size in bytes of the buffer that batches of packets are read into. It is only used
+	// for network implementations that do not read packets directly, and must be large enough to hold the
+	// largest batch received. If set to 0, packet.DefaultReadBufferSize is used. Values smaller than
+	// packet.MinReadBufferSize are raised to packet.MinReadBufferSize.
+	ReadBufferSize int
+	// WriteBufferSize is the initial size in bytes of the buffer that batches of packets are written to
+	// before being compressed. If set to 0, buffers are taken from a pool shared by all connections. Values
+	// smaller than packet.MinWriteBufferSize are raised to packet.MinWriteBufferSize.
+	WriteBufferSize int
+	// TCPOptions holds socket options applied to the connection before the login sequence starts, if the
+	// Network that the Conn is dialed over returns a TCP connection. The "raknet" network is not TCP based and
+	// ignores these options.
+	TCPOptions TCPOptions
+	// MaxPacketSize is the maximum size in bytes of a batch of packets received from the server, both before
+	// and after decompression. Batches exceeding it are rejected before the decompressed data is allocated
+	// where possible, so that a server cannot exhaust memory by declaring very large packets. If set to 0,
+	// packet.DefaultMaxPacketSize is used.
+	MaxPacketSize int
+
+	// SendQueueSize is the maximum amount of packets that may be queued for sending on the Conn before being
+	// flushed. If set to 0, the amount of queued packets is unbounded. If the queue is full, writing a packet
+	// either blocks until the queue is flushed or fails, depending on SendQueuePolicy. Note that with a
+	// negative FlushRate, blocking writes only return after an explicit call to Conn.Flush.
+	SendQueueSize int
+	// SendQueuePolicy specifies the behaviour of writes to the Conn when its send queue is full. By default,
+	// writes block until the queue is flushed.
+	SendQueuePolicy SendQueuePolicy
+	// ReadQueueSize is the maximum amount of packets that the Conn holds after receiving them, until they are
+	// read using Conn.ReadPacket or Conn.Read. If set to 0, the amount of held packets is unbounded, so that
+	// bursts of packets, such as the chunks sent when joining, never slow down reading from the connection,
+	// at the cost of memory growing for as long as packets are received faster than they are read. If the
+	// queue is full, received packets are either held back or dropped, depending on ReadQueuePolicy. Each
+	// packet held keeps its encoded payload in memory, which may be up to the maximum packet size.
+	ReadQueueSize int
+	// ReadQueuePolicy specifies the behaviour of the Conn when a packet is received while its read queue is
+	// full. By default, reading from the underlying connection blocks until the queue has space again.
+	ReadQueuePolicy ReadQueuePolicy
+
 	// EnableClientCache, if set to true, enables the client blob cache for the client. This means that the
 	// server will send chunks as blobs, which may be saved by the client so that chunks don't have to be
 	// transmitted every time, resulting in less network transmission.
 	EnableClientCache bool
+	// BlobCache is the BlobCache used to store blobs sent by the server if EnableClientCache is true. It may be
+	// shared between multiple Dialers, so that blobs do not have to be sent again when reconnecting. If left
+	// nil, a new BlobCache is created for every Conn dialed.
+	BlobCache *BlobCache
+
+	// TrackEntities, if set to true, makes the Conn track the entities spawned by the server using AddActor
+	// packets, so that they may be obtained using Conn.Entities and Conn.Entity.
+	TrackEntities bool
+	// TrackPlayers, if set to true, makes the Conn track the player list sent by the server using PlayerList
+	// packets, so that the players online may be obtained using Conn.Players and Conn.Player, and their skins
+	// using Conn.PlayerSkin.
+	TrackPlayers bool
+	// TrackEffects, if set to true, makes the Conn track the effects of entities sent by the server using
+	// MobEffect packets, so that the effects of the player may be obtained using Conn.Effects and those of
+	// other entities using Conn.EntityEffects.
+	TrackEffects bool
+	// TrackBossBars, if set to true, makes the Conn track the boss bars shown by the server using BossEvent
+	// packets, so that they may be obtained using Conn.BossBars.
+	TrackBossBars bool
+	// TrackScoreboards, if set to true, makes the Conn track the scoreboards displayed by the server, so that
+	// they may be obtained using Conn.Scoreboard.
+	TrackScoreboards bool
+	// TrackRecipes, if set to true, makes the Conn store the recipes sent by the server in CraftingData
+	// packets, so that they may be obtained using Conn.Recipes and Conn.RecipesFor. Servers generally send
+	// thousands of recipes, so enabling this has a considerable memory cost.
+	TrackRecipes bool
+
+	// PlayerMovementMode, if set, is the player movement mode that the client supports, which is one of the
+	// protocol.PlayerMovementMode constants. A bot that moves using MovePlayer packets, for example, may set
+	// it to protocol.PlayerMovementModeClient, while a bot that sends PlayerAuthInput packets may set it to
+	// protocol.PlayerMovementModeServer. The movement mode is decided by the server and sent in the StartGame
+	// packet, so the mode set is checked against it: If the modes differ, dialing fails with a
+	// MovementModeError. If not set, any movement mode is accepted.
+	PlayerMovementMode protocol.Optional[int32]
+	// MaxRewindHistorySize, if not 0, is the maximum rewind history size that the client supports if the
+	// server uses protocol.PlayerMovementModeServerWithRewind. Dialing fails with a MovementModeError if the
+	// server requires the client to keep a larger history.
+	MaxRewindHistorySize int32
+
+	// RawHandshake, if set to true, limits the handling of the login sequence by the Conn to the bare minimum
+	// required to set up the connection: The NetworkSettings packet is handled to enable compression, and the
+	// ServerToClientHandshake packet is handled to enable encryption. DialContext returns as soon as encryption
+	// is enabled, and every packet that follows, including the PlayStatus, resource pack and StartGame packets,
+	// is returned by Conn.ReadPacket so that the login sequence may be driven by the caller, for example by
+	// forwarding packets to a client connected to a Listener with ListenConfig.RawHandshake set.
+	// Conn.DoSpawn and Conn.GameData may not be used on a Conn obtained with RawHandshake set.
+	RawHandshake bool
+
+	// ManualRead, if set to true, stops the goroutine that reads packets from the connection in the background
+	// once the Conn has spawned, or once DialContext returns if RawHandshake is set. From then on, packets are
+	// only read from the connection by calls to Conn.ReadPacket and Conn.Read, on the goroutine making the
+	// call, which suits single-threaded event loops and deterministic test harnesses. The background goroutine
+	// and these calls never read from the connection at the same time: Calls made before the goroutine stopped
+	// wait for the packets it reads. Because nothing else reads from the connection, ReadPacket must be called
+	// regularly, as a Disconnect packet or the connection being closed is only noticed while reading.
+	ManualRead bool
+
+	// DisableEncryption, if set to true, allows the server to skip the ServerToClientHandshake packet, so that
+	// encryption is never enabled and all packets are sent in cleartext, as is the case for a Listener with
+	// ListenConfig.DisableEncryption set. By default, the connection is closed if the server does not enable
+	// encryption. DisableEncryption should only be used for trusted links, such as on localhost or in a LAN for
+	// testing.
+	// If RawHandshake is also set and the server skips encryption, DialContext returns once the PlayStatus
+	// that follows the Login packet is received. This PlayStatus is not returned by Conn.ReadPacket.
+	DisableEncryption bool
+	// ExposeEncryptionKey, if set to true, keeps the AES key negotiated during the login sequence, so that it
+	// may be obtained using Conn.UnsafeEncryptionKey, for example to decrypt captured traffic in external tools.
+	// Anyone holding the key is able to read and forge all packets sent over the connection, so it should
+	// only be set for debugging.
+	ExposeEncryptionKey bool
 
 	// KeepXBLIdentityData, if set to true, enables passing XUID and title ID to the target server
 	// if the authentication token is not set. This is technically not valid and some servers might kick
@@ -126,6 +326,15 @@ func DialContext(ctx context.Context, network, address string) (*Conn, error) {
 	return d.DialContext(ctx, network, address)
 }
 
+// DialAny dials Minecraft connections to all addresses passed over the network passed at the same time and
+// returns the first connection that is established, closing all others. It may be used to quickly fail over
+// between servers of a cluster. An error holding the errors of all attempts is returned if none succeed.
+// DialAny uses a zero value of Dialer to initiate the connections.
+func DialAny(network string, addresses []string) (*Conn, error) {
+	var d Dialer
+	return d.DialAny(network, addresses)
+}
+
 // Dial dials a Minecraft connection to the address passed over the network passed. The network is typically
 // "raknet". A Conn is returned which may be used to receive packets from and send packets to.
 func (d Dialer) Dial(network, address string) (*Conn, error) {
@@ -146,19 +355,111 @@ func (d Dialer) DialTimeout(network, address string, timeout time.Duration) (*Co
 // DialContext dials a Minecraft connection to the address passed over the network passed. The network is
 // typically "raknet". A Conn is returned which may be used to receive packets from and send packets to.
 // If a connection is not established before the context passed is cancelled, DialContext returns an error.
+// The time spent in each phase of dialing may be traced by passing a context obtained using WithDialTrace.
 func (d Dialer) DialContext(ctx context.Context, network, address string) (conn *Conn, err error) {
+	if trace := dialTrace(ctx); trace != nil {
+		*trace = DialTrace{Start: time.Now()}
+		defer func() {
+			trace.Done, trace.Err = time.Now(), err
+		}()
+	}
 	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
 
 	var chainData string
 	if d.TokenSource != nil {
-		chainData, err = authChain(ctx, d.TokenSource, key)
+		chainData, err = d.authChain(ctx, key)
 		if err != nil {
 			return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: err}
 		}
+		if trace := dialTrace(ctx); trace != nil {
+			trace.AuthDone = time.Now()
+		}
+	}
+	return d.dial(ctx, network, address, key, chainData)
+}
+
+// DialAny dials Minecraft connections to all addresses passed over the network passed at the same time and
+// returns the first connection that is established, closing all others. An error holding the errors of all
+// attempts is returned if none succeed within 30 seconds.
+func (d Dialer) DialAny(network string, addresses []string) (*Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	return d.DialAnyContext(ctx, network, addresses)
+}
+
+// DialAnyContext dials Minecraft connections to all addresses passed over the network passed at the same time
+// and returns the first connection that is established. The other attempts are cancelled, and connections
+// that were established regardless are closed, before DialAnyContext returns. If d.TokenSource is set, the
+// Minecraft auth chain is only requested once and shared by all attempts. An error holding the errors of all
+// attempts is returned if none succeed before the context passed is cancelled.
+func (d Dialer) DialAnyContext(ctx context.Context, network string, addresses []string) (*Conn, error) {
+	if len(addresses) == 0 {
+		return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: fmt.Errorf("no addresses to dial")}
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	var chainData string
+	if d.TokenSource != nil {
+		var err error
+		if chainData, err = d.authChain(ctx, key); err != nil {
+			return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: err}
+		}
+	}
+	// The attempts run concurrently, so they cannot fill out the same DialTrace.
+	ctx, cancel := context.WithCancel(WithDialTrace(ctx, nil))
+	defer cancel()
+
+	type result struct {
+		conn *Conn
+		err  error
+	}
+	results := make(chan result, len(addresses))
+	for _, address := range addresses {
+		go func(address string) {
+			conn, err := d.dial(ctx, network, address, key, chainData)
+			results <- result{conn: conn, err: err}
+		}(address)
+	}
+	// Wait for all attempts to finish, so that no connection is left open after returning.
+	var conn *Conn
+	var errs []error
+	for range addresses {
+		r := <-results
+		switch {
+		case r.err != nil:
+			errs = append(errs, r.err)
+		case conn != nil:
+			// Another attempt succeeded first.
+			_ = r.conn.Close()
+		default:
+			conn = r.conn
+			cancel()
+		}
+	}
+	if conn == nil {
+		return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: errors.Join(errs...)}
+	}
+	return conn, nil
+}
+
+// dial dials a Minecraft connection to the address passed like DialContext, logging in using the private key
+// and Minecraft auth chain passed. If chainData is empty, the connection does not use authentication.
+func (d Dialer) dial(ctx context.Context, network, address string, key *ecdsa.PrivateKey, chainData string) (conn *Conn, err error) {
+	if chainData != "" {
 		d.IdentityData = readChainIdentityData([]byte(chainData))
 	}
-	if d.ErrorLog == nil {
-		d.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
+	d.Logger = bridgeLogger(d.Logger, d.ErrorLog)
+	if err := d.validateDeviceData(); err != nil {
+		return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: err}
+	}
+	if err := d.validateLoginOverrides(); err != nil {
+		return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: err}
+	}
+	if err := d.TCPOptions.validate(); err != nil {
+		return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: err}
+	}
+	if d.DeviceSeed != "" {
+		deriveDeviceData(d.DeviceSeed, &d.ClientData)
 	}
 	if d.Protocol == nil {
 		d.Protocol = DefaultProtocol
@@ -182,22 +483,77 @@ func (d Dialer) DialContext(ctx context.Context, network, address string) (conn
 	if err != nil {
 		return nil, err
 	}
+	trace := dialTrace(ctx)
+	if trace != nil {
+		trace.Connected = time.Now()
+	}
+	if err := d.TCPOptions.apply(netConn); err != nil {
+		_ = netConn.Close()
+		return nil, &net.OpError{Op: "dial", Net: "minecraft", Err: err}
+	}
 
-	conn = newConn(netConn, key, d.ErrorLog, d.Protocol, d.FlushRate, false)
+	conn = newConn(netConn, key, d.Logger, d.Protocol, d.FlushRate, d.ReadBufferSize, d.WriteBufferSize, false)
 	conn.pool = conn.proto.Packets(false)
+	conn.dec.SetMaxPacketSize(d.MaxPacketSize)
 	conn.identityData = d.IdentityData
-	conn.clientData = d.ClientData
+	conn.chainData = chainData
+	conn.authenticated = chainData != ""
+	if p, err := ParsePong(pong); err == nil {
+		conn.serverProtocol = p.Protocol
+	}
+	conn.clientData = d.ClientData.Clone()
 	conn.packetFunc = d.PacketFunc
+	conn.packetSizeFunc = d.PacketSizeFunc
+	conn.handshakeFunc = d.HandshakeFunc
+	if trace != nil {
+		conn.stageTimes = &stageTimes{}
+		defer conn.stageTimes.fill(trace)
+	}
+	conn.emoteFunc = d.EmoteFunc
+	conn.respawn.deathFunc, conn.respawn.respawnFunc, conn.respawn.auto = d.DeathFunc, d.RespawnFunc, d.AutoRespawn
+	if d.Capture != nil {
+		conn.captureWriter.Store(newCaptureWriter(d.Capture, false))
+	}
 	conn.downloadResourcePack = d.DownloadResourcePack
+	conn.packsDownloadedFunc = d.ResourcePacksDownloaded
 	conn.cacheEnabled = d.EnableClientCache
+	if conn.cacheEnabled {
+		if conn.blobCache = d.BlobCache; conn.blobCache == nil {
+			conn.blobCache = &BlobCache{}
+		}
+	}
 	conn.disconnectOnInvalidPacket = d.DisconnectOnInvalidPackets
 	conn.disconnectOnUnknownPacket = d.DisconnectOnUnknownPackets
+	conn.ignoreUnknownPacket = d.IgnoreUnknownPackets
+	conn.rawHandshake = d.RawHandshake
+	conn.disableEncryption = d.DisableEncryption
+	conn.exposeKey = d.ExposeEncryptionKey
+	conn.entities.enabled = d.TrackEntities
+	conn.players.enabled = d.TrackPlayers
+	conn.effects.enabled = d.TrackEffects
+	conn.bossBars.enabled = d.TrackBossBars
+	conn.scoreboards.enabled = d.TrackScoreboards
+	conn.recipes.enabled = d.TrackRecipes
+	conn.sendQueueSize, conn.sendQueuePolicy = d.SendQueueSize, d.SendQueuePolicy
+	conn.setReadQueue(d.ReadQueueSize, d.ReadQueuePolicy)
+	conn.serverKey = d.ServerPublicKey
+	conn.movementMode, conn.maxRewindHistorySize = d.PlayerMovementMode, d.MaxRewindHistorySize
+	if d.ManualRead {
+		conn.readerStopped = make(chan struct{})
+	}
 
 	defaultIdentityData(&conn.identityData)
-	defaultClientData(address, conn.identityData.DisplayName, &conn.clientData)
+	serverAddress, thirdPartyName := address, conn.identityData.DisplayName
+	if d.ServerAddress != "" {
+		serverAddress = d.ServerAddress
+	}
+	if d.ThirdPartyName != "" {
+		thirdPartyName = d.ThirdPartyName
+	}
+	defaultClientData(serverAddress, thirdPartyName, &conn.clientData, d.ClientDataSeed)
 
 	var request []byte
-	if d.TokenSource == nil {
+	if chainData == "" {
 		// We haven't logged into the user's XBL account. We create a login request with only one token
 		// holding the identity data set in the Dialer after making sure we clear data from the identity data
 		// that is only present when logged in.
@@ -218,7 +574,7 @@ func (d Dialer) DialContext(ctx context.Context, network, address string) (conn
 	}
 
 	l, c := make(chan struct{}), make(chan struct{})
-	go listenConn(conn, d.ErrorLog, l, c)
+	go listenConn(conn, l, c)
 
 	conn.expect(packet.IDNetworkSettings, packet.IDPlayStatus)
 	if err := conn.WritePacket(&packet.RequestNetworkSettings{ClientProtocol: d.Protocol.ID()}); err != nil {
@@ -230,6 +586,7 @@ func (d Dialer) DialContext(ctx context.Context, network, address string) (conn
 	case <-conn.close:
 		return nil, conn.closeErr("dial")
 	case <-ctx.Done():
+		_ = conn.Close()
 		return nil, conn.wrap(ctx.Err(), "dial")
 	case <-l:
 		// We've received our network settings, so we can now send our login request.
@@ -243,6 +600,7 @@ func (d Dialer) DialContext(ctx context.Context, network, address string) (conn
 		case <-conn.close:
 			return nil, conn.closeErr("dial")
 		case <-ctx.Done():
+			_ = conn.Close()
 			return nil, conn.wrap(ctx.Err(), "dial")
 		case <-c:
 			// We've connected successfully. We return the connection and no error.
@@ -277,24 +635,32 @@ func readChainIdentityData(chainData []byte) login.IdentityData {
 
 // listenConn listens on the connection until it is closed on another goroutine. The channel passed will
 // receive a value once the connection is logged in.
-func listenConn(conn *Conn, logger *log.Logger, l, c chan struct{}) {
+func listenConn(conn *Conn, l, c chan struct{}) {
 	defer func() {
-		_ = conn.Close()
+		select {
+		case <-conn.readerStopped:
+		default:
+			_ = conn.Close()
+		}
 	}()
 	for {
 		// We finally arrived at the packet decoding loop. We constantly decode packets that arrive
 		// and push them to the Conn so that they may be processed.
-		packets, err := conn.dec.Decode()
+		packets, err := conn.readBackground()
 		if err != nil {
 			if !raknet.ErrConnectionClosed(err) {
-				logger.Printf("error reading from dialer connection: %v\n", err)
+				conn.log.Error("read from dialer connection", "err", err)
 			}
 			return
 		}
 		for _, data := range packets {
 			loggedInBefore, readyToLoginBefore := conn.loggedIn, conn.readyToLogin
 			if err := conn.receive(data); err != nil {
-				logger.Printf("error: %v", err)
+				if !isFatal(err) {
+					conn.log.Warn("handle packet", "err", err)
+					continue
+				}
+				conn.log.Error("handle packet", "err", err)
 				return
 			}
 			if !readyToLoginBefore && conn.readyToLogin {
@@ -308,16 +674,41 @@ func listenConn(conn *Conn, logger *log.Logger, l, c chan struct{}) {
 				c <- struct{}{}
 			}
 		}
+		if conn.readerStopped != nil && conn.loggedIn && (conn.rawHandshake || conn.spawned()) {
+			// Packets are read manually from here on out, so we stop reading without closing the
+			// connection.
+			close(conn.readerStopped)
+			return
+		}
 	}
 }
 
-// authChain requests the Minecraft auth JWT chain using the credentials passed. If successful, an encoded
-// chain ready to be put in a login request is returned.
-func authChain(ctx context.Context, src oauth2.TokenSource, key *ecdsa.PrivateKey) (string, error) {
-	// Obtain the Live token, and using that the XSTS token.
-	liveToken, err := src.Token()
-	if err != nil {
-		return "", fmt.Errorf("error obtaining Live Connect token: %v", err)
+// authChain requests the Minecraft auth JWT chain using the TokenSource and HTTPClient of the Dialer. If
+// successful, an encoded chain ready to be put in a login request is returned.
+func (d Dialer) authChain(ctx context.Context, key *ecdsa.PrivateKey) (string, error) {
+	if d.HTTPClient != nil {
+		ctx = auth.WithHTTPClient(ctx, d.HTTPClient)
+	}
+	// Obtain the Live token, and using that the XSTS token. oauth2.TokenSource does not accept a context, so
+	// the token is obtained on a separate goroutine to stop waiting for it once the context is done.
+	type result struct {
+		t   *oauth2.Token
+		err error
+	}
+	c := make(chan result, 1)
+	go func() {
+		t, err := d.TokenSource.Token()
+		c <- result{t: t, err: err}
+	}()
+	var liveToken *oauth2.Token
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("error obtaining Live Connect token: %w", ctx.Err())
+	case res := <-c:
+		if res.err != nil {
+			return "", fmt.Errorf("error obtaining Live Connect token: %v", res.err)
+		}
+		liveToken = res.t
 	}
 	xsts, err := auth.RequestXBLToken(ctx, liveToken, "https://multiplayer.minecraft.net/")
 	if err != nil {
@@ -339,8 +730,21 @@ var skinResourcePatch []byte
 var skinGeometry []byte
 
 // defaultClientData edits the ClientData passed to have defaults set to all fields that were left unchanged.
-func defaultClientData(address, username string, d *login.ClientData) {
-	rand2.Seed(time.Now().Unix())
+// If seed is non-zero, the random values set are generated from a source seeded with it, so that the same
+// seed always produces the same ClientData.
+func defaultClientData(address, username string, d *login.ClientData, seed int64) {
+	randomID, newUUID := cryptoRandomID, uuid.New
+	if seed != 0 {
+		// A source local to this call is used, so that concurrent dials neither share nor change the
+		// global source of math/rand.
+		r := rand2.New(rand2.NewSource(seed))
+		randomID = r.Int63
+		newUUID = func() uuid.UUID {
+			// Reading from a *rand.Rand never fails.
+			id, _ := uuid.NewRandomFromReader(r)
+			return id
+		}
+	}
 
 	d.ServerAddress = address
 	d.ThirdPartyName = username
@@ -351,10 +755,10 @@ func defaultClientData(address, username string, d *login.ClientData) {
 		d.GameVersion = protocol.CurrentVersion
 	}
 	if d.ClientRandomID == 0 {
-		d.ClientRandomID = rand2.Int63()
+		d.ClientRandomID = randomID()
 	}
 	if d.DeviceID == "" {
-		d.DeviceID = uuid.New().String()
+		d.DeviceID = newUUID().String()
 	}
 	if d.LanguageCode == "" {
 		d.LanguageCode = "en_GB"
@@ -369,10 +773,10 @@ func defaultClientData(address, username string, d *login.ClientData) {
 		d.PieceTintColours = make([]login.PersonaPieceTintColour, 0)
 	}
 	if d.SelfSignedID == "" {
-		d.SelfSignedID = uuid.New().String()
+		d.SelfSignedID = newUUID().String()
 	}
 	if d.SkinID == "" {
-		d.SkinID = uuid.New().String()
+		d.SkinID = newUUID().String()
 	}
 	if d.SkinData == "" {
 		d.SkinData = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0, 0, 0, 255}, 32*64))
@@ -387,6 +791,64 @@ func defaultClientData(address, username string, d *login.ClientData) {
 	}
 }
 
+// cryptoRandomID returns a random, non-negative int64 read from crypto/rand.
+func cryptoRandomID() int64 {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return int64(binary.LittleEndian.Uint64(b[:]) >> 1)
+}
+
+// deviceNamespace is the namespace UUID used to derive device specific UUIDs from a Dialer.DeviceSeed.
+var deviceNamespace = uuid.MustParse("6f1ed8c8-2a4e-4e0c-9a2d-0c6e3e9f3b71")
+
+// deriveDeviceData sets the DeviceID and SelfSignedID of the login.ClientData passed to UUIDs derived from
+// the seed passed, provided they were not yet set.
+func deriveDeviceData(seed string, d *login.ClientData) {
+	if d.DeviceID == "" {
+		d.DeviceID = uuid.NewSHA1(deviceNamespace, []byte("device_id:"+seed)).String()
+	}
+	if d.SelfSignedID == "" {
+		d.SelfSignedID = uuid.NewSHA1(deviceNamespace, []byte("self_signed_id:"+seed)).String()
+	}
+}
+
+// validateDeviceData checks if the DeviceID and SelfSignedID of the ClientData of the Dialer are valid UUIDs
+// if they are set alongside a DeviceSeed. Without a DeviceSeed, the device data is sent as set.
+func (d Dialer) validateDeviceData() error {
+	if d.DeviceSeed == "" {
+		return nil
+	}
+	if id := d.ClientData.DeviceID; id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("invalid device ID %v: %w", id, err)
+		}
+	}
+	if id := d.ClientData.SelfSignedID; id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("invalid self signed ID %v: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// validateLoginOverrides checks if the ServerAddress and ThirdPartyName of the Dialer, if set, are not blank
+// and if the ServerAddress holds both a host and a port.
+func (d Dialer) validateLoginOverrides() error {
+	if d.ServerAddress != "" {
+		host, port, err := net.SplitHostPort(d.ServerAddress)
+		if err != nil {
+			return fmt.Errorf("invalid server address %q: %w", d.ServerAddress, err)
+		}
+		if strings.TrimSpace(host) == "" || strings.TrimSpace(port) == "" {
+			return fmt.Errorf("invalid server address %q: host and port must not be empty", d.ServerAddress)
+		}
+	}
+	if d.ThirdPartyName != "" && strings.TrimSpace(d.ThirdPartyName) == "" {
+		return fmt.Errorf("third party name must not be blank")
+	}
+	return nil
+}
+
 // setAndroidData ensures the login.ClientData passed matches settings you would see on an Android device.
 func setAndroidData(data *login.ClientData) {
 	data.DeviceOS = protocol.DeviceAndroid
@@ -418,14 +880,14 @@ func splitPong(s string) []string {
 	inEscape := false
 	for _, r := range s {
 		switch {
+		case inEscape:
+			inEscape = false
+			runes = append(runes, r)
 		case r == '\\':
 			inEscape = true
 		case r == ';':
 			tokens = append(tokens, string(runes))
 			runes = runes[:0]
-		case inEscape:
-			inEscape = false
-			fallthrough
 		default:
 			runes = append(runes, r)
 		}
@@ -0,0 +1,81 @@
+package minecraft
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"testing"
+)
+
+// TestDeriveDeviceData tests that device data derived from a seed is valid, stable for the same seed, unique
+// for different seeds and does not replace device data already set.
+func TestDeriveDeviceData(t *testing.T) {
+	var a, b, other login.ClientData
+	deriveDeviceData("seed", &a)
+	deriveDeviceData("seed", &b)
+	deriveDeviceData("other seed", &other)
+	if err := (Dialer{DeviceSeed: "seed", ClientData: a}).validateDeviceData(); err != nil || a.DeviceID == "" || a.SelfSignedID == "" {
+		t.Fatalf("expected valid device data to be derived, got %+v (%v)", a, err)
+	}
+	if a.DeviceID != b.DeviceID || a.SelfSignedID != b.SelfSignedID {
+		t.Fatalf("expected device data derived from the same seed to be equal")
+	}
+	if a.DeviceID == other.DeviceID || a.SelfSignedID == other.SelfSignedID {
+		t.Fatalf("expected device data derived from different seeds to differ")
+	}
+	if a.DeviceID == a.SelfSignedID {
+		t.Fatalf("expected device ID and self signed ID to differ")
+	}
+
+	set := login.ClientData{DeviceID: uuid.NewString()}
+	deviceID := set.DeviceID
+	deriveDeviceData("seed", &set)
+	if set.DeviceID != deviceID || set.SelfSignedID != a.SelfSignedID {
+		t.Fatalf("expected only the self signed ID to be derived, got %+v", set)
+	}
+}
+
+// TestValidateDeviceData tests that the device ID and self signed ID of client data are only accepted if they
+// are empty or valid UUIDs when a device seed is set, and that they are accepted as is without one.
+func TestValidateDeviceData(t *testing.T) {
+	for _, test := range []struct {
+		seed, deviceID, selfSignedID string
+		ok                           bool
+	}{
+		{"seed", "", "", true},
+		{"seed", uuid.NewString(), uuid.NewString(), true},
+		{"seed", uuid.NewString(), "", true},
+		{"seed", "", uuid.NewString(), true},
+		{"seed", "not a uuid", "", false},
+		{"seed", "", "not a uuid", false},
+		{"seed", uuid.NewString(), "1234", false},
+		{"", "not a uuid", "1234", true},
+	} {
+		d := Dialer{DeviceSeed: test.seed, ClientData: login.ClientData{DeviceID: test.deviceID, SelfSignedID: test.selfSignedID}}
+		if err := d.validateDeviceData(); (err == nil) != test.ok {
+			t.Errorf("seed %q, device ID %q, self signed ID %q: expected valid: %v, got error %v", test.seed, test.deviceID, test.selfSignedID, test.ok, err)
+		}
+	}
+}
+
+// TestValidateLoginOverrides tests that the ServerAddress and ThirdPartyName of a Dialer are only accepted if
+// they are empty or valid.
+func TestValidateLoginOverrides(t *testing.T) {
+	for _, test := range []struct {
+		serverAddress, thirdPartyName string
+		ok                            bool
+	}{
+		{"", "", true},
+		{"play.example.com:19132", "Steve", true},
+		{"127.0.0.1:19132", "", true},
+		{"[::1]:19132", "", true},
+		{"play.example.com", "", false},
+		{":19132", "", false},
+		{"play.example.com:", "", false},
+		{"", "   ", false},
+	} {
+		err := Dialer{ServerAddress: test.serverAddress, ThirdPartyName: test.thirdPartyName}.validateLoginOverrides()
+		if (err == nil) != test.ok {
+			t.Errorf("server address %q, third party name %q: expected valid: %v, got error %v", test.serverAddress, test.thirdPartyName, test.ok, err)
+		}
+	}
+}
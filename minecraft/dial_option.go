@@ -0,0 +1,86 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"golang.org/x/oauth2"
+	"log"
+	"log/slog"
+)
+
+// DialerOption is an option that may be passed to NewDialer to change a field of the Dialer created.
+type DialerOption func(d *Dialer)
+
+// NewDialer creates a Dialer with the options passed applied in order. Fields of the Dialer not changed by
+// any of the options keep their zero value, which results in the same default behaviour as a zero Dialer.
+// The fields of the Dialer returned may still be changed directly.
+func NewDialer(opts ...DialerOption) Dialer {
+	var d Dialer
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return d
+}
+
+// WithClientData returns a DialerOption that sets the login.ClientData that the Dialer logs in with. See
+// Dialer.ClientData for more information.
+func WithClientData(data login.ClientData) DialerOption {
+	return func(d *Dialer) {
+		d.ClientData = data
+	}
+}
+
+// WithIdentityData returns a DialerOption that sets the login.IdentityData that the Dialer logs in with if
+// no TokenSource is set. See Dialer.IdentityData for more information.
+func WithIdentityData(data login.IdentityData) DialerOption {
+	return func(d *Dialer) {
+		d.IdentityData = data
+	}
+}
+
+// WithTokenSource returns a DialerOption that sets the oauth2.TokenSource used to authenticate to XBOX Live.
+// See Dialer.TokenSource for more information.
+func WithTokenSource(src oauth2.TokenSource) DialerOption {
+	return func(d *Dialer) {
+		d.TokenSource = src
+	}
+}
+
+// WithProtocol returns a DialerOption that sets the Protocol used to communicate with the server. See
+// Dialer.Protocol for more information.
+func WithProtocol(p Protocol) DialerOption {
+	return func(d *Dialer) {
+		d.Protocol = p
+	}
+}
+
+// WithBufferSizes returns a DialerOption that sets the sizes of the read and write buffers of the Conn. See
+// Dialer.ReadBufferSize and Dialer.WriteBufferSize for more information.
+func WithBufferSizes(read, write int) DialerOption {
+	return func(d *Dialer) {
+		d.ReadBufferSize, d.WriteBufferSize = read, write
+	}
+}
+
+// WithTCPOptions returns a DialerOption that sets the socket options applied to TCP connections dialed. See
+// Dialer.TCPOptions for more information.
+func WithTCPOptions(opts TCPOptions) DialerOption {
+	return func(d *Dialer) {
+		d.TCPOptions = opts
+	}
+}
+
+// WithErrorLog returns a DialerOption that sets the log.Logger that errors are written to. See
+// Dialer.ErrorLog for more information.
+func WithErrorLog(l *log.Logger) DialerOption {
+	return func(d *Dialer) {
+		d.ErrorLog = l
+	}
+}
+
+// WithLogger returns a DialerOption that sets the slog.Logger that errors are logged to. See Dialer.Logger
+// for more information.
+func WithLogger(l *slog.Logger) DialerOption {
+	return func(d *Dialer) {
+		d.Logger = l
+	}
+}
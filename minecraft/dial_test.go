@@ -0,0 +1,259 @@
+package minecraft_test
+
+import (
+	"context"
+	"errors"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDialConcurrent tests that connections dialed concurrently are each sent unique default client data.
+// It is most useful when run with the race detector enabled.
+func TestDialConcurrent(t *testing.T) {
+	const n = 8
+
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	go func() {
+		for i := 0; i < n; i++ {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = conn.(*minecraft.Conn).StartGameContext(ctx, minecraft.GameData{})
+			}()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	conns := make([]*minecraft.Conn, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i], errs[i] = minecraft.Dialer{}.DialContext(ctx, "raknet", l.Addr().String())
+		}(i)
+	}
+	wg.Wait()
+
+	randomIDs := make(map[int64]struct{}, n)
+	for i, conn := range conns {
+		if errs[i] != nil {
+			t.Fatalf("error dialing: %v", errs[i])
+		}
+		defer conn.Close()
+		randomIDs[conn.ClientData().ClientRandomID] = struct{}{}
+	}
+	if len(randomIDs) != n {
+		t.Fatalf("expected %v unique client random IDs, got %v", n, len(randomIDs))
+	}
+}
+
+// TestAuthenticatedSelfSigned tests that a connection that logs in with a self-signed chain is not considered
+// authenticated on either end, even if its identity data holds an XUID.
+func TestAuthenticatedSelfSigned(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	accepted := make(chan *minecraft.Conn, 1)
+	go func() {
+		conn, err := minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{})
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	d := minecraft.Dialer{IdentityData: login.IdentityData{DisplayName: "Steve", XUID: "2535400000000000"}, KeepXBLIdentityData: true}
+	conn, err := d.DialContext(ctx, "raknet", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if conn.Authenticated() {
+		t.Errorf("expected dialed connection without token source not to be authenticated")
+	}
+	serverConn, ok := <-accepted
+	if !ok {
+		t.Fatalf("error accepting connection")
+	}
+	defer serverConn.Close()
+	if xuid := serverConn.IdentityData().XUID; xuid != d.IdentityData.XUID {
+		t.Fatalf("expected XUID %v, got %v", d.IdentityData.XUID, xuid)
+	}
+	if serverConn.Authenticated() {
+		t.Errorf("expected accepted connection with self-signed chain not to be authenticated")
+	}
+}
+
+// TestAuthenticationRequired tests that a Listener with authentication enabled refuses connections that log
+// in with a self-signed chain.
+func TestAuthenticationRequired(t *testing.T) {
+	l, err := minecraft.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	conn, err := minecraft.Dialer{}.DialContext(ctx, "raknet", l.Addr().String())
+	if err == nil {
+		_ = conn.Close()
+		t.Fatalf("expected dialing without authentication to fail")
+	}
+}
+
+// TestDialTrace tests that a DialTrace is filled out with the phases of a successful dial, and up to the point
+// of failure for a failed dial.
+func TestDialTrace(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	go func() {
+		_, _ = minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{})
+	}()
+
+	var trace minecraft.DialTrace
+	conn, err := minecraft.Dialer{}.DialContext(minecraft.WithDialTrace(ctx, &trace), "raknet", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	_ = conn.Close()
+	if trace.Err != nil || trace.Start.IsZero() || trace.Done.Before(trace.StartGame) {
+		t.Fatalf("unexpected trace of successful dial: %+v", trace)
+	}
+	var names []string
+	for _, phase := range trace.Phases() {
+		names = append(names, phase.Name)
+	}
+	if want := "connect,network settings,encryption,login,resource packs,start game"; strings.Join(names, ",") != want {
+		t.Fatalf("expected phases %v, got %v", want, names)
+	}
+
+	// Use an address without a server listening on it, after closing the Listener.
+	address := l.Addr().String()
+	_ = l.Close()
+	failCtx, failCancel := context.WithTimeout(ctx, time.Millisecond*500)
+	defer failCancel()
+	if _, err := (minecraft.Dialer{}).DialContext(minecraft.WithDialTrace(failCtx, &trace), "raknet", address); err == nil {
+		t.Fatalf("expected dialing a closed listener to fail")
+	}
+	if trace.Err == nil || trace.Start.IsZero() || trace.Done.IsZero() || !trace.NetworkSettings.IsZero() {
+		t.Fatalf("unexpected trace of failed dial: %+v", trace)
+	}
+}
+
+// TestDialMovementMode tests that dialing fails with a MovementModeError if the movement settings of the server
+// are not compatible with those supported by the Dialer, and succeeds if they are.
+func TestDialMovementMode(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	settings := protocol.PlayerMovementSettings{MovementType: protocol.PlayerMovementModeServerWithRewind, RewindHistorySize: 40}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = c.(*minecraft.Conn).StartGameContext(ctx, minecraft.GameData{PlayerMovementSettings: settings})
+			}()
+		}
+	}()
+
+	for _, test := range []struct {
+		d  minecraft.Dialer
+		ok bool
+	}{
+		{minecraft.Dialer{}, true},
+		{minecraft.Dialer{PlayerMovementMode: protocol.Option[int32](protocol.PlayerMovementModeServerWithRewind), MaxRewindHistorySize: 40}, true},
+		{minecraft.Dialer{PlayerMovementMode: protocol.Option[int32](protocol.PlayerMovementModeClient)}, false},
+		{minecraft.Dialer{MaxRewindHistorySize: 20}, false},
+	} {
+		conn, err := test.d.DialContext(ctx, "raknet", l.Addr().String())
+		var movementErr minecraft.MovementModeError
+		switch {
+		case test.ok && err != nil:
+			t.Fatalf("error dialing with compatible movement settings: %v", err)
+		case !test.ok && !errors.As(err, &movementErr):
+			t.Fatalf("expected MovementModeError dialing with incompatible movement settings, got %v", err)
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
+
+// TestDialAny tests that DialAnyContext returns the connection to the address that a server listens on when
+// dialing it together with addresses that no server listens on, and that it fails if no attempt succeeds.
+func TestDialAny(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+	closed, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	closedAddress := closed.Addr().String()
+	_ = closed.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	go func() {
+		_, _ = minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{})
+	}()
+
+	conn, err := minecraft.Dialer{}.DialAnyContext(ctx, "raknet", []string{closedAddress, l.Addr().String()})
+	if err != nil {
+		t.Fatalf("error dialing any: %v", err)
+	}
+	if conn.RemoteAddr().String() != l.Addr().String() {
+		t.Fatalf("expected connection to %v, got %v", l.Addr(), conn.RemoteAddr())
+	}
+	_ = conn.Close()
+
+	if _, err := (minecraft.Dialer{}).DialAnyContext(ctx, "raknet", nil); err == nil {
+		t.Fatalf("expected dialing no addresses to fail")
+	}
+	failCtx, failCancel := context.WithTimeout(ctx, time.Millisecond*500)
+	defer failCancel()
+	if _, err := (minecraft.Dialer{}).DialAnyContext(failCtx, "raknet", []string{closedAddress}); err == nil {
+		t.Fatalf("expected dialing only closed addresses to fail")
+	}
+}
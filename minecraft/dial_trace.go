@@ -0,0 +1,109 @@
+package minecraft
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DialTrace holds the times at which a dial reached each phase of connecting to a server, similar to
+// httptrace.ClientTrace for HTTP requests. A DialTrace is filled out by Dialer.DialContext if it is set in the
+// context passed using WithDialTrace. It is filled out up to the point of failure if dialing fails. Phases
+// that were not reached have a zero time.
+type DialTrace struct {
+	// Start is the time at which dialing started.
+	Start time.Time
+	// AuthDone is the time at which the Minecraft auth chain was obtained. It is zero if the Dialer has no
+	// TokenSource.
+	AuthDone time.Time
+	// Connected is the time at which the connection over the network was established, which includes pinging
+	// the server to find the port to connect to.
+	Connected time.Time
+	// NetworkSettings, Encryption, PacksStarted, PacksFinished and StartGame are the times at which the
+	// HandshakeStage of the same name was reached.
+	NetworkSettings, Encryption, PacksStarted, PacksFinished, StartGame time.Time
+	// Done is the time at which DialContext returned.
+	Done time.Time
+	// Err is the error that dialing failed with, or nil if it succeeded.
+	Err error
+}
+
+// DialPhase is a single phase of a dial traced using a DialTrace.
+type DialPhase struct {
+	// Name is the name of the phase, such as 'encryption'.
+	Name string
+	// Duration is the time that the phase took.
+	Duration time.Duration
+}
+
+// Phases returns the time spent in each phase of the dial that was completed. The phases are 'auth',
+// 'connect', 'network settings', 'encryption' (the ECDH key exchange), 'login' (up to the start of the
+// resource pack sequence), 'resource packs' (including pack downloads) and 'start game'. Each phase starts
+// when the last completed phase before it ended, so the durations of skipped phases, such as 'encryption'
+// when encryption is disabled, are included in the next phase completed.
+func (t *DialTrace) Phases() []DialPhase {
+	ends := []struct {
+		name string
+		t    time.Time
+	}{
+		{"auth", t.AuthDone},
+		{"connect", t.Connected},
+		{"network settings", t.NetworkSettings},
+		{"encryption", t.Encryption},
+		{"login", t.PacksStarted},
+		{"resource packs", t.PacksFinished},
+		{"start game", t.StartGame},
+	}
+	var phases []DialPhase
+	start := t.Start
+	for _, end := range ends {
+		if end.t.IsZero() {
+			continue
+		}
+		phases = append(phases, DialPhase{Name: end.name, Duration: end.t.Sub(start)})
+		start = end.t
+	}
+	return phases
+}
+
+// dialTraceKey is the key under which a *DialTrace is stored in a context.Context.
+type dialTraceKey struct{}
+
+// WithDialTrace returns a copy of the context passed that makes Dialer.DialContext fill out the DialTrace
+// passed. Dialer.DialAnyContext dials multiple addresses at once and does not fill out a DialTrace.
+func WithDialTrace(ctx context.Context, trace *DialTrace) context.Context {
+	return context.WithValue(ctx, dialTraceKey{}, trace)
+}
+
+// dialTrace returns the *DialTrace set in the context passed using WithDialTrace, or nil if none is set.
+func dialTrace(ctx context.Context) *DialTrace {
+	trace, _ := ctx.Value(dialTraceKey{}).(*DialTrace)
+	return trace
+}
+
+// stageTimes holds the times at which a Conn reached each HandshakeStage. It is used to fill out a DialTrace
+// with times recorded while handling packets.
+type stageTimes struct {
+	mu sync.Mutex
+	t  [HandshakeStageSpawned + 1]time.Time
+}
+
+// reach records the current time for the HandshakeStage passed if it was not reached before.
+func (s *stageTimes) reach(stage HandshakeStage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if int(stage) < len(s.t) && s.t[stage].IsZero() {
+		s.t[stage] = time.Now()
+	}
+}
+
+// fill sets the times of the stages reached so far in the DialTrace passed.
+func (s *stageTimes) fill(trace *DialTrace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trace.NetworkSettings = s.t[HandshakeStageNetworkSettings]
+	trace.Encryption = s.t[HandshakeStageEncryption]
+	trace.PacksStarted = s.t[HandshakeStagePacksStarted]
+	trace.PacksFinished = s.t[HandshakeStagePacksFinished]
+	trace.StartGame = s.t[HandshakeStageStartGame]
+}
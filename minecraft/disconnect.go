@@ -0,0 +1,112 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"strings"
+)
+
+// DisconnectCategory is a best-effort category of the reason that a connection was closed with through a
+// Disconnect packet. It is obtained from the disconnect message using ClassifyDisconnect.
+type DisconnectCategory int
+
+const (
+	// DisconnectCategoryUnknown is the category of disconnect messages that could not be classified.
+	DisconnectCategoryUnknown DisconnectCategory = iota
+	// DisconnectCategoryKicked is the category of disconnects in which the player was kicked, without the
+	// message indicating a more specific reason.
+	DisconnectCategoryKicked
+	// DisconnectCategoryBanned is the category of disconnects in which the player is banned from the server.
+	DisconnectCategoryBanned
+	// DisconnectCategoryNotAllowed is the category of disconnects in which the player is not allowed to join,
+	// for example because it is not whitelisted or not authenticated.
+	DisconnectCategoryNotAllowed
+	// DisconnectCategoryVersion is the category of disconnects in which the version of the client is not
+	// supported by the server.
+	DisconnectCategoryVersion
+	// DisconnectCategoryServerFull is the category of disconnects in which the server is full.
+	DisconnectCategoryServerFull
+	// DisconnectCategoryServerClosed is the category of disconnects in which the server is stopping or
+	// restarting.
+	DisconnectCategoryServerClosed
+	// DisconnectCategoryTimeout is the category of disconnects in which the connection timed out.
+	DisconnectCategoryTimeout
+)
+
+// Reconnect reports if dialing the server again is likely to succeed after a disconnect of the category. It
+// is false for players that are banned, not allowed to join or that use an unsupported version.
+func (c DisconnectCategory) Reconnect() bool {
+	switch c {
+	case DisconnectCategoryBanned, DisconnectCategoryNotAllowed, DisconnectCategoryVersion:
+		return false
+	}
+	return true
+}
+
+// String ...
+func (c DisconnectCategory) String() string {
+	switch c {
+	case DisconnectCategoryKicked:
+		return "kicked"
+	case DisconnectCategoryBanned:
+		return "banned"
+	case DisconnectCategoryNotAllowed:
+		return "not allowed"
+	case DisconnectCategoryVersion:
+		return "version"
+	case DisconnectCategoryServerFull:
+		return "server full"
+	case DisconnectCategoryServerClosed:
+		return "server closed"
+	case DisconnectCategoryTimeout:
+		return "timeout"
+	}
+	return "unknown"
+}
+
+// disconnectKeywords holds the keywords checked for by ClassifyDisconnect for every category, in the order in
+// which the categories are checked. Besides keywords commonly found in messages of servers, the vanilla
+// translation keys are included, which the client shows translated.
+var disconnectKeywords = []struct {
+	category DisconnectCategory
+	keywords []string
+}{
+	{DisconnectCategoryBanned, []string{"banned", "disconnectionscreen.banned", "you are ban"}},
+	{DisconnectCategoryVersion, []string{"outdated", "disconnectionscreen.outdatedclient", "disconnectionscreen.outdatedserver", "unsupported version", "incompatible version", "update your game"}},
+	{DisconnectCategoryNotAllowed, []string{"whitelist", "allowlist", "disconnectionscreen.notallowed", "not allowed", "not authenticated", "logged in with xbox live"}},
+	{DisconnectCategoryServerFull, []string{"disconnectionscreen.serverfull", "server is full", "server full"}},
+	{DisconnectCategoryServerClosed, []string{"restart", "shutting down", "shutdown", "server closed", "server stopped", "stopping"}},
+	{DisconnectCategoryTimeout, []string{"timed out", "timeout"}},
+	{DisconnectCategoryKicked, []string{"kicked", "disconnect.kicked"}},
+}
+
+// ClassifyDisconnect returns a best-effort DisconnectCategory for the disconnect message passed, such as the
+// one held by a DisconnectError. The message is matched case-insensitively against keywords and vanilla
+// translation keys, ignoring formatting codes. DisconnectCategoryUnknown is returned if no keyword matched.
+func ClassifyDisconnect(message string) DisconnectCategory {
+	message = strings.ToLower(text.Clean(message))
+	for _, c := range disconnectKeywords {
+		for _, keyword := range c.keywords {
+			if strings.Contains(message, keyword) {
+				return c.category
+			}
+		}
+	}
+	return DisconnectCategoryUnknown
+}
+
+// Category returns the best-effort DisconnectCategory of the disconnect message, as returned by
+// ClassifyDisconnect.
+func (d DisconnectError) Category() DisconnectCategory {
+	return ClassifyDisconnect(string(d))
+}
+
+// DisconnectReason returns the reason and message of the Disconnect packet that the other end closed the
+// connection with. The reason is sent by vanilla servers for telemetry and is generally 0 for other servers.
+// If the connection was not closed through a Disconnect packet, ok is false.
+func (conn *Conn) DisconnectReason() (reason int32, message string, ok bool) {
+	pk := conn.disconnectPacket.Load()
+	if pk == nil {
+		return 0, "", false
+	}
+	return pk.Reason, pk.Message, true
+}
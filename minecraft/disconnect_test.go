@@ -0,0 +1,34 @@
+package minecraft
+
+import "testing"
+
+// TestClassifyDisconnect tests that disconnect messages of servers and vanilla translation keys are classified
+// into the expected DisconnectCategory.
+func TestClassifyDisconnect(t *testing.T) {
+	tests := map[string]struct {
+		message   string
+		category  DisconnectCategory
+		reconnect bool
+	}{
+		"Empty":         {message: "", category: DisconnectCategoryUnknown, reconnect: true},
+		"Banned":        {message: "§cYou are BANNED from this server.\nReason: cheating", category: DisconnectCategoryBanned},
+		"OutdatedKey":   {message: "disconnectionScreen.outdatedClient", category: DisconnectCategoryVersion},
+		"Whitelist":     {message: "You are not whitelisted on this server!", category: DisconnectCategoryNotAllowed},
+		"XBOXLive":      {message: "§r§cYou must be logged in with XBOX Live to join.§r", category: DisconnectCategoryNotAllowed},
+		"ServerFullKey": {message: "disconnectionScreen.serverFull", category: DisconnectCategoryServerFull, reconnect: true},
+		"Restart":       {message: "Server is restarting, please reconnect shortly", category: DisconnectCategoryServerClosed, reconnect: true},
+		"Timeout":       {message: "Connection timed out", category: DisconnectCategoryTimeout, reconnect: true},
+		"Kicked":        {message: "Kicked by an operator.", category: DisconnectCategoryKicked, reconnect: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := DisconnectError(test.message).Category()
+			if c != test.category {
+				t.Fatalf("expected category %v, got %v", test.category, c)
+			}
+			if c.Reconnect() != test.reconnect {
+				t.Fatalf("expected Reconnect() to be %v for %v", test.reconnect, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package minecraft
+
+import (
+	"cmp"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Effect is an effect active on an entity, such as poison or regeneration, as sent by the server in MobEffect
+// packets.
+type Effect struct {
+	// Type is the type of the effect. It is one of the packet.Effect constants, such as packet.EffectPoison.
+	Type int32
+	// Amplifier is the amplifier of the effect. The level of the effect is generally one higher than its
+	// amplifier.
+	Amplifier int32
+	// Particles specifies if particles are shown around the entity that has the effect.
+	Particles bool
+	// Duration is the duration of the effect as last sent by the server in a MobEffect packet.
+	Duration int32
+	// Updated is the time at which the effect was last added or modified.
+	Updated time.Time
+}
+
+// effectState tracks the effects of entities sent by the server if Dialer.TrackEffects is set.
+type effectState struct {
+	mu      sync.Mutex
+	enabled bool
+	effects map[uint64]map[int32]Effect
+	// runtimeID maps the unique IDs of spawned entities to their runtime IDs, so that the effects of an
+	// entity may be cleared once a RemoveActor packet removes it.
+	runtimeID map[int64]uint64
+}
+
+// Effects returns the effects currently active on the player of the Conn, sorted by their type. It always
+// returns nil unless the Conn was dialed with Dialer.TrackEffects set.
+func (conn *Conn) Effects() []Effect {
+	return conn.EntityEffects(conn.gameData.EntityRuntimeID)
+}
+
+// EntityEffects returns the effects currently active on the entity with the runtime ID passed, sorted by
+// their type. Like Effects, it always returns nil unless the Conn was dialed with Dialer.TrackEffects set.
+func (conn *Conn) EntityEffects(entityRuntimeID uint64) []Effect {
+	conn.effects.mu.Lock()
+	defer conn.effects.mu.Unlock()
+	m := conn.effects.effects[entityRuntimeID]
+	if len(m) == 0 {
+		return nil
+	}
+	effects := make([]Effect, 0, len(m))
+	for _, e := range m {
+		effects = append(effects, e)
+	}
+	slices.SortFunc(effects, func(a, b Effect) int {
+		return cmp.Compare(a.Type, b.Type)
+	})
+	return effects
+}
+
+// handleMobEffect adds, modifies or removes the effect held in the MobEffect packet passed.
+func (s *effectState) handleMobEffect(pk *packet.MobEffect) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch pk.Operation {
+	case packet.MobEffectAdd, packet.MobEffectModify:
+		if s.effects == nil {
+			s.effects = make(map[uint64]map[int32]Effect)
+		}
+		m, ok := s.effects[pk.EntityRuntimeID]
+		if !ok {
+			m = make(map[int32]Effect)
+			s.effects[pk.EntityRuntimeID] = m
+		}
+		m[pk.EffectType] = Effect{
+			Type:      pk.EffectType,
+			Amplifier: pk.Amplifier,
+			Particles: pk.Particles,
+			Duration:  pk.Duration,
+			Updated:   time.Now(),
+		}
+	case packet.MobEffectRemove:
+		m := s.effects[pk.EntityRuntimeID]
+		delete(m, pk.EffectType)
+		if len(m) == 0 {
+			delete(s.effects, pk.EntityRuntimeID)
+		}
+	}
+}
+
+// handleAddEntity registers the unique and runtime ID of an entity spawned in an AddActor or AddPlayer packet.
+func (s *effectState) handleAddEntity(uniqueID int64, runtimeID uint64) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runtimeID == nil {
+		s.runtimeID = make(map[int64]uint64)
+	}
+	s.runtimeID[uniqueID] = runtimeID
+}
+
+// handleRemoveActor clears the effects of the entity removed in the RemoveActor packet passed.
+func (s *effectState) handleRemoveActor(pk *packet.RemoveActor) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if runtimeID, ok := s.runtimeID[pk.EntityUniqueID]; ok {
+		delete(s.effects, runtimeID)
+		delete(s.runtimeID, pk.EntityUniqueID)
+	}
+}
@@ -0,0 +1,31 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+// TestEffectsRemoveActor tests that the effects of an entity are sorted by their type and cleared once the
+// entity is removed using a RemoveActor packet.
+func TestEffectsRemoveActor(t *testing.T) {
+	conn := &Conn{}
+	conn.effects.enabled = true
+	conn.observePacket(&packet.AddActor{EntityUniqueID: -5, EntityRuntimeID: 7})
+	conn.observePacket(&packet.MobEffect{EntityRuntimeID: 7, Operation: packet.MobEffectAdd, EffectType: packet.EffectPoison})
+	conn.observePacket(&packet.MobEffect{EntityRuntimeID: 7, Operation: packet.MobEffectAdd, EffectType: packet.EffectSpeed})
+	// Effects of an entity that was never spawned are kept until they are removed.
+	conn.observePacket(&packet.MobEffect{EntityRuntimeID: 8, Operation: packet.MobEffectAdd, EffectType: packet.EffectSpeed})
+
+	effects := conn.EntityEffects(7)
+	if len(effects) != 2 || effects[0].Type != packet.EffectSpeed || effects[1].Type != packet.EffectPoison {
+		t.Fatalf("expected speed and poison effects sorted by type, got %+v", effects)
+	}
+
+	conn.observePacket(&packet.RemoveActor{EntityUniqueID: -5})
+	if effects := conn.EntityEffects(7); effects != nil {
+		t.Fatalf("expected effects to be cleared after the entity was removed, got %+v", effects)
+	}
+	if len(conn.effects.effects) != 1 || len(conn.effects.runtimeID) != 0 {
+		t.Fatalf("expected only the effects of the other entity to remain, got %v", conn.effects.effects)
+	}
+}
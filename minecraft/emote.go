@@ -0,0 +1,70 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// PlayerEmote is an emote performed by a player, as read from an Emote packet.
+type PlayerEmote struct {
+	// EntityRuntimeID is the runtime ID of the player that performed the emote.
+	EntityRuntimeID uint64
+	// EmoteID is the UUID of the emote performed.
+	EmoteID uuid.UUID
+	// XUID is the XUID of the player that performed the emote. It is empty if the player is not authenticated
+	// with XBOX Live.
+	XUID string
+	// PlatformID is an identifier of the platform of the player, which is only set on some platforms.
+	PlatformID string
+	// ServerSide specifies if the emote was broadcast by the server rather than sent by the client.
+	ServerSide bool
+	// MuteChat specifies if the chat message that the client shows for the emote is muted.
+	MuteChat bool
+}
+
+// Emote makes the player of the Conn perform the emote with the UUID passed, such as
+// '4c8ae710-df2e-47cd-814d-cc7bf21a3d67'. For a Conn obtained using a Dialer, the server generally broadcasts
+// the emote to other players. For a Conn obtained from a Listener, the emote is shown to the client as if
+// performed by its own player. An error is returned if the emote ID is not a valid UUID.
+func (conn *Conn) Emote(emoteID string) error {
+	id, err := uuid.Parse(emoteID)
+	if err != nil {
+		return conn.wrap(fmt.Errorf("invalid emote ID %q: %w", emoteID, err), "emote")
+	}
+	pk := &packet.Emote{
+		EntityRuntimeID: conn.gameData.EntityRuntimeID,
+		EmoteID:         id.String(),
+		XUID:            conn.identityData.XUID,
+	}
+	if conn.readerLimits {
+		// Emotes sent by the server must have the server side flag set, or the client ignores them.
+		pk.Flags |= packet.EmoteFlagServerSide
+	}
+	return conn.WritePacket(pk)
+}
+
+// handleEmote passes the emote held in the Emote packet passed to the emote function of the Conn, if set.
+// Emotes with an invalid UUID, and emotes that a client sends on behalf of another entity, are ignored.
+func (conn *Conn) handleEmote(pk *packet.Emote) {
+	if conn.emoteFunc == nil {
+		return
+	}
+	id, err := uuid.Parse(pk.EmoteID)
+	if err != nil {
+		conn.log.Warn("ignoring emote with invalid ID", "id", pk.EmoteID)
+		return
+	}
+	if conn.readerLimits && pk.EntityRuntimeID != conn.gameData.EntityRuntimeID {
+		conn.log.Warn("ignoring emote for other entity", "entityRuntimeID", pk.EntityRuntimeID)
+		return
+	}
+	conn.emoteFunc(conn, PlayerEmote{
+		EntityRuntimeID: pk.EntityRuntimeID,
+		EmoteID:         id,
+		XUID:            pk.XUID,
+		PlatformID:      pk.PlatformID,
+		ServerSide:      pk.Flags&packet.EmoteFlagServerSide != 0,
+		MuteChat:        pk.Flags&packet.EmoteFlagMuteChat != 0,
+	})
+}
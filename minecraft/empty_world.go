@@ -0,0 +1,80 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// AcceptEmptyWorld accepts the next connection from the Listener passed and spawns it in an empty world,
+// after which the Conn is returned to the caller. It serves as a starting point for servers: the Conn returned
+// is fully spawned and may be used to read and write packets directly.
+// The GameData passed is used to start the game. If the EntityUniqueID, EntityRuntimeID or PlayerPosition are
+// not set, suitable defaults are used. The context passed is used to cancel spawning the Conn. If spawning
+// fails, the Conn is closed and an error is returned.
+func AcceptEmptyWorld(ctx context.Context, listener *Listener, data GameData) (*Conn, error) {
+	c, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	conn := c.(*Conn)
+
+	if data.EntityUniqueID == 0 {
+		data.EntityUniqueID = 1
+	}
+	if data.EntityRuntimeID == 0 {
+		data.EntityRuntimeID = 1
+	}
+	if data.PlayerPosition == (mgl32.Vec3{}) {
+		data.PlayerPosition = mgl32.Vec3{0, 1.62, 0}
+	}
+	if err := conn.StartGameContext(ctx, data); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := conn.sendEmptyChunks(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// emptyChunkPayload is the payload of a LevelChunk packet without any sub chunks. It holds 24 biome sections
+// (the height of the overworld) with all biomes set to plains, followed by a zero byte for the border blocks.
+var emptyChunkPayload = func() []byte {
+	b := make([]byte, 0, 49)
+	for i := 0; i < 24; i++ {
+		// A palette header with 0 bits per entry, followed by the plains biome ID (1) as varint32.
+		b = append(b, 1, 2)
+	}
+	return append(b, 0)
+}()
+
+// sendEmptyChunks sends empty chunks around the spawn position of the Conn, within the chunk radius that was
+// negotiated with the client.
+func (conn *Conn) sendEmptyChunks() error {
+	pos := conn.gameData.PlayerPosition
+	radius := int32(conn.ChunkRadius())
+	centre := protocol.ChunkPos{int32(pos[0]) >> 4, int32(pos[2]) >> 4}
+
+	if err := conn.WritePacket(&packet.NetworkChunkPublisherUpdate{
+		Position: protocol.BlockPos{int32(pos[0]), int32(pos[1]), int32(pos[2])},
+		Radius:   uint32(radius) << 4,
+	}); err != nil {
+		return fmt.Errorf("error sending chunk publisher update: %w", err)
+	}
+	for x := centre[0] - radius; x <= centre[0]+radius; x++ {
+		for z := centre[1] - radius; z <= centre[1]+radius; z++ {
+			if err := conn.WritePacket(&packet.LevelChunk{
+				Position:   protocol.ChunkPos{x, z},
+				Dimension:  conn.gameData.Dimension,
+				RawPayload: emptyChunkPayload,
+			}); err != nil {
+				return fmt.Errorf("error sending empty chunk: %w", err)
+			}
+		}
+	}
+	return conn.Flush()
+}
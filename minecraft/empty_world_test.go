@@ -0,0 +1,42 @@
+package minecraft_test
+
+import (
+	"context"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"testing"
+	"time"
+)
+
+// TestAcceptEmptyWorld tests that a client can dial a Listener that spawns connections using
+// minecraft.AcceptEmptyWorld.
+func TestAcceptEmptyWorld(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		conn, err := minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{WorldName: "Empty World"})
+		if err == nil {
+			_ = conn.Close()
+		}
+		errs <- err
+	}()
+
+	conn, err := minecraft.Dialer{}.DialContext(ctx, "raknet", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if name := conn.GameData().WorldName; name != "Empty World" {
+		t.Errorf("expected world name %q, got %q", "Empty World", name)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("error spawning connection: %v", err)
+	}
+}
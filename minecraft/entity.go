@@ -0,0 +1,215 @@
+package minecraft
+
+import (
+	"cmp"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Entity is an entity other than a player, as spawned by the server using an AddActor packet.
+type Entity struct {
+	// UniqueID is the unique ID of the entity. Servers generally fill it out with the runtime ID.
+	UniqueID int64
+	// RuntimeID is the runtime ID of the entity, which is used to identify it in most packets.
+	RuntimeID uint64
+	// Type is the type of the entity, such as 'minecraft:skeleton'.
+	Type string
+	// Position is the position of the entity.
+	Position mgl32.Vec3
+	// Velocity is the velocity of the entity when it was spawned.
+	Velocity mgl32.Vec3
+	// Pitch, Yaw, HeadYaw and BodyYaw make up the rotation of the entity, measured in degrees.
+	Pitch, Yaw, HeadYaw, BodyYaw float32
+	// OnGround specifies if the entity was on the ground after its last movement.
+	OnGround bool
+	// Attributes holds the attributes of the entity, such as its health, keyed by their name.
+	Attributes map[string]protocol.AttributeValue
+	// Metadata holds the metadata of the entity, keyed by the protocol.EntityData constants. The changes sent
+	// in SetActorData packets are merged into it.
+	Metadata map[uint32]any
+	// Properties holds the properties of the entity, sorted by their index. The changes sent in SetActorData
+	// packets are merged into it.
+	Properties protocol.EntityProperties
+	// Links holds the entity links active on the entity when it was spawned, such as an entity riding it.
+	Links []protocol.EntityLink
+}
+
+// ParseEntity parses the entity spawned by the AddActor packet passed.
+func ParseEntity(pk *packet.AddActor) Entity {
+	attributes := make(map[string]protocol.AttributeValue, len(pk.Attributes))
+	for _, attr := range pk.Attributes {
+		attributes[attr.Name] = attr
+	}
+	return Entity{
+		UniqueID:   pk.EntityUniqueID,
+		RuntimeID:  pk.EntityRuntimeID,
+		Type:       pk.EntityType,
+		Position:   pk.Position,
+		Velocity:   pk.Velocity,
+		Pitch:      pk.Pitch,
+		Yaw:        pk.Yaw,
+		HeadYaw:    pk.HeadYaw,
+		BodyYaw:    pk.BodyYaw,
+		Attributes: attributes,
+		Metadata:   pk.EntityMetadata,
+		Properties: mergeEntityProperties(protocol.EntityProperties{}, pk.EntityProperties),
+		Links:      pk.EntityLinks,
+	}
+}
+
+// entityTracker tracks the entities spawned by the server if Dialer.TrackEntities is set.
+type entityTracker struct {
+	mu        sync.Mutex
+	enabled   bool
+	entities  map[uint64]Entity
+	runtimeID map[int64]uint64
+}
+
+// Entities returns all entities currently spawned by the server. It always returns nil unless the Conn was
+// dialed with Dialer.TrackEntities set. The maps and slices in the entities returned must not be modified.
+func (conn *Conn) Entities() []Entity {
+	conn.entities.mu.Lock()
+	defer conn.entities.mu.Unlock()
+	if len(conn.entities.entities) == 0 {
+		return nil
+	}
+	entities := make([]Entity, 0, len(conn.entities.entities))
+	for _, e := range conn.entities.entities {
+		entities = append(entities, e)
+	}
+	return entities
+}
+
+// Entity looks up an entity spawned by the server by its runtime ID. False is returned if no entity with the
+// runtime ID is spawned or if the Conn was not dialed with Dialer.TrackEntities set.
+func (conn *Conn) Entity(runtimeID uint64) (Entity, bool) {
+	conn.entities.mu.Lock()
+	defer conn.entities.mu.Unlock()
+	e, ok := conn.entities.entities[runtimeID]
+	return e, ok
+}
+
+// handleAddActor adds the entity spawned in the AddActor packet passed.
+func (t *entityTracker) handleAddActor(pk *packet.AddActor) {
+	if !t.enabled {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entities == nil {
+		t.entities, t.runtimeID = make(map[uint64]Entity), make(map[int64]uint64)
+	}
+	t.entities[pk.EntityRuntimeID] = ParseEntity(pk)
+	t.runtimeID[pk.EntityUniqueID] = pk.EntityRuntimeID
+}
+
+// handleMoveActorAbsolute updates the position and rotation of the entity moved in the MoveActorAbsolute
+// packet passed.
+func (t *entityTracker) handleMoveActorAbsolute(pk *packet.MoveActorAbsolute) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entities[pk.EntityRuntimeID]
+	if !ok {
+		return
+	}
+	e.Position, e.OnGround = pk.Position, pk.Flags&packet.MoveFlagOnGround != 0
+	e.Pitch, e.Yaw, e.HeadYaw = pk.Rotation[0], pk.Rotation[1], pk.Rotation[2]
+	t.entities[pk.EntityRuntimeID] = e
+}
+
+// handleMoveActorDelta updates the position and rotation of the entity moved in the MoveActorDelta packet
+// passed. Only the fields present in the packet are updated.
+func (t *entityTracker) handleMoveActorDelta(pk *packet.MoveActorDelta) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entities[pk.EntityRuntimeID]
+	if !ok {
+		return
+	}
+	// Since 1.16.100, the packet holds absolute values rather than deltas for the fields present.
+	fields := []struct {
+		flag uint16
+		dst  *float32
+		src  float32
+	}{
+		{packet.MoveActorDeltaFlagHasX, &e.Position[0], pk.Position[0]},
+		{packet.MoveActorDeltaFlagHasY, &e.Position[1], pk.Position[1]},
+		{packet.MoveActorDeltaFlagHasZ, &e.Position[2], pk.Position[2]},
+		{packet.MoveActorDeltaFlagHasRotX, &e.Pitch, pk.Rotation[0]},
+		{packet.MoveActorDeltaFlagHasRotY, &e.Yaw, pk.Rotation[1]},
+		{packet.MoveActorDeltaFlagHasRotZ, &e.HeadYaw, pk.Rotation[2]},
+	}
+	for _, f := range fields {
+		if pk.Flags&f.flag != 0 {
+			*f.dst = f.src
+		}
+	}
+	e.OnGround = pk.Flags&packet.MoveActorDeltaFlagOnGround != 0
+	t.entities[pk.EntityRuntimeID] = e
+}
+
+// handleSetActorData merges the metadata and properties changed in the SetActorData packet passed into those
+// of the entity. The metadata map and properties are copied rather than changed in place, as entities
+// returned by Conn.Entities before may still refer to them.
+func (t *entityTracker) handleSetActorData(pk *packet.SetActorData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entities[pk.EntityRuntimeID]
+	if !ok {
+		return
+	}
+	metadata := protocol.EntityMetadata(maps.Clone(e.Metadata))
+	if metadata == nil {
+		metadata = make(protocol.EntityMetadata, len(pk.EntityMetadata))
+	}
+	metadata.Apply(pk.EntityMetadata)
+	e.Metadata = metadata
+	e.Properties = mergeEntityProperties(e.Properties, pk.EntityProperties)
+	t.entities[pk.EntityRuntimeID] = e
+}
+
+// mergeEntityProperties returns the entity properties held by current after overwriting them with those in
+// delta that have the same index, and adding those in delta with a new index. The properties returned are
+// sorted by their index. current is not changed.
+func mergeEntityProperties(current, delta protocol.EntityProperties) protocol.EntityProperties {
+	ints := make(map[uint32]int32, len(current.IntegerProperties)+len(delta.IntegerProperties))
+	for _, props := range [][]protocol.IntegerEntityProperty{current.IntegerProperties, delta.IntegerProperties} {
+		for _, prop := range props {
+			ints[prop.Index] = prop.Value
+		}
+	}
+	floats := make(map[uint32]float32, len(current.FloatProperties)+len(delta.FloatProperties))
+	for _, props := range [][]protocol.FloatEntityProperty{current.FloatProperties, delta.FloatProperties} {
+		for _, prop := range props {
+			floats[prop.Index] = prop.Value
+		}
+	}
+	var merged protocol.EntityProperties
+	for index, v := range ints {
+		merged.IntegerProperties = append(merged.IntegerProperties, protocol.IntegerEntityProperty{Index: index, Value: v})
+	}
+	for index, v := range floats {
+		merged.FloatProperties = append(merged.FloatProperties, protocol.FloatEntityProperty{Index: index, Value: v})
+	}
+	slices.SortFunc(merged.IntegerProperties, func(a, b protocol.IntegerEntityProperty) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+	slices.SortFunc(merged.FloatProperties, func(a, b protocol.FloatEntityProperty) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+	return merged
+}
+
+// handleRemoveActor removes the entity removed in the RemoveActor packet passed.
+func (t *entityTracker) handleRemoveActor(pk *packet.RemoveActor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if runtimeID, ok := t.runtimeID[pk.EntityUniqueID]; ok {
+		delete(t.entities, runtimeID)
+		delete(t.runtimeID, pk.EntityUniqueID)
+	}
+}
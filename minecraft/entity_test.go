@@ -0,0 +1,87 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSetActorDataMerge(t *testing.T) {
+	tracker := entityTracker{enabled: true}
+	tracker.handleAddActor(&packet.AddActor{
+		EntityUniqueID:  1,
+		EntityRuntimeID: 1,
+		EntityMetadata: map[uint32]any{
+			protocol.EntityDataKeyName:  "zombie",
+			protocol.EntityDataKeyScale: float32(1),
+		},
+		EntityProperties: protocol.EntityProperties{
+			IntegerProperties: []protocol.IntegerEntityProperty{{Index: 0, Value: 1}},
+		},
+	})
+	spawned := tracker.entities[1]
+
+	deltas := []*packet.SetActorData{
+		{EntityRuntimeID: 1, EntityMetadata: map[uint32]any{protocol.EntityDataKeyScale: float32(2)}},
+		{EntityRuntimeID: 1, EntityMetadata: map[uint32]any{protocol.EntityDataKeyName: int32(5)}},
+		{EntityRuntimeID: 1, EntityMetadata: map[uint32]any{protocol.EntityDataKeyVariant: int32(3)}, EntityProperties: protocol.EntityProperties{
+			IntegerProperties: []protocol.IntegerEntityProperty{{Index: 2, Value: 4}, {Index: 0, Value: 7}},
+			FloatProperties:   []protocol.FloatEntityProperty{{Index: 1, Value: 0.5}},
+		}},
+		// A SetActorData packet for an entity that is not tracked must be ignored.
+		{EntityRuntimeID: 2, EntityMetadata: map[uint32]any{protocol.EntityDataKeyVariant: int32(9)}},
+	}
+	for _, pk := range deltas {
+		tracker.handleSetActorData(pk)
+	}
+
+	e := tracker.entities[1]
+	expectedMetadata := map[uint32]any{
+		protocol.EntityDataKeyName:    int32(5),
+		protocol.EntityDataKeyScale:   float32(2),
+		protocol.EntityDataKeyVariant: int32(3),
+	}
+	if !reflect.DeepEqual(e.Metadata, expectedMetadata) {
+		t.Fatalf("expected metadata %v, got %v", expectedMetadata, e.Metadata)
+	}
+	expectedProperties := protocol.EntityProperties{
+		IntegerProperties: []protocol.IntegerEntityProperty{{Index: 0, Value: 7}, {Index: 2, Value: 4}},
+		FloatProperties:   []protocol.FloatEntityProperty{{Index: 1, Value: 0.5}},
+	}
+	if !reflect.DeepEqual(e.Properties, expectedProperties) {
+		t.Fatalf("expected properties %v, got %v", expectedProperties, e.Properties)
+	}
+	if name := spawned.Metadata[protocol.EntityDataKeyName]; name != "zombie" {
+		t.Fatalf("expected metadata of entity returned before to be unchanged, got name %v", name)
+	}
+	if _, ok := tracker.entities[2]; ok {
+		t.Fatalf("expected untracked entity to be ignored")
+	}
+}
+
+func TestEntityMetadataDiffApply(t *testing.T) {
+	m := protocol.EntityMetadata{
+		protocol.EntityDataKeyName:    "zombie",
+		protocol.EntityDataKeyScale:   float32(1),
+		protocol.EntityDataKeyVariant: int32(1),
+	}
+	other := protocol.EntityMetadata{
+		protocol.EntityDataKeyName:       "zombie",
+		protocol.EntityDataKeyScale:      int64(2),
+		protocol.EntityDataKeyColorIndex: uint8(4),
+	}
+	changed := m.Apply(m.Diff(other))
+	if !reflect.DeepEqual(m, other) {
+		t.Fatalf("expected metadata %v after applying diff, got %v", other, m)
+	}
+	expectedChanged := []uint32{protocol.EntityDataKeyVariant, protocol.EntityDataKeyColorIndex, protocol.EntityDataKeyScale}
+	slices.Sort(expectedChanged)
+	if !reflect.DeepEqual(changed, expectedChanged) {
+		t.Fatalf("expected changed keys %v, got %v", expectedChanged, changed)
+	}
+	if changed := m.Apply(map[uint32]any{protocol.EntityDataKeyName: "zombie"}); len(changed) != 0 {
+		t.Fatalf("expected no changed keys when applying an equal value, got %v", changed)
+	}
+}
@@ -2,16 +2,53 @@ package minecraft
 
 import (
 	"errors"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"net"
+	"time"
 )
 
 var (
-	// TODO: Change this to net.ErrClosed in 1.16.
-	errClosed         = errors.New("use of closed network connection")
 	errBufferTooSmall = errors.New("a message sent was larger than the buffer used to receive the message into")
 	errListenerClosed = errors.New("use of closed listener")
+	errNotListener    = errors.New("only supported for connections obtained from a Listener")
+	errNotDialer      = errors.New("only supported for connections obtained using a Dialer")
 )
 
+// ErrConnClosed is returned by operations on a Conn that was closed, such as WritePacket and ReadPacket. It
+// is wrapped in a net.OpError and may be checked for using errors.Is. If the Conn was closed because of a
+// Disconnect packet, a failed login or a login timeout, a DisconnectError, LoginFailedError or
+// LoginTimeoutError is returned instead, which errors.Is also reports as ErrConnClosed. ErrConnClosed is
+// equal to net.ErrClosed.
+var ErrConnClosed = net.ErrClosed
+
+// nonFatalError is an error that occurred while handling a single packet, which does not require the
+// connection to be closed. Such errors are logged, after which the connection continues reading packets.
+// Errors are non-fatal if the packet could not be read or decoded while unknown or invalid packets are
+// allowed, or if the packet is a resource pack packet that does not affect the packets being downloaded.
+// Any other error returned while handling a packet closes the connection.
+type nonFatalError struct {
+	err error
+}
+
+// Error ...
+func (err nonFatalError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err nonFatalError) Unwrap() error {
+	return err.err
+}
+
+// isFatal checks if the error passed, returned while handling an incoming packet, requires the connection to
+// be closed.
+func isFatal(err error) bool {
+	var nonFatal nonFatalError
+	return !errors.As(err, &nonFatal)
+}
+
 // wrap wraps the error passed into a net.OpError with the op as operation and returns it, or nil if the error
 // passed is nil.
 func (conn *Conn) wrap(err error, op string) error {
@@ -27,6 +64,114 @@ func (conn *Conn) wrap(err error, op string) error {
 	}
 }
 
+// listenerOnly returns an error wrapped for the op passed if the Conn was not obtained from a Listener. It is
+// used by methods that send packets that only a server may send.
+func (conn *Conn) listenerOnly(op string) error {
+	if conn.readerLimits {
+		return nil
+	}
+	return conn.wrap(errNotListener, op)
+}
+
+// dialerOnly returns an error wrapped for the op passed if the Conn was not obtained using a Dialer. It is
+// used by methods that send packets that only a client may send.
+func (conn *Conn) dialerOnly(op string) error {
+	if !conn.readerLimits {
+		return nil
+	}
+	return conn.wrap(errNotDialer, op)
+}
+
+// CloseTimeoutError is returned by Conn.CloseGracefully if the connection could not be closed gracefully
+// within the timeout passed. It is wrapped in a net.OpError and may be obtained using errors.As.
+type CloseTimeoutError struct {
+	// Timeout is the timeout that expired.
+	Timeout time.Duration
+}
+
+// Error ...
+func (err CloseTimeoutError) Error() string {
+	return fmt.Sprintf("connection not closed gracefully within %v", err.Timeout)
+}
+
+// LoginTimeoutError is the error that a connection of a Listener is closed with if it did not complete the
+// login sequence within ListenConfig.LoginTimeout. It is wrapped in a net.OpError and may be obtained using
+// errors.As.
+type LoginTimeoutError struct {
+	// Timeout is the timeout that expired.
+	Timeout time.Duration
+}
+
+// Error ...
+func (err LoginTimeoutError) Error() string {
+	return fmt.Sprintf("login sequence not completed within %v", err.Timeout)
+}
+
+// Is reports if the target is ErrConnClosed, as a Conn is closed when its login times out.
+func (err LoginTimeoutError) Is(target error) bool {
+	return target == ErrConnClosed
+}
+
+// HandshakeError is returned when dialing a server if the ServerToClientHandshake packet that it sent could
+// not be verified, for example because the JWT it holds was not signed with the public key found in it, or
+// because that key is not the one set in Dialer.ServerPublicKey. It is wrapped in a net.OpError and may be
+// obtained using errors.As.
+type HandshakeError struct {
+	// Err is the reason the handshake could not be verified.
+	Err error
+}
+
+// Error ...
+func (err HandshakeError) Error() string {
+	return "verify server handshake: " + err.Err.Error()
+}
+
+// Unwrap returns the reason the handshake could not be verified.
+func (err HandshakeError) Unwrap() error {
+	return err.Err
+}
+
+// MovementModeError is returned when dialing a server if the player movement settings that it sent in the
+// StartGame packet are not compatible with Dialer.PlayerMovementMode or Dialer.MaxRewindHistorySize. It is
+// wrapped in a net.OpError and may be obtained using errors.As.
+type MovementModeError struct {
+	// Settings are the player movement settings sent by the server.
+	Settings protocol.PlayerMovementSettings
+	// Mode is the movement mode supported by the client. If it is equal to the movement mode of Settings,
+	// the rewind history size of Settings exceeded MaxRewindHistorySize.
+	Mode int32
+	// MaxRewindHistorySize is the maximum rewind history size supported by the client, or 0 if the movement
+	// modes differ.
+	MaxRewindHistorySize int32
+}
+
+// Error ...
+func (err MovementModeError) Error() string {
+	if err.Settings.MovementType != err.Mode {
+		return fmt.Sprintf("server uses %v movement mode, client supports %v movement mode", movementModeString(err.Settings.MovementType), movementModeString(err.Mode))
+	}
+	return fmt.Sprintf("server rewind history size %v exceeds maximum of %v", err.Settings.RewindHistorySize, err.MaxRewindHistorySize)
+}
+
+// Is reports if the target is ErrConnClosed, as a Conn is closed when its movement settings are not
+// compatible.
+func (err MovementModeError) Is(target error) bool {
+	return target == ErrConnClosed
+}
+
+// movementModeString returns a readable name for the player movement mode passed.
+func movementModeString(mode int32) string {
+	switch mode {
+	case protocol.PlayerMovementModeClient:
+		return "client"
+	case protocol.PlayerMovementModeServer:
+		return "server"
+	case protocol.PlayerMovementModeServerWithRewind:
+		return "server with rewind"
+	}
+	return fmt.Sprintf("unknown (%v)", mode)
+}
+
 // DisconnectError is an error returned by operations from Conn when the connection is closed by the other
 // end through a packet.Disconnect. It is wrapped in a net.OpError and may be obtained using
 // errors.Unwrap(net.OpError).
@@ -36,3 +181,58 @@ type DisconnectError string
 func (d DisconnectError) Error() string {
 	return string(d)
 }
+
+// Is reports if the target is ErrConnClosed, as a Conn is closed when it is disconnected.
+func (d DisconnectError) Is(target error) bool {
+	return target == ErrConnClosed
+}
+
+// LoginFailedError is returned by Dial if the server refused the login of the client because their protocol
+// versions are incompatible, as indicated by a packet.PlayStatus with the PlayStatusLoginFailedClient or
+// PlayStatusLoginFailedServer status. It is wrapped in a net.OpError and may be obtained using errors.As.
+type LoginFailedError struct {
+	// Status is the status of the packet.PlayStatus, which is either packet.PlayStatusLoginFailedClient or
+	// packet.PlayStatusLoginFailedServer.
+	Status int32
+	// ExpectedProtocol is the protocol version of the server. It is 0 if it is not known, which is the case if
+	// the server did not respond to the ping sent before connecting.
+	ExpectedProtocol int32
+	// GotProtocol is the protocol version of the client.
+	GotProtocol int32
+}
+
+// Error ...
+func (err LoginFailedError) Error() string {
+	msg := "server outdated"
+	if err.Status == packet.PlayStatusLoginFailedClient {
+		msg = "client outdated"
+	}
+	msg += ": client protocol = " + protocolString(err.GotProtocol)
+	if err.ExpectedProtocol != 0 {
+		msg += ", server protocol = " + protocolString(err.ExpectedProtocol)
+	}
+	return msg + " (" + err.Hint() + ")"
+}
+
+// Is reports if the target is ErrConnClosed, as a Conn is closed when its login fails.
+func (err LoginFailedError) Is(target error) bool {
+	return target == ErrConnClosed
+}
+
+// Hint returns a suggestion on how to resolve the incompatibility between the protocol versions of the client
+// and the server.
+func (err LoginFailedError) Hint() string {
+	if err.Status == packet.PlayStatusLoginFailedClient {
+		return "update the client or set Dialer.Protocol to the version of the server"
+	}
+	return "connect using an older client, or add the protocol of the client to ListenConfig.AcceptedProtocols of the server"
+}
+
+// protocolString returns the protocol number passed together with the name of its Minecraft version, if a
+// protocol.Version with the protocol number is registered.
+func protocolString(id int32) string {
+	if v, ok := protocol.VersionByProtocol(id); ok {
+		return fmt.Sprintf("%v (v%v)", id, v.Name)
+	}
+	return fmt.Sprint(id)
+}
@@ -0,0 +1,168 @@
+package minecraft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// FormResponse is the response of a player to a form sent using Conn.SendForm. It is either submitted, in
+// which case Data holds the JSON encoded response, or cancelled, for example because the player closed the
+// form.
+type FormResponse struct {
+	// FormID is the ID of the form that the response is for.
+	FormID uint32
+	// Data is the JSON encoded response of the player. It is nil if the form was cancelled.
+	Data []byte
+	// Cancelled specifies if the player did not submit the form. CancelReason holds the reason if this is the
+	// case.
+	Cancelled bool
+	// CancelReason is the reason the form was cancelled. It is one of the packet.ModalFormCancelReason
+	// constants.
+	CancelReason uint8
+}
+
+// Button returns the index of the button clicked by the player in response to a menu form. An error is
+// returned if the form was cancelled or if the response is not a button index.
+func (resp FormResponse) Button() (int, error) {
+	var index int
+	if err := resp.decode(&index); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// Modal returns the button clicked by the player in response to a modal form: true for the first button and
+// false for the second button. An error is returned if the form was cancelled or if the response is not a
+// boolean.
+func (resp FormResponse) Modal() (bool, error) {
+	var b bool
+	if err := resp.decode(&b); err != nil {
+		return false, err
+	}
+	return b, nil
+}
+
+// Values returns the values the player submitted in response to a custom form, with one value for every
+// element of the form. An error is returned if the form was cancelled or if the response is not an array.
+func (resp FormResponse) Values() ([]any, error) {
+	var values []any
+	if err := resp.decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// decode decodes the JSON encoded data of the response into v.
+func (resp FormResponse) decode(v any) error {
+	if resp.Cancelled {
+		return fmt.Errorf("form %v was cancelled", resp.FormID)
+	}
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		return fmt.Errorf("decode response to form %v: %w", resp.FormID, err)
+	}
+	return nil
+}
+
+// formRequests keeps track of forms sent by a Conn that have not yet received a response from the client. The
+// zero value is ready to use.
+type formRequests struct {
+	mu      sync.Mutex
+	id      uint32
+	pending map[uint32]chan FormResponse
+	// closed is true once the Conn is closed, after which no responses will arrive anymore.
+	closed bool
+}
+
+// next assigns a new form ID and returns it together with a channel that receives the response to the form
+// with that ID. If the Conn is already closed, false is returned.
+func (r *formRequests) next() (uint32, chan FormResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, nil, false
+	}
+	if r.pending == nil {
+		r.pending = make(map[uint32]chan FormResponse)
+	}
+	r.id++
+	c := make(chan FormResponse, 1)
+	r.pending[r.id] = c
+	return r.id, c, true
+}
+
+// resolve passes the response held in the ModalFormResponse packet passed to the channel of the form that it
+// responds to. Responses to forms not sent through SendForm are ignored.
+func (r *formRequests) resolve(pk *packet.ModalFormResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.pending[pk.FormID]
+	if !ok {
+		return
+	}
+	delete(r.pending, pk.FormID)
+
+	resp := FormResponse{FormID: pk.FormID}
+	data, ok := pk.ResponseData.Value()
+	// Older clients do not send a cancel reason, but send a JSON encoded null if the form was closed.
+	if !ok || bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		resp.Cancelled, resp.CancelReason = true, packet.ModalFormCancelReasonUserClosed
+		if reason, ok := pk.CancelReason.Value(); ok {
+			resp.CancelReason = reason
+		}
+	} else {
+		resp.Data = data
+	}
+	c <- resp
+}
+
+// cancel stops tracking the form with the ID passed, for example if it could not be sent.
+func (r *formRequests) cancel(id uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+}
+
+// close closes the channels of all forms that have not yet received a response, as none will arrive after the
+// Conn is closed.
+func (r *formRequests) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for id, c := range r.pending {
+		close(c)
+		delete(r.pending, id)
+	}
+}
+
+// SendForm sends a form to the player of the Conn using a ModalFormRequest packet. The form is encoded using
+// json.Marshal, so it may either be a value that marshals into a form definition or a json.RawMessage holding
+// one. The form is assigned a unique form ID automatically. The channel returned receives the FormResponse
+// that the client sends once the player submits or closes the form. If the Conn is closed before the response
+// arrives, the channel is closed without receiving a value, so that a receive on it returns ok as false.
+// Responses are only matched with their forms when the ModalFormResponse packet holding them is read using
+// Conn.ReadPacket, so ReadPacket must be called continuously for the channel to ever receive a value. An error
+// is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) SendForm(form any) (<-chan FormResponse, error) {
+	if err := conn.listenerOnly("send form"); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(form)
+	if err != nil {
+		return nil, conn.wrap(fmt.Errorf("encode form: %w", err), "send form")
+	}
+	id, c, ok := conn.forms.next()
+	if !ok {
+		return nil, conn.closeErr("send form")
+	}
+	if err := conn.WritePacket(&packet.ModalFormRequest{FormID: id, FormData: data}); err != nil {
+		conn.forms.cancel(id)
+		return nil, err
+	}
+	return c, nil
+}
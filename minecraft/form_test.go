@@ -0,0 +1,63 @@
+package minecraft
+
+import (
+	"errors"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestSendForm tests that the response to a form is passed to the channel returned by SendForm, that the
+// channel of a form without response is closed when the Conn is closed and that no forms may be sent after.
+func TestSendForm(t *testing.T) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	conn.readerLimits = true
+	defer other.Close()
+	go func() {
+		_, _ = io.Copy(io.Discard, other)
+	}()
+
+	resolved, err := conn.SendForm(map[string]any{"type": "modal"})
+	if err != nil {
+		t.Fatalf("error sending form: %v", err)
+	}
+	pending, err := conn.SendForm(map[string]any{"type": "modal"})
+	if err != nil {
+		t.Fatalf("error sending form: %v", err)
+	}
+	conn.observePacket(&packet.ModalFormResponse{FormID: 1, ResponseData: protocol.Option([]byte("true"))})
+	select {
+	case resp := <-resolved:
+		if b, err := resp.Modal(); err != nil || !b {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected response to be passed to the channel")
+	}
+
+	done := make(chan bool)
+	go func() {
+		_, ok := <-pending
+		done <- ok
+	}()
+	_ = conn.Close()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatalf("expected no response for the pending form")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected channel of pending form to be closed when the Conn is closed")
+	}
+	if len(conn.forms.pending) != 0 {
+		t.Fatalf("expected no pending forms after closing, got %v", len(conn.forms.pending))
+	}
+	if _, err := conn.SendForm(map[string]any{"type": "modal"}); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed sending a form after closing, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package minecraft
+
+import (
+	"fmt"
+)
+
+// HandshakeStage is a stage of the login sequence of a Conn, which is passed to Dialer.HandshakeFunc and
+// ListenConfig.HandshakeFunc as the Conn progresses through the sequence. The stages are always reached in
+// the order in which the constants below are defined, although stages may be skipped. Each stage is reached
+// at most once for every Conn.
+type HandshakeStage uint8
+
+const (
+	// HandshakeStageNetworkSettings is reached once compression was negotiated using the NetworkSettings
+	// packet, directly before the Login packet is sent or received.
+	HandshakeStageNetworkSettings HandshakeStage = iota
+	// HandshakeStageEncryption is reached once encryption was enabled using the ServerToClientHandshake
+	// packet. It is skipped if encryption is disabled using Dialer.DisableEncryption or
+	// ListenConfig.DisableEncryption.
+	HandshakeStageEncryption
+	// HandshakeStagePacksStarted is reached once the ResourcePacksInfo packet is received by a client or sent
+	// by a server, which starts the negotiation and downloading of resource packs.
+	HandshakeStagePacksStarted
+	// HandshakeStagePacksFinished is reached once the client reports that it has completed the resource pack
+	// sequence, after which the server sends the StartGame packet.
+	HandshakeStagePacksFinished
+	// HandshakeStageStartGame is reached once the StartGame packet is received by a client or sent by a
+	// server.
+	HandshakeStageStartGame
+	// HandshakeStageSpawned is reached once the player has spawned, which is when a client sends the
+	// SetLocalPlayerAsInitialised packet or when a server receives it. It is the final stage of the login
+	// sequence, reached before Conn.DoSpawn and Conn.StartGame return.
+	HandshakeStageSpawned
+)
+
+// String returns a human-readable name of the HandshakeStage, such as 'encryption'.
+func (stage HandshakeStage) String() string {
+	switch stage {
+	case HandshakeStageNetworkSettings:
+		return "network settings"
+	case HandshakeStageEncryption:
+		return "encryption"
+	case HandshakeStagePacksStarted:
+		return "packs started"
+	case HandshakeStagePacksFinished:
+		return "packs finished"
+	case HandshakeStageStartGame:
+		return "start game"
+	case HandshakeStageSpawned:
+		return "spawned"
+	}
+	return fmt.Sprintf("HandshakeStage(%d)", uint8(stage))
+}
+
+// reachStage calls the handshake function of the Conn with the HandshakeStage passed, if set and if the
+// stage was not reached before. If the Conn is being dialed with a DialTrace, the time is recorded.
+func (conn *Conn) reachStage(stage HandshakeStage) {
+	if conn.stageTimes != nil {
+		conn.stageTimes.reach(stage)
+	}
+	if conn.handshakeFunc == nil {
+		return
+	}
+	bit := uint32(1) << stage
+	for {
+		reached := conn.reachedStages.Load()
+		if reached&bit != 0 {
+			return
+		}
+		if conn.reachedStages.CompareAndSwap(reached, reached|bit) {
+			break
+		}
+	}
+	conn.handshakeFunc(conn, stage)
+}
@@ -0,0 +1,211 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// signHandshake creates a ServerToClientHandshake JWT signed with the key passed, holding the public key
+// passed in its x5u header.
+func signHandshake(t *testing.T, key *ecdsa.PrivateKey, x5u *ecdsa.PublicKey, salt string) []byte {
+	signer, err := jose.NewSigner(jose.SigningKey{Key: key, Algorithm: jose.ES384}, &jose.SignerOptions{
+		ExtraHeaders: map[jose.HeaderKey]any{"x5u": login.MarshalPublicKey(x5u)},
+	})
+	if err != nil {
+		t.Fatalf("create signer: %v", err)
+	}
+	s, err := jwt.Signed(signer).Claims(saltClaims{Salt: salt}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("sign handshake: %v", err)
+	}
+	return []byte(s)
+}
+
+// TestServerToClientHandshakeVerification tests that a client only enables encryption for a
+// ServerToClientHandshake of which the JWT is signed correctly, and that it fails with a HandshakeError
+// otherwise.
+func TestServerToClientHandshakeVerification(t *testing.T) {
+	serverKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+
+	valid := signHandshake(t, serverKey, &serverKey.PublicKey, salt)
+	parts := strings.Split(string(valid), ".")
+	tamperedClaims := base64.RawURLEncoding.EncodeToString([]byte(`{"salt":"` + base64.RawStdEncoding.EncodeToString([]byte("fedcba9876543210")) + `"}`))
+
+	tests := map[string]struct {
+		jwt       []byte
+		serverKey *ecdsa.PublicKey
+		valid     bool
+	}{
+		"Valid":            {jwt: valid, valid: true},
+		"ValidPinnedKey":   {jwt: valid, serverKey: &serverKey.PublicKey, valid: true},
+		"TamperedClaims":   {jwt: []byte(parts[0] + "." + tamperedClaims + "." + parts[2])},
+		"SignedByOtherKey": {jwt: signHandshake(t, otherKey, &serverKey.PublicKey, salt)},
+		"OtherPinnedKey":   {jwt: valid, serverKey: &otherKey.PublicKey},
+		"Malformed":        {jwt: []byte("not a jwt")},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, other := net.Pipe()
+			defer other.Close()
+			go func() {
+				_, _ = io.Copy(io.Discard, other)
+			}()
+			clientKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+			conn := newConn(c, clientKey, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+			defer conn.Close()
+			conn.serverKey = test.serverKey
+
+			err := conn.handleServerToClientHandshake(&packet.ServerToClientHandshake{JWT: test.jwt})
+			if test.valid {
+				if err != nil {
+					t.Fatalf("expected handshake to be accepted, got %v", err)
+				}
+				if !conn.encrypted {
+					t.Fatal("expected encryption to be enabled")
+				}
+				return
+			}
+			var handshakeErr HandshakeError
+			if !errors.As(err, &handshakeErr) {
+				t.Fatalf("expected HandshakeError, got %v", err)
+			}
+			if conn.encrypted {
+				t.Fatal("expected encryption not to be enabled")
+			}
+		})
+	}
+}
+
+// TestClientToServerHandshakeEncrypted tests that a client flushes packets buffered before the
+// ServerToClientHandshake unencrypted, and that it immediately responds with a ClientToServerHandshake as the
+// first encrypted packet.
+func TestClientToServerHandshakeEncrypted(t *testing.T) {
+	serverKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+
+	c, other := net.Pipe()
+	defer other.Close()
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	conn := newConn(c, clientKey, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	defer conn.Close()
+	conn.exposeKey = true
+
+	if err := conn.WritePacket(&packet.Text{Message: "before"}); err != nil {
+		t.Fatalf("write packet: %v", err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- conn.handleServerToClientHandshake(&packet.ServerToClientHandshake{JWT: signHandshake(t, serverKey, &serverKey.PublicKey, salt)})
+	}()
+
+	batch, err := packet.NewDecoder(other).Decode()
+	if err != nil {
+		t.Fatalf("decode unencrypted batch: %v", err)
+	}
+	if len(batch) != 1 || readPacketID(t, batch[0]) != packet.IDText {
+		t.Fatalf("expected unencrypted batch holding a Text packet, got %v packets", len(batch))
+	}
+
+	raw := make([]byte, 4096)
+	n, err := other.Read(raw)
+	if err != nil {
+		t.Fatalf("read encrypted batch: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("handle handshake: %v", err)
+	}
+	key, err := conn.UnsafeEncryptionKey()
+	if err != nil {
+		t.Fatalf("get encryption key: %v", err)
+	}
+	dec := packet.NewDecoder(bytes.NewReader(raw[:n]))
+	dec.EnableEncryption(key)
+	batch, err = dec.Decode()
+	if err != nil {
+		t.Fatalf("decode encrypted batch: %v", err)
+	}
+	if len(batch) != 1 || readPacketID(t, batch[0]) != packet.IDClientToServerHandshake {
+		t.Fatalf("expected encrypted batch holding a ClientToServerHandshake packet, got %v packets", len(batch))
+	}
+}
+
+// readPacketID reads the packet ID from the header of the packet data passed.
+func readPacketID(t *testing.T, data []byte) uint32 {
+	var h packet.Header
+	if err := h.Read(bytes.NewBuffer(data)); err != nil {
+		t.Fatalf("read packet header: %v", err)
+	}
+	return h.PacketID
+}
+
+// TestHandshakeFunc tests that the HandshakeFunc of both the Dialer and the ListenConfig is called with every
+// stage of the login sequence, in order and once each.
+func TestHandshakeFunc(t *testing.T) {
+	var mu sync.Mutex
+	var clientStages, serverStages []HandshakeStage
+	record := func(stages *[]HandshakeStage) func(*Conn, HandshakeStage) {
+		return func(_ *Conn, stage HandshakeStage) {
+			mu.Lock()
+			defer mu.Unlock()
+			*stages = append(*stages, stage)
+		}
+	}
+
+	l, err := ListenConfig{AuthenticationDisabled: true, HandshakeFunc: record(&serverStages)}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	accepted := make(chan error, 1)
+	go func() {
+		conn, err := AcceptEmptyWorld(ctx, l, GameData{})
+		if err == nil {
+			defer conn.Close()
+		}
+		accepted <- err
+	}()
+
+	conn, err := Dialer{HandshakeFunc: record(&clientStages)}.DialContext(ctx, "raknet", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.DoSpawnContext(ctx); err != nil {
+		t.Fatalf("error spawning: %v", err)
+	}
+	if err := <-accepted; err != nil {
+		t.Fatalf("error accepting: %v", err)
+	}
+
+	want := []HandshakeStage{HandshakeStageNetworkSettings, HandshakeStageEncryption, HandshakeStagePacksStarted, HandshakeStagePacksFinished, HandshakeStageStartGame, HandshakeStageSpawned}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(clientStages, want) {
+		t.Errorf("expected client stages %v, got %v", want, clientStages)
+	}
+	if !slices.Equal(serverStages, want) {
+		t.Errorf("expected server stages %v, got %v", want, serverStages)
+	}
+}
@@ -0,0 +1,154 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// interactionState holds the hot bar slot and item held by the player of a Conn obtained using a Dialer and
+// the position of the player, as last sent by the server. They are used to fill out the inventory
+// transactions sent to interact with the world.
+type interactionState struct {
+	mu     sync.Mutex
+	slot   int32
+	held   protocol.ItemInstance
+	pos    mgl32.Vec3
+	hasPos bool
+}
+
+// InteractWith interacts with the entity with the runtime ID passed, like a player clicking the entity while
+// holding its current item. The action is either protocol.UseItemOnEntityActionInteract, which for example
+// makes the player mount a horse or trade with a villager, or protocol.UseItemOnEntityActionAttack. The held
+// item and position sent are those last sent by the server in MobEquipment, MovePlayer and Respawn packets
+// read using Conn.ReadPacket. An error is returned if the Conn was obtained from a Listener or if the action
+// is unknown.
+func (conn *Conn) InteractWith(entityRuntimeID uint64, action uint32) error {
+	if err := conn.dialerOnly("interact"); err != nil {
+		return err
+	}
+	if action != protocol.UseItemOnEntityActionInteract && action != protocol.UseItemOnEntityActionAttack {
+		return conn.wrap(fmt.Errorf("unknown use item on entity action %v", action), "interact")
+	}
+	slot, held, pos := conn.interactionData()
+	return conn.WritePacket(&packet.InventoryTransaction{TransactionData: &protocol.UseItemOnEntityTransactionData{
+		TargetEntityRuntimeID: entityRuntimeID,
+		ActionType:            action,
+		HotBarSlot:            slot,
+		HeldItem:              held,
+		Position:              pos,
+	}})
+}
+
+// AttackEntity attacks the entity with the runtime ID passed using the item currently held. It is a shorthand
+// for InteractWith with protocol.UseItemOnEntityActionAttack.
+func (conn *Conn) AttackEntity(entityRuntimeID uint64) error {
+	return conn.InteractWith(entityRuntimeID, protocol.UseItemOnEntityActionAttack)
+}
+
+// Animate plays the animation passed on the player of the Conn for all players viewing it, such as swinging
+// its arm. The action is one of packet.AnimateActionSwingArm, packet.AnimateActionStopSleep,
+// packet.AnimateActionCriticalHit or packet.AnimateActionMagicCriticalHit. Rowing animations, which carry
+// extra data, are played using AnimateRowing. An error is returned if the action is unknown.
+func (conn *Conn) Animate(action int32) error {
+	switch action {
+	case packet.AnimateActionSwingArm, packet.AnimateActionStopSleep, packet.AnimateActionCriticalHit, packet.AnimateActionMagicCriticalHit:
+	default:
+		return conn.wrap(fmt.Errorf("unknown animate action %v", action), "animate")
+	}
+	return conn.WritePacket(&packet.Animate{ActionType: action, EntityRuntimeID: conn.gameData.EntityRuntimeID})
+}
+
+// AnimateRowing plays the animation of the right or left paddle of the boat that the player of the Conn is
+// riding. The rowing time is the time that the paddle has been rowing for, which determines its position in
+// the animation.
+func (conn *Conn) AnimateRowing(right bool, rowingTime float32) error {
+	action := int32(packet.AnimateActionRowLeft)
+	if right {
+		action = packet.AnimateActionRowRight
+	}
+	return conn.WritePacket(&packet.Animate{ActionType: action, EntityRuntimeID: conn.gameData.EntityRuntimeID, BoatRowingTime: rowingTime})
+}
+
+// UseItemOn uses the item currently held on the face of the block at the position passed, like a player
+// right-clicking the block. Depending on the block and item, this for example opens a container, places a
+// block or tills dirt. The face is one of the faces of a block: 0 (down), 1 (up), 2 (north), 3 (south),
+// 4 (west) or 5 (east). The centre of the face is sent as the position clicked. An error is returned if the
+// Conn was obtained from a Listener or if the face is invalid.
+func (conn *Conn) UseItemOn(pos protocol.BlockPos, face int32) error {
+	if err := conn.dialerOnly("use item"); err != nil {
+		return err
+	}
+	if face < 0 || face > 5 {
+		return conn.wrap(fmt.Errorf("block face must be between 0 and 5, got %v", face), "use item")
+	}
+	clicked := mgl32.Vec3{0.5, 0.5, 0.5}
+	switch face {
+	case 0:
+		clicked[1] = 0
+	case 1:
+		clicked[1] = 1
+	case 2:
+		clicked[2] = 0
+	case 3:
+		clicked[2] = 1
+	case 4:
+		clicked[0] = 0
+	case 5:
+		clicked[0] = 1
+	}
+	slot, held, playerPos := conn.interactionData()
+	return conn.WritePacket(&packet.InventoryTransaction{TransactionData: &protocol.UseItemTransactionData{
+		ActionType:      protocol.UseItemActionClickBlock,
+		BlockPosition:   pos,
+		BlockFace:       face,
+		HotBarSlot:      slot,
+		HeldItem:        held,
+		Position:        playerPos,
+		ClickedPosition: clicked,
+	}})
+}
+
+// interactionData returns the hot bar slot, held item and position of the player to send in an inventory
+// transaction. The stack network ID of the held item is only sent if the server uses the server
+// authoritative inventory, as it is not known to the server otherwise.
+func (conn *Conn) interactionData() (slot int32, held protocol.ItemInstance, pos mgl32.Vec3) {
+	s := &conn.interaction
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot, held, pos = s.slot, s.held, s.pos
+	if !s.hasPos {
+		pos = conn.gameData.PlayerPosition
+	}
+	if !conn.gameData.ServerAuthoritativeInventory {
+		held.StackNetworkID = 0
+	}
+	return slot, held, pos
+}
+
+// handleMobEquipment updates the held item of the player if the MobEquipment packet passed concerns the
+// player's own inventory.
+func (s *interactionState) handleMobEquipment(pk *packet.MobEquipment, entityRuntimeID uint64) {
+	if pk.EntityRuntimeID != entityRuntimeID || pk.WindowID != protocol.WindowIDInventory {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slot, s.held = int32(pk.HotBarSlot), pk.NewItem
+}
+
+// handleMovePlayer updates the position of the player if the MovePlayer packet passed moves the player.
+func (s *interactionState) handleMovePlayer(pk *packet.MovePlayer, entityRuntimeID uint64) {
+	if pk.EntityRuntimeID == entityRuntimeID {
+		s.setPosition(pk.Position)
+	}
+}
+
+// setPosition sets the position of the player to the position passed.
+func (s *interactionState) setPosition(pos mgl32.Vec3) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pos, s.hasPos = pos, true
+}
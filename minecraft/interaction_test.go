@@ -0,0 +1,78 @@
+package minecraft_test
+
+import (
+	"context"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+	"time"
+)
+
+// TestAttackEntity tests that a client attacking an entity using Conn.AttackEntity sends an inventory
+// transaction that a server can respond to by hurting the entity.
+func TestAttackEntity(t *testing.T) {
+	const target = 7
+
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		conn, err := minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{EntityRuntimeID: 1})
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+		for {
+			pk, err := conn.ReadPacket()
+			if err != nil {
+				errs <- err
+				return
+			}
+			tr, ok := pk.(*packet.InventoryTransaction)
+			if !ok {
+				continue
+			}
+			data, ok := tr.TransactionData.(*protocol.UseItemOnEntityTransactionData)
+			if !ok || data.ActionType != protocol.UseItemOnEntityActionAttack || data.TargetEntityRuntimeID != target {
+				continue
+			}
+			errs <- conn.WritePacket(&packet.ActorEvent{EntityRuntimeID: target, EventType: packet.ActorEventHurt})
+			_ = conn.Flush()
+			return
+		}
+	}()
+
+	conn, err := minecraft.Dialer{}.DialContext(ctx, "raknet", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.DoSpawnContext(ctx); err != nil {
+		t.Fatalf("error spawning: %v", err)
+	}
+	if err := conn.AttackEntity(target); err != nil {
+		t.Fatalf("error attacking entity: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("error handling attack: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	for {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading packet: %v", err)
+		}
+		if event, ok := pk.(*packet.ActorEvent); ok && event.EntityRuntimeID == target && event.EventType == packet.ActorEventHurt {
+			return
+		}
+	}
+}
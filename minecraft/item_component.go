@@ -0,0 +1,112 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// CustomItem is a custom item registered by the server. Its components are sent in an ItemComponent packet,
+// while its runtime ID is sent in the item table of the StartGame packet.
+type CustomItem struct {
+	// Name is the name of the item, such as 'example:sword'.
+	Name string
+	// RuntimeID is the runtime ID of the item as found in the item table in GameData.Items. It is 0 if the
+	// item was not present in the item table.
+	RuntimeID int16
+	// ComponentBased specifies if the item was marked as component based in the item table.
+	ComponentBased bool
+	// Data holds the full NBT data sent for the item in the ItemComponent packet.
+	Data ItemComponents
+}
+
+// Components returns the components of the item, which are held in the 'components' tag of its data. Each
+// component is keyed by its name, such as 'minecraft:icon'.
+func (item CustomItem) Components() ItemComponents {
+	c, _ := item.Data.Compound("components")
+	return c
+}
+
+// Properties returns the properties of the item, which are held in the 'item_properties' component.
+func (item CustomItem) Properties() ItemComponents {
+	c, _ := item.Components().Compound("item_properties")
+	return c
+}
+
+// ItemComponents is an NBT compound holding (part of) the components of a CustomItem. It provides methods to
+// navigate the compound.
+type ItemComponents map[string]any
+
+// Lookup looks up the value found by following the path of compound keys passed. For example,
+// Lookup("minecraft:icon", "texture") returns the texture tag of the 'minecraft:icon' compound. False is
+// returned if any of the keys in the path was not found or if a non-final key does not point to a compound.
+func (c ItemComponents) Lookup(path ...string) (any, bool) {
+	var v any = map[string]any(c)
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// Compound looks up the compound found by following the path of keys passed, as described in Lookup. False is
+// returned if the value found is not a compound.
+func (c ItemComponents) Compound(path ...string) (ItemComponents, bool) {
+	v, ok := c.Lookup(path...)
+	if !ok {
+		return nil, false
+	}
+	m, ok := v.(map[string]any)
+	return m, ok
+}
+
+// String looks up the string found by following the path of keys passed, as described in Lookup. False is
+// returned if the value found is not a string.
+func (c ItemComponents) String(path ...string) (string, bool) {
+	v, _ := c.Lookup(path...)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// itemComponentState holds the custom items registered by the server through ItemComponent packets.
+type itemComponentState struct {
+	mu    sync.Mutex
+	items map[string]CustomItem
+}
+
+// CustomItems returns all custom items that the server registered through an ItemComponent packet, keyed by
+// their name. Their runtime IDs are looked up in the item table found in GameData.
+func (conn *Conn) CustomItems() map[string]CustomItem {
+	conn.itemComponents.mu.Lock()
+	defer conn.itemComponents.mu.Unlock()
+	items := make(map[string]CustomItem, len(conn.itemComponents.items))
+	for name, item := range conn.itemComponents.items {
+		items[name] = item
+	}
+	return items
+}
+
+// handleItemComponent stores the custom items found in the ItemComponent packet passed.
+func (conn *Conn) handleItemComponent(pk *packet.ItemComponent) {
+	runtimeIDs := make(map[string]int, len(conn.gameData.Items))
+	for i, entry := range conn.gameData.Items {
+		runtimeIDs[entry.Name] = i
+	}
+
+	conn.itemComponents.mu.Lock()
+	defer conn.itemComponents.mu.Unlock()
+	if conn.itemComponents.items == nil {
+		conn.itemComponents.items = make(map[string]CustomItem, len(pk.Items))
+	}
+	for _, entry := range pk.Items {
+		item := CustomItem{Name: entry.Name, Data: entry.Data}
+		if i, ok := runtimeIDs[entry.Name]; ok {
+			item.RuntimeID, item.ComponentBased = conn.gameData.Items[i].RuntimeID, conn.gameData.Items[i].ComponentBased
+		}
+		conn.itemComponents.items[entry.Name] = item
+	}
+}
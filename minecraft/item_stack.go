@@ -0,0 +1,98 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// itemStackRequests keeps track of item stack requests sent by a Conn that have not yet received a response
+// from the server. The zero value is ready to use.
+type itemStackRequests struct {
+	mu sync.Mutex
+	// id is the ID of the last request sent. The vanilla client uses negative, odd request IDs that decrease
+	// with every request, so the same is done here.
+	id      int32
+	pending map[int32]chan protocol.ItemStackResponse
+	// closed is true once the Conn is closed, after which no responses will arrive anymore.
+	closed bool
+}
+
+// next assigns a new request ID and returns it together with a channel that receives the response to the
+// request with that ID. If the Conn is already closed, the channel returned is closed.
+func (r *itemStackRequests) next() (int32, chan protocol.ItemStackResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := make(chan protocol.ItemStackResponse, 1)
+	if r.closed {
+		close(c)
+		return 0, c
+	}
+	if r.pending == nil {
+		r.pending = make(map[int32]chan protocol.ItemStackResponse)
+	}
+	if r.id == 0 {
+		r.id = 1
+	}
+	r.id -= 2
+	r.pending[r.id] = c
+	return r.id, c
+}
+
+// resolve passes each of the responses passed to the channel of the request that it responds to. Responses
+// to requests not sent through RequestItemStack are ignored.
+func (r *itemStackRequests) resolve(responses []protocol.ItemStackResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, resp := range responses {
+		if c, ok := r.pending[resp.RequestID]; ok {
+			delete(r.pending, resp.RequestID)
+			c <- resp
+		}
+	}
+}
+
+// cancel stops tracking the request with the ID passed, for example if it could not be sent.
+func (r *itemStackRequests) cancel(id int32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+}
+
+// close closes the channels of all requests that have not yet received a response, as none will arrive after
+// the Conn is closed.
+func (r *itemStackRequests) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for id, c := range r.pending {
+		close(c)
+		delete(r.pending, id)
+	}
+}
+
+// RequestItemStack sends an ItemStackRequest packet holding a single request with the actions passed. The
+// request is assigned a unique request ID automatically. The channel returned receives the
+// protocol.ItemStackResponse that the server sends in response to the request, which may be checked for a
+// Status of protocol.ItemStackResponseStatusOK to find out if the request was accepted. If the Conn is closed
+// before the response arrives, the channel is closed without receiving a value, so that a receive on it
+// returns ok as false.
+// Responses are only matched with their requests when the ItemStackResponse packet holding them is read
+// using Conn.ReadPacket, so ReadPacket must be called continuously for the channel to ever receive a value.
+func (conn *Conn) RequestItemStack(actions []protocol.StackRequestAction, filterStrings []string, filterCause int32) (<-chan protocol.ItemStackResponse, error) {
+	id, c := conn.stackRequests.next()
+	err := conn.WritePacket(&packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{
+		RequestID:     id,
+		Actions:       actions,
+		FilterStrings: filterStrings,
+		FilterCause:   filterCause,
+	}}})
+	if err != nil {
+		conn.stackRequests.cancel(id)
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,79 @@
+package minecraft
+
+import (
+	"bytes"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRequestItemStack tests that the response to an item stack request is passed to the channel returned by
+// RequestItemStack, and that the channel of a request without response is closed when the Conn is closed.
+func TestRequestItemStack(t *testing.T) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, time.Millisecond, 0, 0, false)
+	defer other.Close()
+
+	ids := make(chan int32, 2)
+	go func() {
+		dec := packet.NewDecoder(other)
+		for {
+			batch, err := dec.Decode()
+			if err != nil {
+				return
+			}
+			for _, data := range batch {
+				buf := bytes.NewBuffer(data)
+				var header packet.Header
+				if err := header.Read(buf); err != nil || header.PacketID != packet.IDItemStackRequest {
+					continue
+				}
+				var pk packet.ItemStackRequest
+				pk.Marshal(DefaultProtocol.NewReader(buf, 0, false))
+				ids <- pk.Requests[0].RequestID
+			}
+		}
+	}()
+
+	resolved, err := conn.RequestItemStack(nil, nil, 0)
+	if err != nil {
+		t.Fatalf("error requesting item stack: %v", err)
+	}
+	ignored, err := conn.RequestItemStack(nil, nil, 0)
+	if err != nil {
+		t.Fatalf("error requesting item stack: %v", err)
+	}
+	id := <-ids
+	if other := <-ids; other == id {
+		t.Fatalf("expected unique request IDs, got %v twice", id)
+	}
+	conn.observePacket(&packet.ItemStackResponse{Responses: []protocol.ItemStackResponse{{
+		Status:    protocol.ItemStackResponseStatusOK,
+		RequestID: id,
+	}}})
+	select {
+	case resp := <-resolved:
+		if resp.RequestID != id || resp.Status != protocol.ItemStackResponseStatusOK {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected response to be passed to the channel")
+	}
+
+	_ = conn.Close()
+	select {
+	case _, ok := <-ignored:
+		if ok {
+			t.Fatalf("expected no response for the ignored request")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected channel of ignored request to be closed when the Conn is closed")
+	}
+	if len(conn.stackRequests.pending) != 0 {
+		t.Fatalf("expected no pending requests after closing, got %v", len(conn.stackRequests.pending))
+	}
+}
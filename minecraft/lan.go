@@ -0,0 +1,223 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// LANPort is the port that Minecraft clients broadcast pings to over IPv4 to discover games on the local
+// network.
+const LANPort = 19132
+
+// lanPingInterval is the interval at which DiscoverLAN broadcasts pings. Pings are sent more than once
+// because they, or the pongs sent in response, may be lost.
+const lanPingInterval = time.Second
+
+const (
+	idUnconnectedPing byte = 0x01
+	idUnconnectedPong byte = 0x1c
+)
+
+// unconnectedMagic is the magic sequence that RakNet includes in every unconnected ping and pong.
+var unconnectedMagic = [16]byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+// LANServer is a server on the local network found using DiscoverLAN.
+type LANServer struct {
+	// Address is the address of the server that may be dialed over "raknet". It is made up of the IP
+	// address that the pong was sent from and the IPv4 port found in the pong, if any.
+	Address string
+	// Pong is the pong that the server responded with.
+	Pong Pong
+}
+
+// DiscoverLAN discovers servers on the local network, like a client does when opening the Friends tab. It
+// broadcasts pings to LANPort on every IPv4 network that the system is connected to until the context passed
+// is done, after which all servers that responded are returned in the order in which they first responded.
+// A context with a timeout of a few seconds should therefore be passed. An error is only returned if no
+// socket could be opened to send the pings.
+func DiscoverLAN(ctx context.Context) ([]LANServer, error) {
+	return discoverLAN(ctx, broadcastAddrs(LANPort))
+}
+
+// discoverLAN sends pings to the addresses passed until the context passed is done and returns the servers
+// that responded.
+func discoverLAN(ctx context.Context, addrs []*net.UDPAddr) ([]LANServer, error) {
+	pc, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("discover lan: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = pc.Close()
+	}()
+
+	var guid [8]byte
+	_, _ = rand.Read(guid[:])
+	ping := new(bytes.Buffer)
+	ping.WriteByte(idUnconnectedPing)
+	_ = binary.Write(ping, binary.BigEndian, time.Now().UnixMilli())
+	ping.Write(unconnectedMagic[:])
+	ping.Write(guid[:])
+	go func() {
+		ticker := time.NewTicker(lanPingInterval)
+		defer ticker.Stop()
+		for {
+			for _, addr := range addrs {
+				// Some networks may not allow broadcasting, which we simply ignore.
+				_, _ = pc.WriteToUDP(ping.Bytes(), addr)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var servers []LANServer
+	found := make(map[string]struct{})
+	b := make([]byte, 1500)
+	for {
+		n, addr, err := pc.ReadFromUDP(b)
+		if err != nil {
+			if ctx.Err() != nil {
+				return servers, nil
+			}
+			return servers, fmt.Errorf("discover lan: %w", err)
+		}
+		data, ok := parseUnconnectedPong(b[:n])
+		if !ok {
+			continue
+		}
+		pong, err := ParsePong(data)
+		if err != nil {
+			continue
+		}
+		port := addr.Port
+		if pong.PortV4 != 0 {
+			port = int(pong.PortV4)
+		}
+		address := net.JoinHostPort(addr.IP.String(), strconv.Itoa(port))
+		if _, ok := found[address]; ok {
+			continue
+		}
+		found[address] = struct{}{}
+		servers = append(servers, LANServer{Address: address, Pong: pong})
+	}
+}
+
+// AdvertiseLAN makes the Listener discoverable by clients on the local network if it listens on a port other
+// than LANPort. It listens on LANPort and responds to the pings that clients broadcast to it with the pong of
+// the Listener, which holds the port that the Listener is actually listening on. AdvertiseLAN blocks until the
+// context passed is done or the Listener is closed. A Listener that listens on LANPort on all interfaces
+// already responds to such pings itself and does not need to be advertised. An error is returned if
+// LANPort could not be listened on, for example because another process is already listening on it.
+func (listener *Listener) AdvertiseLAN(ctx context.Context) error {
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{Port: LANPort})
+	if err != nil {
+		return fmt.Errorf("advertise lan: %w", err)
+	}
+	return listener.serveLAN(ctx, pc)
+}
+
+// serveLAN responds to unconnected pings read from the net.UDPConn passed with the pong of the Listener until
+// the context passed is done or the Listener is closed. The net.UDPConn is closed when serveLAN returns.
+func (listener *Listener) serveLAN(ctx context.Context, pc *net.UDPConn) error {
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-listener.close:
+		}
+		_ = pc.Close()
+	}()
+
+	b := make([]byte, 1500)
+	for {
+		n, addr, err := pc.ReadFromUDP(b)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("advertise lan: %w", err)
+		}
+		timestamp, ok := parseUnconnectedPing(b[:n])
+		if !ok {
+			continue
+		}
+		data := listener.pong().Bytes()
+		pong := new(bytes.Buffer)
+		pong.WriteByte(idUnconnectedPong)
+		_ = binary.Write(pong, binary.BigEndian, timestamp)
+		_ = binary.Write(pong, binary.BigEndian, listener.listener.ID())
+		pong.Write(unconnectedMagic[:])
+		_ = binary.Write(pong, binary.BigEndian, int16(len(data)))
+		pong.Write(data)
+		_, _ = pc.WriteToUDP(pong.Bytes(), addr)
+	}
+}
+
+// parseUnconnectedPing parses the unconnected ping held in the data passed and returns its timestamp. False
+// is returned if the data is not an unconnected ping.
+func parseUnconnectedPing(b []byte) (timestamp int64, ok bool) {
+	if len(b) < 33 || b[0] != idUnconnectedPing || !bytes.Equal(b[9:25], unconnectedMagic[:]) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(b[1:9])), true
+}
+
+// parseUnconnectedPong parses the unconnected pong held in the data passed and returns the pong data that it
+// holds. False is returned if the data is not an unconnected pong.
+func parseUnconnectedPong(b []byte) ([]byte, bool) {
+	if len(b) < 35 || b[0] != idUnconnectedPong || !bytes.Equal(b[17:33], unconnectedMagic[:]) {
+		return nil, false
+	}
+	n := int(binary.BigEndian.Uint16(b[33:35]))
+	if len(b) < 35+n {
+		return nil, false
+	}
+	return b[35 : 35+n], true
+}
+
+// broadcastAddrs returns the addresses with the port passed to broadcast to in order to reach all IPv4
+// networks that the system is connected to. Besides the limited broadcast address, which is generally only
+// sent out on the interface of the default route, the directed broadcast address of every interface is
+// included.
+func broadcastAddrs(port int) []*net.UDPAddr {
+	addrs := []*net.UDPAddr{{IP: net.IPv4bcast, Port: port}}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return addrs
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range ifaceAddrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			if ip == nil || len(ipNet.Mask) != net.IPv4len {
+				continue
+			}
+			bcast := make(net.IP, net.IPv4len)
+			for i := range ip {
+				bcast[i] = ip[i] | ^ipNet.Mask[i]
+			}
+			addrs = append(addrs, &net.UDPAddr{IP: bcast, Port: port})
+		}
+	}
+	return addrs
+}
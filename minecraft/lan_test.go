@@ -0,0 +1,41 @@
+package minecraft
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestDiscoverLAN tests that a Listener advertised on the LAN is discovered with the address that it is
+// listening on, rather than the address of the socket that responded to the ping.
+func TestDiscoverLAN(t *testing.T) {
+	l, err := ListenConfig{AuthenticationDisabled: true, StatusProvider: NewStatusProvider("LAN World")}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("error listening for pings: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	go func() {
+		_ = l.serveLAN(ctx, pc)
+	}()
+
+	servers, err := discoverLAN(ctx, []*net.UDPAddr{pc.LocalAddr().(*net.UDPAddr)})
+	if err != nil {
+		t.Fatalf("error discovering: %v", err)
+	}
+	if len(servers) != 1 {
+		t.Fatalf("expected 1 server, got %v", len(servers))
+	}
+	want := net.JoinHostPort("127.0.0.1", strconv.Itoa(l.Addr().(*net.UDPAddr).Port))
+	if servers[0].Address != want || servers[0].Pong.MOTD != "LAN World" {
+		t.Fatalf("expected server %v with MOTD %q, got %+v", want, "LAN World", servers[0])
+	}
+}
@@ -0,0 +1,82 @@
+package minecraft
+
+import (
+	"context"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+	"time"
+)
+
+// latencyState holds the NetworkStackLatency requests sent using Conn.MeasureLatency that have not yet been
+// answered by the other end of the connection.
+type latencyState struct {
+	mu      sync.Mutex
+	counter int64
+	pending map[int64]chan struct{}
+}
+
+// MeasureLatency measures the latency over the entire Minecraft stack, rather than only the RakNet latency
+// returned by Conn.Latency. It sends a NetworkStackLatency packet and returns the time it took for the other
+// end of the connection to send it back. An error is returned if no response arrived within 10 seconds.
+// Packets must be read concurrently using Conn.ReadPacket while MeasureLatency waits for the response.
+// MeasureLatency is generally used on a Conn obtained from a Listener, as vanilla servers do not respond to
+// NetworkStackLatency packets sent by a client.
+func (conn *Conn) MeasureLatency() (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	return conn.MeasureLatencyContext(ctx)
+}
+
+// MeasureLatencyContext measures the latency over the entire Minecraft stack like MeasureLatency, using a
+// specific context for cancellation.
+func (conn *Conn) MeasureLatencyContext(ctx context.Context) (time.Duration, error) {
+	conn.latency.mu.Lock()
+	if conn.latency.pending == nil {
+		conn.latency.pending = make(map[int64]chan struct{})
+	}
+	conn.latency.counter++
+	// Some versions of the client scale the timestamp by a factor of 1000 in their response. We only use
+	// multiples of 1000, so that the response can be correlated either way.
+	timestamp, c := conn.latency.counter*1000, make(chan struct{})
+	conn.latency.pending[timestamp] = c
+	conn.latency.mu.Unlock()
+
+	defer func() {
+		conn.latency.mu.Lock()
+		delete(conn.latency.pending, timestamp)
+		conn.latency.mu.Unlock()
+	}()
+
+	start := time.Now()
+	if err := conn.WritePacket(&packet.NetworkStackLatency{Timestamp: timestamp, NeedsResponse: true}); err != nil {
+		return 0, err
+	}
+	if err := conn.Flush(); err != nil {
+		return 0, err
+	}
+	select {
+	case <-conn.close:
+		return 0, conn.closeErr("measure latency")
+	case <-ctx.Done():
+		return 0, conn.wrap(ctx.Err(), "measure latency")
+	case <-c:
+		return time.Since(start), nil
+	}
+}
+
+// handleNetworkStackLatency resolves the pending MeasureLatency call that the NetworkStackLatency packet passed
+// is a response to, if any.
+func (conn *Conn) handleNetworkStackLatency(pk *packet.NetworkStackLatency) {
+	if pk.NeedsResponse {
+		return
+	}
+	conn.latency.mu.Lock()
+	defer conn.latency.mu.Unlock()
+	for _, timestamp := range []int64{pk.Timestamp, pk.Timestamp / 1000, pk.Timestamp * 1000} {
+		if c, ok := conn.latency.pending[timestamp]; ok {
+			close(c)
+			delete(conn.latency.pending, timestamp)
+			return
+		}
+	}
+}
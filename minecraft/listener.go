@@ -7,20 +7,28 @@ import (
 	"fmt"
 	"github.com/sandertv/go-raknet"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"io"
 	"log"
+	"log/slog"
 	"net"
-	"os"
 	"sync/atomic"
 	"time"
 )
 
 // ListenConfig holds settings that may be edited to change behaviour of a Listener.
 type ListenConfig struct {
-	// ErrorLog is a log.Logger that errors that occur during packet handling of clients are written to. By
-	// default, ErrorLog is set to one equal to the global logger.
+	// ErrorLog is a log.Logger that errors that occur during packet handling of clients are written to. If
+	// Logger is nil and ErrorLog is set, errors are logged to the writer of ErrorLog using a slog.TextHandler.
+	// Logger should be used instead where possible.
 	ErrorLog *log.Logger
+	// Logger is the slog.Logger that errors and warnings that occur during packet handling of clients are
+	// logged to. Log records carry the remote address of the connection and, if applicable, the ID of the
+	// packet that caused the error. By default, Logger is set to a Logger that writes to ErrorLog if set, or
+	// slog.Default() otherwise.
+	Logger *slog.Logger
 
 	// AuthenticationDisabled specifies if authentication of players that join is disabled. If set to true, no
 	// verification will be done to ensure that the player connecting is authenticated using their XBOX Live
@@ -32,11 +40,30 @@ type ListenConfig struct {
 	// will be dynamically updated each time a player joins, so that an unlimited amount of players is
 	// accepted into the server.
 	MaximumPlayers int
+	// MaximumHandshakes is the maximum amount of connections that may be going through the login sequence
+	// at the same time. Connections made while this many connections are logging in are rejected with a
+	// 'server full' message before any of their login sequence is handled, which limits the resources spent
+	// during a connection flood. If zero, the amount is unlimited.
+	MaximumHandshakes int
+	// MaximumConnections is the maximum amount of connections that may have completed the login sequence at
+	// the same time, regardless of whether they were accepted using Listener.Accept yet. Connections that
+	// complete the login sequence while this many connections are established are rejected with a 'server
+	// full' message. If zero, the amount is unlimited.
+	MaximumConnections int
+	// LoginTimeout is the maximum duration that a connection may take to complete the login sequence, including
+	// the download of resource packs. Connections that have not completed it when the timeout expires are
+	// closed with a LoginTimeoutError, so that clients that connect but never finish logging in do not pile
+	// up. If zero, connections may take any amount of time to log in.
+	LoginTimeout time.Duration
 
 	// AllowUnknownPackets specifies if connections of this Listener are allowed to send packets not present
 	// in the packet pool. If false (by default), such packets lead to the connection being closed immediately.
 	// If set to true, the packets will be returned as a packet.Unknown.
 	AllowUnknownPackets bool
+	// IgnoreUnknownPackets specifies if packets received that are not present in the packet pool should be
+	// dropped rather than being returned as a packet.Unknown. The raw payload of these packets is still
+	// passed to the PacketFunc if set. IgnoreUnknownPackets has no effect unless AllowUnknownPackets is set.
+	IgnoreUnknownPackets bool
 
 	// AllowInvalidPackets specifies if invalid packets (either too few bytes or too many bytes) should be
 	// allowed. If false (by default), such packets lead to the connection being closed immediately. If true,
@@ -46,6 +73,11 @@ type ListenConfig struct {
 	// StatusProvider is the ServerStatusProvider of the Listener. When set to nil, the default provider,
 	// ListenerStatusProvider, is used as provider.
 	StatusProvider ServerStatusProvider
+	// ServerID is the unique ID of the server that is sent to clients in pongs and during the RakNet connection
+	// sequence, which clients use to tell servers apart in the server list. Setting it keeps the ID the same
+	// when the server is restarted. If ServerID is 0, the network picks a random ID. ServerID is ignored for
+	// networks other than "raknet" that do not support setting the ID.
+	ServerID int64
 
 	// AcceptedProtocols is a slice of Protocol accepted by a Listener created with this ListenConfig. The current
 	// Protocol is always added to this slice. Clients with a protocol version that is not present in this slice will
@@ -62,6 +94,35 @@ type ListenConfig struct {
 	// calls to `(*Conn).Write()` or `(*Conn).WritePacket()` to send the packets over network.
 	FlushRate time.Duration
 
+	// ReadBufferSize is the size in bytes of the buffer that batches of packets are read into. It is only used
+	// for network implementations that do not read packets directly, and must be large enough to hold the
+	// largest batch received. If set to 0, packet.DefaultReadBufferSize is used. Values smaller than
+	// packet.MinReadBufferSize are raised to packet.MinReadBufferSize.
+	ReadBufferSize int
+	// WriteBufferSize is the initial size in bytes of the buffer that batches of packets are written to
+	// before being compressed. If set to 0, buffers are taken from a pool shared by all connections. Values
+	// smaller than packet.MinWriteBufferSize are raised to packet.MinWriteBufferSize.
+	WriteBufferSize int
+
+	// SendQueueSize is the maximum amount of packets that may be queued for sending on the Conn before being
+	// flushed. If set to 0, the amount of queued packets is unbounded. If the queue is full, writing a packet
+	// either blocks until the queue is flushed or fails, depending on SendQueuePolicy. Note that with a
+	// negative FlushRate, blocking writes only return after an explicit call to Conn.Flush.
+	SendQueueSize int
+	// SendQueuePolicy specifies the behaviour of writes to the Conn when its send queue is full. By default,
+	// writes block until the queue is flushed.
+	SendQueuePolicy SendQueuePolicy
+	// ReadQueueSize is the maximum amount of packets that the Conn holds after receiving them, until they are
+	// read using Conn.ReadPacket or Conn.Read. If set to 0, the amount of held packets is unbounded, so that
+	// bursts of packets, such as the chunks sent when joining, never slow down reading from the connection,
+	// at the cost of memory growing for as long as packets are received faster than they are read. If the
+	// queue is full, received packets are either held back or dropped, depending on ReadQueuePolicy. Each
+	// packet held keeps its encoded payload in memory, which may be up to the maximum packet size.
+	ReadQueueSize int
+	// ReadQueuePolicy specifies the behaviour of the Conn when a packet is received while its read queue is
+	// full. By default, reading from the underlying connection blocks until the queue has space again.
+	ReadQueuePolicy ReadQueuePolicy
+
 	// ResourcePacks is a slice of resource packs that the listener may hold. Each client will be asked to
 	// download these resource packs upon joining.
 	// This field should not be edited during runtime of the Listener to avoid race conditions. Use
@@ -74,11 +135,71 @@ type ListenConfig struct {
 	// be able to join the server. If they don't accept, they can only leave the server.
 	TexturePacksRequired bool
 
+	// RawHandshake, if set to true, limits the handling of the login sequence by connections of the Listener
+	// to the bare minimum required to set up the connection: The RequestNetworkSettings packet is handled to
+	// enable compression, and the Login and ClientToServerHandshake packets are handled to parse the login
+	// request and to enable encryption. The connection is returned by Accept directly after this, without
+	// a PlayStatus or any resource pack packets having been sent, so that the remainder of the login sequence
+	// may be driven by the caller, for example by forwarding packets from a Conn dialed with
+	// Dialer.RawHandshake set.
+	// Conn.StartGame may not be used on a Conn obtained with RawHandshake set.
+	RawHandshake bool
+
+	// DisableEncryption, if set to true, makes connections of the Listener skip the ServerToClientHandshake
+	// packet, so that encryption is never enabled and all packets are sent in cleartext. Clients that require
+	// encryption, such as a Dialer without Dialer.DisableEncryption set, refuse to connect. DisableEncryption
+	// should only be used for trusted links, such as on localhost or in a LAN for testing.
+	DisableEncryption bool
+	// ExposeEncryptionKey, if set to true, keeps the AES key negotiated with each connection of the Listener,
+	// so that it may be obtained using Conn.UnsafeEncryptionKey, for example to decrypt captured traffic in
+	// external tools. Anyone holding the key is able to read and forge all packets sent over the connection,
+	// so it should only be set for debugging.
+	ExposeEncryptionKey bool
+
 	// PacketFunc is called whenever a packet is read from or written to a connection returned when using
 	// Listener.Accept. It includes packets that are otherwise covered in the connection sequence, such as the
 	// Login packet. The function is called with the header of the packet and its raw payload, the address
 	// from which the packet originated, and the destination address.
 	PacketFunc func(header packet.Header, payload []byte, src, dst net.Addr)
+	// PacketSizeFunc is called for every packet read from or written to a connection returned when using
+	// Listener.Accept, like PacketFunc, but with the size of the packet rather than its payload. size is the
+	// length of the packet including its header before compression. wireSize is the share of the packet in
+	// the size of the compressed and encrypted batch that it was sent in, which is divided over the packets
+	// in the batch by their size, so that the wire sizes of all packets in a batch add up to the size of the
+	// batch. PacketSizeFunc may be used to measure bandwidth per packet type.
+	PacketSizeFunc func(header packet.Header, size, wireSize int, src, dst net.Addr)
+	// HandshakeFunc is called with a connection of the Listener whenever it reaches a new HandshakeStage of
+	// the login sequence, which may be used to find out where a connection that does not finish logging in is
+	// stuck. Stages are reached in order and at most once. HandshakeFunc is called on the goroutine that
+	// handles incoming packets, except for HandshakeStageStartGame, which is reached on the goroutine calling
+	// Conn.StartGame. It should not block for long.
+	HandshakeFunc func(conn *Conn, stage HandshakeStage)
+	// EmoteFunc is called with a connection of the Listener for every emote that its client performs, as read
+	// from an Emote packet using Conn.ReadPacket. The server is responsible for broadcasting emotes to other
+	// players, which may be done from EmoteFunc. It is called on the goroutine reading the packet, before
+	// ReadPacket returns it.
+	EmoteFunc func(conn *Conn, emote PlayerEmote)
+
+	// Capture is called for every connection accepted by the Listener. If it returns a non-nil io.Writer, every
+	// packet read from and written to the connection is recorded to it, so that the session may be inspected
+	// or replayed later using a CaptureReader. See Dialer.Capture for more information.
+	Capture func(addr net.Addr) io.Writer
+
+	// PacketViolationFunc is called when a connection returned by Listener.Accept reads a
+	// PacketViolationWarning, which the client sends when it receives a packet that it could not handle. The
+	// warning is logged regardless of whether PacketViolationFunc is set.
+	PacketViolationFunc func(conn *Conn, pk *packet.PacketViolationWarning)
+
+	// AllowAddr is called for every connection made to the Listener with its remote address, before any part
+	// of its login sequence is handled. If it returns false, the connection is disconnected with the message
+	// returned and closed, so that connections from banned addresses may be rejected without the cost of
+	// verifying their login request and enabling encryption.
+	AllowAddr func(addr net.Addr) (message string, ok bool)
+	// AllowIdentity is called with the identity of a connection once its login request has been parsed and,
+	// unless AuthenticationDisabled is set, verified, so that the XUID of the player may be relied on. If it
+	// returns false, the connection is disconnected with the message returned and closed before encryption is
+	// enabled.
+	AllowIdentity func(addr net.Addr, identity login.IdentityData) (message string, ok bool)
 }
 
 // Listener implements a Minecraft listener on top of an unspecific net.Listener. It abstracts away the
@@ -91,6 +212,9 @@ type Listener struct {
 	// playerCount is the amount of players connected to the server. If MaximumPlayers is non-zero and equal
 	// to the playerCount, no more players will be accepted.
 	playerCount atomic.Int32
+	// handshakes and connections are the amount of connections currently going through the login sequence
+	// and the amount that completed it respectively.
+	handshakes, connections atomic.Int32
 
 	incoming chan *Conn
 	close    chan struct{}
@@ -107,14 +231,18 @@ func (cfg ListenConfig) Listen(network string, address string) (*Listener, error
 		return nil, fmt.Errorf("listen: no network under id: %v", network)
 	}
 
-	netListener, err := n.Listen(address)
+	var netListener NetworkListener
+	var err error
+	if idNetwork, ok := n.(serverIDNetwork); ok && cfg.ServerID != 0 {
+		netListener, err = idNetwork.ListenServerID(address, cfg.ServerID)
+	} else {
+		netListener, err = n.Listen(address)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if cfg.ErrorLog == nil {
-		cfg.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
-	}
+	cfg.Logger = bridgeLogger(cfg.Logger, cfg.ErrorLog)
 	if cfg.StatusProvider == nil {
 		cfg.StatusProvider = NewStatusProvider("Minecraft Server")
 	}
@@ -161,6 +289,25 @@ func (listener *Listener) Accept() (net.Conn, error) {
 	return conn, nil
 }
 
+// ListenerStats holds the amount of connections of a Listener in each stage of their lifetime.
+type ListenerStats struct {
+	// Handshaking is the amount of connections currently going through the login sequence.
+	Handshaking int
+	// Established is the amount of connections that completed the login sequence and were not yet closed,
+	// including those not yet accepted using Listener.Accept.
+	Established int
+}
+
+// Stats returns the amount of connections of the Listener that are currently logging in and that completed
+// the login sequence. These are the counts limited by ListenConfig.MaximumHandshakes and
+// ListenConfig.MaximumConnections respectively.
+func (listener *Listener) Stats() ListenerStats {
+	return ListenerStats{
+		Handshaking: int(listener.handshakes.Load()),
+		Established: int(listener.connections.Load()),
+	}
+}
+
 // Disconnect disconnects a Minecraft Conn passed by first sending a disconnect with the message passed, and
 // closing the connection after. If the message passed is empty, the client will be immediately sent to the
 // server list instead of a disconnect screen.
@@ -177,6 +324,13 @@ func (listener *Listener) Addr() net.Addr {
 	return listener.listener.Addr()
 }
 
+// PublicKey returns the public key that the Listener signs the ServerToClientHandshake packets of its
+// connections with. It may be set as Dialer.ServerPublicKey to make sure a client is connected to this
+// Listener.
+func (listener *Listener) PublicKey() *ecdsa.PublicKey {
+	return &listener.key.PublicKey
+}
+
 // Close closes the listener and the underlying net.Listener. Pending calls to Accept will fail immediately.
 func (listener *Listener) Close() error {
 	return listener.listener.Close()
@@ -185,11 +339,28 @@ func (listener *Listener) Close() error {
 // updatePongData updates the pong data of the listener using the current only players, maximum players and
 // server name of the listener, provided the listener isn't currently hijacking the pong of another server.
 func (listener *Listener) updatePongData() {
+	listener.listener.PongData(listener.pong().Bytes())
+}
+
+// pong returns the Pong that the listener responds to pings with, holding the current online players, maximum
+// players and server name of the listener.
+func (listener *Listener) pong() Pong {
 	s := listener.status()
-	listener.listener.PongData([]byte(fmt.Sprintf("MCPE;%v;%v;%v;%v;%v;%v;Gophertunnel;%v;%v;%v;%v;",
-		s.ServerName, protocol.CurrentProtocol, protocol.CurrentVersion, s.PlayerCount, s.MaxPlayers,
-		listener.listener.ID(), "Creative", 1, listener.Addr().(*net.UDPAddr).Port, listener.Addr().(*net.UDPAddr).Port,
-	)))
+	port := uint16(listener.Addr().(*net.UDPAddr).Port)
+	return Pong{
+		Edition:     "MCPE",
+		MOTD:        s.ServerName,
+		Protocol:    protocol.CurrentProtocol,
+		Version:     protocol.CurrentVersion,
+		PlayerCount: s.PlayerCount,
+		MaxPlayers:  s.MaxPlayers,
+		ServerID:    uint64(listener.listener.ID()),
+		SubMOTD:     "Gophertunnel",
+		GameMode:    "Creative",
+		GameModeID:  1,
+		PortV4:      port,
+		PortV6:      port,
+	}
 }
 
 // listen starts listening for incoming connections and packets. When a player is fully connected, it submits
@@ -227,26 +398,65 @@ func (listener *Listener) listen() {
 // createConn creates a connection for the net.Conn passed and adds it to the listener, so that it may be
 // accepted once its login sequence is complete.
 func (listener *Listener) createConn(netConn net.Conn) {
-	conn := newConn(netConn, listener.key, listener.cfg.ErrorLog, proto{}, listener.cfg.FlushRate, true)
+	conn := newConn(netConn, listener.key, listener.cfg.Logger, proto{}, listener.cfg.FlushRate, listener.cfg.ReadBufferSize, listener.cfg.WriteBufferSize, true)
 	conn.acceptedProto = append(listener.cfg.AcceptedProtocols, proto{})
 	conn.compression = listener.cfg.Compression
 	conn.pool = conn.proto.Packets(true)
 
 	conn.packetFunc = listener.cfg.PacketFunc
+	conn.packetSizeFunc = listener.cfg.PacketSizeFunc
+	conn.handshakeFunc = listener.cfg.HandshakeFunc
+	conn.emoteFunc = listener.cfg.EmoteFunc
+	if f := listener.cfg.PacketViolationFunc; f != nil {
+		conn.violationFunc = func(pk *packet.PacketViolationWarning) {
+			f(conn, pk)
+		}
+	}
+	if listener.cfg.Capture != nil {
+		if w := listener.cfg.Capture(netConn.RemoteAddr()); w != nil {
+			conn.captureWriter.Store(newCaptureWriter(w, true))
+		}
+	}
 	conn.texturePacksRequired = listener.cfg.TexturePacksRequired
 	conn.resourcePacks = listener.cfg.ResourcePacks
 	conn.biomes = listener.cfg.Biomes
 	conn.gameData.WorldName = listener.status().ServerName
 	conn.authEnabled = !listener.cfg.AuthenticationDisabled
 	conn.disconnectOnUnknownPacket = !listener.cfg.AllowUnknownPackets
+	conn.ignoreUnknownPacket = listener.cfg.IgnoreUnknownPackets
 	conn.disconnectOnInvalidPacket = !listener.cfg.AllowInvalidPackets
+	conn.rawHandshake = listener.cfg.RawHandshake
+	conn.disableEncryption = listener.cfg.DisableEncryption
+	conn.exposeKey = listener.cfg.ExposeEncryptionKey
+	conn.sendQueueSize, conn.sendQueuePolicy = listener.cfg.SendQueueSize, listener.cfg.SendQueuePolicy
+	conn.setReadQueue(listener.cfg.ReadQueueSize, listener.cfg.ReadQueuePolicy)
+	if f := listener.cfg.AllowIdentity; f != nil {
+		conn.identityFunc = func(identity login.IdentityData) (string, bool) {
+			return f(netConn.RemoteAddr(), identity)
+		}
+	}
 
+	if f := listener.cfg.AllowAddr; f != nil {
+		if message, ok := f(netConn.RemoteAddr()); !ok {
+			conn.log.Info("connection rejected by address", "message", message)
+			_ = conn.WritePacket(&packet.Disconnect{Message: message})
+			_ = conn.Close()
+			return
+		}
+	}
 	if listener.playerCount.Load() == int32(listener.cfg.MaximumPlayers) && listener.cfg.MaximumPlayers != 0 {
 		// The server was full. We kick the player immediately and close the connection.
 		_ = conn.WritePacket(&packet.PlayStatus{Status: packet.PlayStatusLoginFailedServerFull})
 		_ = conn.Close()
 		return
 	}
+	if n := listener.handshakes.Add(1); listener.cfg.MaximumHandshakes != 0 && n > int32(listener.cfg.MaximumHandshakes) {
+		listener.handshakes.Add(-1)
+		conn.log.Warn("connection rejected: too many connections logging in", "limit", listener.cfg.MaximumHandshakes)
+		_ = conn.WritePacket(&packet.PlayStatus{Status: packet.PlayStatusLoginFailedServerFull})
+		_ = conn.Close()
+		return
+	}
 	listener.playerCount.Add(1)
 	listener.updatePongData()
 
@@ -265,28 +475,60 @@ func (listener *Listener) status() ServerStatus {
 // handleConn handles an incoming connection of the Listener. It will first attempt to get the connection to
 // log in, after which it will expose packets received to the user.
 func (listener *Listener) handleConn(conn *Conn) {
+	established := false
 	defer func() {
 		_ = conn.Close()
+		if established {
+			listener.connections.Add(-1)
+		} else {
+			listener.handshakes.Add(-1)
+		}
 		listener.playerCount.Add(-1)
 		listener.updatePongData()
 	}()
+	var loginTimer *time.Timer
+	if timeout := listener.cfg.LoginTimeout; timeout > 0 {
+		loginTimer = time.AfterFunc(timeout, func() {
+			var err error = LoginTimeoutError{Timeout: timeout}
+			conn.log.Warn("close listener connection", "err", err)
+			conn.loginErr.Store(&err)
+			_ = conn.Close()
+		})
+		defer loginTimer.Stop()
+	}
 	for {
 		// We finally arrived at the packet decoding loop. We constantly decode packets that arrive
 		// and push them to the Conn so that they may be processed.
-		packets, err := conn.dec.Decode()
+		packets, err := conn.readBackground()
 		if err != nil {
 			if !raknet.ErrConnectionClosed(err) {
-				listener.cfg.ErrorLog.Printf("error reading from listener connection: %v\n", err)
+				conn.log.Error("read from listener connection", "err", err)
 			}
 			return
 		}
 		for _, data := range packets {
 			loggedInBefore := conn.loggedIn
 			if err := conn.receive(data); err != nil {
-				listener.cfg.ErrorLog.Printf("error: %v", err)
+				if !isFatal(err) {
+					conn.log.Warn("handle packet", "err", err)
+					continue
+				}
+				conn.log.Error("handle packet", "err", err)
 				return
 			}
 			if !loggedInBefore && conn.loggedIn {
+				if loginTimer != nil && !loginTimer.Stop() {
+					// The login timeout expired just before the connection logged in, so it is already being
+					// closed.
+					return
+				}
+				listener.handshakes.Add(-1)
+				established = true
+				if n := listener.connections.Add(1); listener.cfg.MaximumConnections != 0 && n > int32(listener.cfg.MaximumConnections) {
+					conn.log.Warn("connection rejected: too many connections", "limit", listener.cfg.MaximumConnections)
+					_ = conn.WritePacket(&packet.Disconnect{Message: "Server is full."})
+					return
+				}
 				select {
 				case <-listener.close:
 					// The listener was closed while this one was logged in, so the incoming channel will be
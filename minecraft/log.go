@@ -0,0 +1,19 @@
+package minecraft
+
+import (
+	"log"
+	"log/slog"
+)
+
+// bridgeLogger returns the slog.Logger l if it is non-nil. If l is nil and errorLog is non-nil, a
+// slog.Logger is returned that writes to the writer of errorLog, so that the deprecated log.Logger fields
+// keep working. If both are nil, slog.Default() is returned.
+func bridgeLogger(l *slog.Logger, errorLog *log.Logger) *slog.Logger {
+	if l != nil {
+		return l
+	}
+	if errorLog != nil {
+		return slog.New(slog.NewTextHandler(errorLog.Writer(), nil))
+	}
+	return slog.Default()
+}
@@ -217,6 +217,9 @@ func (d *Decoder) unmarshalTag(val reflect.Value, t tagType, tagName string) err
 		if err != nil {
 			return err
 		}
+		if length < 0 {
+			return NegativeLengthError{Off: d.r.off, Op: "ByteArray", Length: length}
+		}
 		b := make([]byte, length)
 		if _, err := d.r.Read(b); err != nil {
 			return BufferOverrunError{Op: "ByteArray"}
@@ -297,6 +300,9 @@ func (d *Decoder) unmarshalTag(val reflect.Value, t tagType, tagName string) err
 			if err != nil {
 				return BufferOverrunError{Op: "ByteSlice"}
 			}
+			if length < 0 {
+				return NegativeLengthError{Off: d.r.off, Op: "ByteSlice", Length: length}
+			}
 			if length == 0 {
 				// Empty lists are allowed to have the TAG_Byte type.
 				val.Set(reflect.MakeSlice(sliceType, int(length), int(length)))
@@ -339,6 +345,9 @@ func (d *Decoder) unmarshalTag(val reflect.Value, t tagType, tagName string) err
 			if err != nil {
 				return err
 			}
+			if length < 0 {
+				return NegativeLengthError{Off: d.r.off, Op: "List", Length: length}
+			}
 			v := reflect.MakeSlice(sliceType, int(length), int(length))
 			for i := 0; i < int(length); i++ {
 				if err := d.unmarshalTag(v.Index(i), listType, ""); err != nil {
@@ -191,6 +191,9 @@ func (e networkLittleEndian) Int32Slice(r *offsetReader) ([]int32, error) {
 	if err != nil {
 		return nil, BufferOverrunError{Op: "Int32Slice"}
 	}
+	if n < 0 {
+		return nil, NegativeLengthError{Off: r.off, Op: "Int32Slice", Length: n}
+	}
 	m := make([]int32, n)
 	for i := int32(0); i < n; i++ {
 		m[i], err = e.Int32(r)
@@ -207,6 +210,9 @@ func (e networkLittleEndian) Int64Slice(r *offsetReader) ([]int64, error) {
 	if err != nil {
 		return nil, BufferOverrunError{Op: "Int64Slice"}
 	}
+	if n < 0 {
+		return nil, NegativeLengthError{Off: r.off, Op: "Int64Slice", Length: n}
+	}
 	m := make([]int64, n)
 	for i := int32(0); i < n; i++ {
 		m[i], err = e.Int64(r)
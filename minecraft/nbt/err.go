@@ -168,3 +168,15 @@ type InvalidVarintError struct {
 func (err InvalidVarintError) Error() string {
 	return fmt.Sprintf("nbt: varint did not terminate after %v bytes at offset %v", err.N, err.Off)
 }
+
+// NegativeLengthError is returned when the length of an array or list read from the NBT is negative.
+type NegativeLengthError struct {
+	Off    int64
+	Op     string
+	Length int32
+}
+
+// Error ...
+func (err NegativeLengthError) Error() string {
+	return fmt.Sprintf("nbt: negative length %v at %v during op '%v'", err.Length, err.Off, err.Op)
+}
@@ -2,7 +2,9 @@ package minecraft
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"time"
 )
 
 // Network represents an implementation of a supported network layers, such as RakNet.
@@ -39,6 +41,14 @@ type NetworkListener interface {
 	PongData(data []byte)
 }
 
+// serverIDNetwork is a Network of which listeners may be created with a fixed server ID. Listener uses it to
+// set the ID from ListenConfig.ServerID.
+type serverIDNetwork interface {
+	// ListenServerID listens on the address passed like Network.Listen, but with the server ID passed
+	// instead of one picked by the Network.
+	ListenServerID(address string, id int64) (NetworkListener, error)
+}
+
 // networks holds a map of id => Network to be used for looking up the network by an ID. It is registered to when calling
 // RegisterNetwork.
 var networks = map[string]Network{}
@@ -53,3 +63,49 @@ func networkByID(id string) (Network, bool) {
 	n, ok := networks[id]
 	return n, ok
 }
+
+// TCPOptions holds socket options for connections of a Network that are backed by TCP. The zero value keeps
+// the defaults of the net package, which disables Nagle's algorithm and enables keep-alive probes.
+type TCPOptions struct {
+	// Delay, if set to true, enables Nagle's algorithm (clears TCP_NODELAY), so that small writes are
+	// coalesced at the cost of latency. Because the Conn already batches packets written within its flush
+	// rate, Delay should rarely be needed.
+	Delay bool
+	// KeepAlive is the interval between keep-alive probes sent over the connection. If set to 0, the default
+	// of the operating system is used. If negative, keep-alive probes are disabled. Positive values must be at
+	// least a second, which is the granularity supported by most operating systems.
+	KeepAlive time.Duration
+}
+
+// validate checks if the TCPOptions hold valid values.
+func (opts TCPOptions) validate() error {
+	if opts.KeepAlive > 0 && opts.KeepAlive < time.Second {
+		return fmt.Errorf("tcp keep-alive interval must be at least 1s, got %v", opts.KeepAlive)
+	}
+	return nil
+}
+
+// apply applies the TCPOptions to the connection passed if it is a TCP connection. Connections of other
+// networks, such as RakNet, are left unchanged.
+func (opts TCPOptions) apply(conn net.Conn) error {
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	if err := tcp.SetNoDelay(!opts.Delay); err != nil {
+		return fmt.Errorf("set tcp no delay: %w", err)
+	}
+	if opts.KeepAlive < 0 {
+		if err := tcp.SetKeepAlive(false); err != nil {
+			return fmt.Errorf("disable tcp keep-alive: %w", err)
+		}
+	} else if opts.KeepAlive > 0 {
+		if err := tcp.SetKeepAlive(true); err != nil {
+			return fmt.Errorf("enable tcp keep-alive: %w", err)
+		}
+		if err := tcp.SetKeepAlivePeriod(opts.KeepAlive); err != nil {
+			return fmt.Errorf("set tcp keep-alive period: %w", err)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"net"
 )
 
 // packetData holds the data of a Minecraft packet.
@@ -20,15 +21,54 @@ func parseData(data []byte, conn *Conn) (*packetData, error) {
 	if err := header.Read(buf); err != nil {
 		// We don't return this as an error as it's not in the hand of the user to control this. Instead,
 		// we return to reading a new packet.
-		return nil, fmt.Errorf("error reading packet header: %v", err)
+		return nil, nonFatalError{err: fmt.Errorf("error reading packet header: %v", err)}
 	}
 	if conn.packetFunc != nil {
 		// The packet func was set, so we call it.
 		conn.packetFunc(*header, buf.Bytes(), conn.RemoteAddr(), conn.LocalAddr())
 	}
+	conn.capture(false, *header, buf.Bytes())
 	return &packetData{h: header, full: data, payload: buf}, nil
 }
 
+// decode decodes a batch of packets from the underlying connection and passes their sizes to the
+// PacketSizeFunc if set.
+func (conn *Conn) decode() ([][]byte, error) {
+	packets, err := conn.dec.Decode()
+	if err == nil {
+		conn.reportSizes(packets, conn.dec.BatchSize(), conn.RemoteAddr(), conn.LocalAddr())
+	}
+	return packets, err
+}
+
+// reportSizes passes the size of every packet in a batch with the wire size passed to the PacketSizeFunc of
+// the Conn, if set. The wire size of the batch is divided over the packets by their size, with the bytes left
+// over after rounding down attributed to the last packet.
+func (conn *Conn) reportSizes(packets [][]byte, batchSize int, src, dst net.Addr) {
+	if conn.packetSizeFunc == nil || len(packets) == 0 {
+		return
+	}
+	total := 0
+	for _, data := range packets {
+		total += len(data)
+	}
+	remaining := batchSize
+	for i, data := range packets {
+		wireSize := remaining
+		if i != len(packets)-1 && total != 0 {
+			wireSize = batchSize * len(data) / total
+		}
+		remaining -= wireSize
+
+		var header packet.Header
+		if err := header.Read(bytes.NewBuffer(data)); err != nil {
+			// Packets with an invalid header are dropped by parseData, which logs the error.
+			continue
+		}
+		conn.packetSizeFunc(header, len(data), wireSize, src, dst)
+	}
+}
+
 type unknownPacketError struct {
 	id uint32
 }
@@ -48,7 +88,11 @@ func (p *packetData) decode(conn *Conn) (pks []packet.Packet, err error) {
 		}
 		if _, ok := err.(unknownPacketError); ok || conn.disconnectOnInvalidPacket {
 			_ = conn.Close()
+			return
 		}
+		// Invalid packets are allowed, so the error only affects this packet and the connection may continue
+		// reading packets.
+		err = nonFatalError{err: err}
 	}()
 
 	// Attempt to fetch the packet with the right packet ID from the pool.
@@ -60,11 +104,15 @@ func (p *packetData) decode(conn *Conn) (pks []packet.Packet, err error) {
 		if conn.disconnectOnUnknownPacket {
 			return nil, unknownPacketError{id: p.h.PacketID}
 		}
+		if conn.ignoreUnknownPacket {
+			// The raw payload of the packet was already passed to the PacketFunc, so we can simply drop it.
+			return nil, nil
+		}
 	} else {
 		pk = pkFunc()
 	}
 
-	r := conn.proto.NewReader(p.payload, conn.shieldID.Load(), conn.readerLimits)
+	r := conn.versioned(conn.proto.NewReader(p.payload, conn.shieldID.Load(), conn.readerLimits))
 	pk.Marshal(r)
 	if p.payload.Len() != 0 {
 		err = fmt.Errorf("%T: %v unread bytes left: 0x%x", pk, p.payload.Len(), p.payload.Bytes())
@@ -0,0 +1,83 @@
+package minecraft
+
+import (
+	"bytes"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+)
+
+// TestPacketErrorClassification tests that errors decoding or handling a single packet only close the
+// connection if they are fatal.
+func TestPacketErrorClassification(t *testing.T) {
+	text := bytes.NewBuffer(nil)
+	(&packet.Text{TextType: packet.TextTypeRaw, Message: "hello"}).Marshal(protocol.NewWriter(text, 0))
+
+	for _, test := range []struct {
+		name                     string
+		id                       uint32
+		payload                  []byte
+		disconnectOnUnknown      bool
+		disconnectOnInvalid      bool
+		ignoreUnknown            bool
+		wantPackets, wantErr     bool
+		wantFatal, wantConnClose bool
+	}{
+		{name: "Valid", id: packet.IDText, payload: text.Bytes(), wantPackets: true},
+		{name: "TooManyBytes", id: packet.IDText, payload: append(bytes.Clone(text.Bytes()), 0x01), wantPackets: true, wantErr: true},
+		{name: "TooFewBytes", id: packet.IDText, payload: text.Bytes()[:2], wantErr: true},
+		{name: "TooManyBytesDisconnect", id: packet.IDText, payload: append(bytes.Clone(text.Bytes()), 0x01), disconnectOnInvalid: true, wantErr: true, wantFatal: true, wantConnClose: true},
+		{name: "Unknown", id: 0x3fe, payload: []byte{0x01}, wantPackets: true},
+		{name: "UnknownIgnored", id: 0x3fe, payload: []byte{0x01}, ignoreUnknown: true},
+		{name: "UnknownDisconnect", id: 0x3fe, payload: []byte{0x01}, disconnectOnUnknown: true, wantErr: true, wantFatal: true, wantConnClose: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, other := net.Pipe()
+			defer other.Close()
+			conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+			defer conn.Close()
+			conn.pool = conn.proto.Packets(false)
+			conn.disconnectOnUnknownPacket, conn.disconnectOnInvalidPacket = test.disconnectOnUnknown, test.disconnectOnInvalid
+			conn.ignoreUnknownPacket = test.ignoreUnknown
+
+			buf := bytes.NewBuffer(nil)
+			_ = (&packet.Header{PacketID: test.id}).Write(buf)
+			buf.Write(test.payload)
+			data, err := parseData(buf.Bytes(), conn)
+			if err != nil {
+				t.Fatalf("parse data: %v", err)
+			}
+			pks, err := data.decode(conn)
+			if (len(pks) != 0) != test.wantPackets {
+				t.Errorf("expected packets returned: %v, got %v", test.wantPackets, pks)
+			}
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error: %v, got %v", test.wantErr, err)
+			}
+			if err != nil && isFatal(err) != test.wantFatal {
+				t.Errorf("expected fatal error: %v, got %v", test.wantFatal, isFatal(err))
+			}
+			select {
+			case <-conn.close:
+				if !test.wantConnClose {
+					t.Errorf("expected connection to remain open")
+				}
+			default:
+				if test.wantConnClose {
+					t.Errorf("expected connection to be closed")
+				}
+			}
+		})
+	}
+
+	t.Run("StrayResourcePackChunk", func(t *testing.T) {
+		conn := &Conn{packQueue: &resourcePackQueue{awaitingPacks: map[string]*downloadingPack{}}}
+		err := conn.handleResourcePackChunkData(&packet.ResourcePackChunkData{UUID: "1f9ce3d6-7b5c-4c44-a1c9-4b8b1e8e7b2a"})
+		if err == nil || isFatal(err) {
+			t.Fatalf("expected non-fatal error for stray resource pack chunk, got %v", err)
+		}
+	})
+}
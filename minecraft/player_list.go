@@ -0,0 +1,94 @@
+package minecraft
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ParsePlayerList returns the entries added to and the UUIDs of the entries removed from the player list by
+// the PlayerList packet passed. Only one of the two is non-empty, depending on the action of the packet. The
+// entries removed only hold their UUID in the packet.
+func ParsePlayerList(pk *packet.PlayerList) (added []protocol.PlayerListEntry, removed []uuid.UUID) {
+	switch pk.ActionType {
+	case packet.PlayerListActionAdd:
+		return pk.Entries, nil
+	case packet.PlayerListActionRemove:
+		removed = make([]uuid.UUID, 0, len(pk.Entries))
+		for _, entry := range pk.Entries {
+			removed = append(removed, entry.UUID)
+		}
+		return nil, removed
+	}
+	return nil, nil
+}
+
+// playerList tracks the players in the player list sent by the server if Dialer.TrackPlayers is set.
+type playerList struct {
+	mu      sync.Mutex
+	enabled bool
+	players map[uuid.UUID]protocol.PlayerListEntry
+}
+
+// Players returns all players currently in the player list sent by the server using PlayerList packets,
+// sorted by their username. It always returns nil unless the Conn was dialed with Dialer.TrackPlayers set.
+// The skins of the players are kept up to date with PlayerSkin packets, and must not be modified.
+func (conn *Conn) Players() []protocol.PlayerListEntry {
+	conn.players.mu.Lock()
+	defer conn.players.mu.Unlock()
+	if len(conn.players.players) == 0 {
+		return nil
+	}
+	players := make([]protocol.PlayerListEntry, 0, len(conn.players.players))
+	for _, p := range conn.players.players {
+		players = append(players, p)
+	}
+	slices.SortFunc(players, func(a, b protocol.PlayerListEntry) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+	return players
+}
+
+// Player returns the player with the UUID passed from the player list sent by the server. False is returned
+// if the player is not in the player list or if Dialer.TrackPlayers is not set.
+func (conn *Conn) Player(id uuid.UUID) (protocol.PlayerListEntry, bool) {
+	conn.players.mu.Lock()
+	defer conn.players.mu.Unlock()
+	p, ok := conn.players.players[id]
+	return p, ok
+}
+
+// handlePlayerList adds or removes the entries in the PlayerList packet passed.
+func (l *playerList) handlePlayerList(pk *packet.PlayerList) {
+	if !l.enabled {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	added, removed := ParsePlayerList(pk)
+	if l.players == nil {
+		l.players = make(map[uuid.UUID]protocol.PlayerListEntry)
+	}
+	for _, entry := range added {
+		l.players[entry.UUID] = entry
+	}
+	for _, id := range removed {
+		delete(l.players, id)
+	}
+}
+
+// handlePlayerSkin updates the skin of the player in the PlayerSkin packet passed, if in the player list.
+func (l *playerList) handlePlayerSkin(pk *packet.PlayerSkin) {
+	if !l.enabled {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p, ok := l.players[pk.UUID]; ok {
+		p.Skin = pk.Skin
+		l.players[pk.UUID] = p
+	}
+}
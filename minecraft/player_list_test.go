@@ -0,0 +1,36 @@
+package minecraft
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+)
+
+// TestPlayerListSkins tests that the skins of players in the player list are updated with PlayerSkin packets
+// and dropped once the player is removed from the player list.
+func TestPlayerListSkins(t *testing.T) {
+	id := uuid.New()
+	conn := &Conn{}
+	conn.players.handlePlayerList(&packet.PlayerList{ActionType: packet.PlayerListActionAdd, Entries: []protocol.PlayerListEntry{{UUID: id, Username: "Steve"}}})
+	if _, ok := conn.PlayerSkin(id); ok {
+		t.Fatalf("expected no skin to be tracked without TrackPlayers")
+	}
+
+	conn.players.enabled = true
+	conn.players.handlePlayerList(&packet.PlayerList{ActionType: packet.PlayerListActionAdd, Entries: []protocol.PlayerListEntry{{UUID: id, Username: "Steve"}}})
+	conn.players.handlePlayerSkin(&packet.PlayerSkin{UUID: id, Skin: protocol.Skin{SkinID: "custom"}})
+	// Skins of players not in the player list are not tracked.
+	conn.players.handlePlayerSkin(&packet.PlayerSkin{UUID: uuid.New(), Skin: protocol.Skin{SkinID: "other"}})
+	if skin, ok := conn.PlayerSkin(id); !ok || skin.SkinID != "custom" {
+		t.Fatalf("expected skin to be updated, got %q (%v)", skin.SkinID, ok)
+	}
+	if len(conn.players.players) != 1 {
+		t.Fatalf("expected 1 player to be tracked, got %v", len(conn.players.players))
+	}
+
+	conn.players.handlePlayerList(&packet.PlayerList{ActionType: packet.PlayerListActionRemove, Entries: []protocol.PlayerListEntry{{UUID: id}}})
+	if _, ok := conn.PlayerSkin(id); ok {
+		t.Fatalf("expected skin to be dropped after the player was removed")
+	}
+}
@@ -0,0 +1,103 @@
+package minecraft
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pong holds the data found in the pong sent by a server in response to an unconnected ping, such as one sent
+// by Ping or when a client refreshes the server list. The data is sent as a string of fields separated by
+// semicolons, such as "MCPE;Dedicated Server;662;1.20.70;0;10;13253860892328930865;Bedrock level;Survival;1;19132;19133;".
+// Servers of older versions may leave out any of the fields after MaxPlayers, in which case they are left
+// empty.
+type Pong struct {
+	// Edition is the edition of the game that the server runs, typically "MCPE", or "MCEE" for Education
+	// Edition servers.
+	Edition string
+	// MOTD is the first line of the MOTD, or server name, displayed in the server list.
+	MOTD string
+	// Protocol is the protocol version of the server, such as protocol.CurrentProtocol.
+	Protocol int32
+	// Version is the Minecraft version of the server, such as protocol.CurrentVersion.
+	Version string
+	// PlayerCount is the amount of players online on the server.
+	PlayerCount int
+	// MaxPlayers is the maximum amount of players that may be online on the server.
+	MaxPlayers int
+	// ServerID is the unique ID of the server, which is generally the RakNet GUID of the server.
+	ServerID uint64
+	// SubMOTD is the second line of the MOTD, which is generally the name of the world of the server.
+	SubMOTD string
+	// GameMode is the name of the default game mode of the server, such as "Survival".
+	GameMode string
+	// GameModeID is the numerical ID of the default game mode of the server.
+	GameModeID int
+	// PortV4 and PortV6 are the IPv4 and IPv6 ports that the server listens on. They are 0 if not present.
+	PortV4, PortV6 uint16
+}
+
+// ParsePong parses the pong data passed into a Pong. Semicolons in the fields may be escaped using a
+// backslash. An error is returned if the data has fewer than the six fields up to and including MaxPlayers,
+// or if any of the numerical fields present could not be parsed.
+func ParsePong(data []byte) (Pong, error) {
+	frag := splitPong(string(data))
+	if len(frag) < 6 {
+		return Pong{}, fmt.Errorf("parse pong: expected at least 6 fields, got %v", len(frag))
+	}
+	// Vanilla servers end the pong with a semicolon, resulting in an empty trailing field.
+	frag = append(frag, make([]string, 12)...)[:12]
+
+	var (
+		p   = Pong{Edition: frag[0], MOTD: frag[1], Version: frag[3], SubMOTD: frag[7], GameMode: frag[8]}
+		err error
+	)
+	intField := func(name, s string, bitSize int) int64 {
+		if s == "" || err != nil {
+			return 0
+		}
+		var v int64
+		if v, err = strconv.ParseInt(s, 10, bitSize); err != nil {
+			err = fmt.Errorf("parse pong: invalid %v %q: %w", name, s, err)
+		}
+		return v
+	}
+	p.Protocol = int32(intField("protocol", frag[2], 32))
+	p.PlayerCount = int(intField("player count", frag[4], 0))
+	p.MaxPlayers = int(intField("max players", frag[5], 0))
+	p.GameModeID = int(intField("game mode ID", frag[9], 0))
+	if err != nil {
+		return Pong{}, err
+	}
+	for i, port := range []*uint16{&p.PortV4, &p.PortV6} {
+		if s := frag[10+i]; s != "" {
+			v, err := strconv.ParseUint(s, 10, 16)
+			if err != nil {
+				return Pong{}, fmt.Errorf("parse pong: invalid port %q: %w", s, err)
+			}
+			*port = uint16(v)
+		}
+	}
+	if s := frag[6]; s != "" {
+		// Some servers write the server ID as a signed integer, so we accept both.
+		if p.ServerID, err = strconv.ParseUint(s, 10, 64); err != nil {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return Pong{}, fmt.Errorf("parse pong: invalid server ID %q: %w", s, err)
+			}
+			p.ServerID = uint64(id)
+		}
+	}
+	return p, nil
+}
+
+// Bytes encodes the Pong into pong data in the format sent by vanilla servers, so that it may be parsed using
+// ParsePong. Semicolons and backslashes in the fields are escaped.
+func (p Pong) Bytes() []byte {
+	escape := strings.NewReplacer(`\`, `\\`, `;`, `\;`)
+	return []byte(fmt.Sprintf("%v;%v;%v;%v;%v;%v;%v;%v;%v;%v;%v;%v;",
+		escape.Replace(p.Edition), escape.Replace(p.MOTD), p.Protocol, escape.Replace(p.Version), p.PlayerCount,
+		p.MaxPlayers, p.ServerID, escape.Replace(p.SubMOTD), escape.Replace(p.GameMode), p.GameModeID, p.PortV4,
+		p.PortV6,
+	))
+}
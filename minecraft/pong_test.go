@@ -0,0 +1,72 @@
+package minecraft
+
+import (
+	"testing"
+)
+
+func TestParsePong(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Pong
+		// exact specifies if encoding the parsed Pong should produce exactly the data passed.
+		exact bool
+	}{{
+		name:  "v1.20.70 dedicated server",
+		data:  "MCPE;Dedicated Server;662;1.20.70;0;10;13253860892328930865;Bedrock level;Survival;1;19132;19133;",
+		want:  Pong{Edition: "MCPE", MOTD: "Dedicated Server", Protocol: 662, Version: "1.20.70", MaxPlayers: 10, ServerID: 13253860892328930865, SubMOTD: "Bedrock level", GameMode: "Survival", GameModeID: 1, PortV4: 19132, PortV6: 19133},
+		exact: true,
+	}, {
+		name:  "v1.16.220 server with colour codes",
+		data:  "MCPE;§aThe Hive§r;431;1.16.220;19034;100000;4710282103424436581;Hive Games;Survival;1;19132;19133;",
+		want:  Pong{Edition: "MCPE", MOTD: "§aThe Hive§r", Protocol: 431, Version: "1.16.220", PlayerCount: 19034, MaxPlayers: 100000, ServerID: 4710282103424436581, SubMOTD: "Hive Games", GameMode: "Survival", GameModeID: 1, PortV4: 19132, PortV6: 19133},
+		exact: true,
+	}, {
+		name: "v1.2.0 server without game mode ID and ports",
+		data: "MCPE;Steve's world;160;1.2.0;1;8;1234567890123456789;Bedrock level;Survival",
+		want: Pong{Edition: "MCPE", MOTD: "Steve's world", Protocol: 160, Version: "1.2.0", PlayerCount: 1, MaxPlayers: 8, ServerID: 1234567890123456789, SubMOTD: "Bedrock level", GameMode: "Survival"},
+	}, {
+		name: "v0.14.0 server with only required fields",
+		data: "MCPE;A Minecraft Server;70;0.14.0;2;20",
+		want: Pong{Edition: "MCPE", MOTD: "A Minecraft Server", Protocol: 70, Version: "0.14.0", PlayerCount: 2, MaxPlayers: 20},
+	}, {
+		name: "education edition server with escaped MOTD",
+		data: `MCEE;Class\;room \\ 1;662;1.20.70;3;30;-5;World;Creative;1;19132;19133;`,
+		want: Pong{Edition: "MCEE", MOTD: `Class;room \ 1`, Protocol: 662, Version: "1.20.70", PlayerCount: 3, MaxPlayers: 30, ServerID: 18446744073709551611, SubMOTD: "World", GameMode: "Creative", GameModeID: 1, PortV4: 19132, PortV6: 19133},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := ParsePong([]byte(test.data))
+			if err != nil {
+				t.Fatalf("parse pong: %v", err)
+			}
+			if p != test.want {
+				t.Fatalf("parse pong:\n got  %+v\n want %+v", p, test.want)
+			}
+			data := p.Bytes()
+			if test.exact && string(data) != test.data {
+				t.Errorf("encode pong:\n got  %q\n want %q", data, test.data)
+			}
+			again, err := ParsePong(data)
+			if err != nil {
+				t.Fatalf("parse encoded pong: %v", err)
+			}
+			if again != p {
+				t.Errorf("round trip:\n got  %+v\n want %+v", again, p)
+			}
+		})
+	}
+}
+
+func TestParsePongInvalid(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"MCPE;Server;662;1.20.70;0",
+		"MCPE;Server;abc;1.20.70;0;10",
+		"MCPE;Server;662;1.20.70;0;10;1;World;Survival;1;70000;19133;",
+	} {
+		if _, err := ParsePong([]byte(data)); err == nil {
+			t.Errorf("expected error parsing pong %q", data)
+		}
+	}
+}
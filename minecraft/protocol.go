@@ -21,10 +21,11 @@ type Protocol interface {
 	// packets that may be sent by a client should be allowed.
 	Packets(listener bool) packet.Pool
 	// NewReader returns a protocol.IO that implements reading operations for reading types
-	// that are used for this Protocol.
+	// that are used for this Protocol. If the protocol.IO returned has a SetProtocolVersion method, such as
+	// protocol.Reader, the Conn calls it with ID, so that packets read the fields present in this Protocol.
 	NewReader(r ByteReader, shieldID int32, enableLimits bool) protocol.IO
 	// NewWriter returns a protocol.IO that implements writing operations for writing types
-	// that are used for this Protocol.
+	// that are used for this Protocol. Like for NewReader, SetProtocolVersion is called with ID if present.
 	NewWriter(w ByteWriter, shieldID int32) protocol.IO
 	// ConvertToLatest converts a packet.Packet obtained from the other end of a Conn to a slice of packet.Packets from
 	// the latest protocol. Any packet.Packet implementation in the packet.Pool obtained through a call to Packets that
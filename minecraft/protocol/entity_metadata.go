@@ -1,5 +1,11 @@
 package protocol
 
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"reflect"
+	"slices"
+)
+
 const (
 	EntityDataKeyFlags = iota
 	EntityDataKeyStructuralIntegrity
@@ -301,3 +307,133 @@ func (m EntityMetadata) Flag(key uint32, index uint8) bool {
 		return v.(int64)&(1<<int64(index)) != 0
 	}
 }
+
+// Byte returns the byte value stored under the key passed. If no value is set for the key, or if the value
+// is not of the byte type, false is returned.
+func (m EntityMetadata) Byte(key uint32) (byte, bool) {
+	v, ok := m[key].(byte)
+	return v, ok
+}
+
+// Int16 returns the int16 value stored under the key passed. If no value is set for the key, or if the value
+// is not of the int16 type, false is returned.
+func (m EntityMetadata) Int16(key uint32) (int16, bool) {
+	v, ok := m[key].(int16)
+	return v, ok
+}
+
+// Int32 returns the int32 value stored under the key passed. If no value is set for the key, or if the value
+// is not of the int32 type, false is returned.
+func (m EntityMetadata) Int32(key uint32) (int32, bool) {
+	v, ok := m[key].(int32)
+	return v, ok
+}
+
+// Int64 returns the int64 value stored under the key passed. If no value is set for the key, or if the value
+// is not of the int64 type, false is returned.
+func (m EntityMetadata) Int64(key uint32) (int64, bool) {
+	v, ok := m[key].(int64)
+	return v, ok
+}
+
+// Float32 returns the float32 value stored under the key passed. If no value is set for the key, or if the
+// value is not of the float32 type, false is returned.
+func (m EntityMetadata) Float32(key uint32) (float32, bool) {
+	v, ok := m[key].(float32)
+	return v, ok
+}
+
+// String returns the string value stored under the key passed. If no value is set for the key, or if the
+// value is not of the string type, false is returned.
+func (m EntityMetadata) String(key uint32) (string, bool) {
+	v, ok := m[key].(string)
+	return v, ok
+}
+
+// Compound returns the compound tag stored under the key passed. If no value is set for the key, or if the
+// value is not a compound tag, false is returned.
+func (m EntityMetadata) Compound(key uint32) (map[string]any, bool) {
+	v, ok := m[key].(map[string]any)
+	return v, ok
+}
+
+// BlockPos returns the BlockPos value stored under the key passed. If no value is set for the key, or if the
+// value is not of the BlockPos type, false is returned.
+func (m EntityMetadata) BlockPos(key uint32) (BlockPos, bool) {
+	v, ok := m[key].(BlockPos)
+	return v, ok
+}
+
+// Vec3 returns the mgl32.Vec3 value stored under the key passed. If no value is set for the key, or if the
+// value is not of the mgl32.Vec3 type, false is returned.
+func (m EntityMetadata) Vec3(key uint32) (mgl32.Vec3, bool) {
+	v, ok := m[key].(mgl32.Vec3)
+	return v, ok
+}
+
+// Type returns the entity data type (one of the EntityDataType constants) of the value stored under the key
+// passed. If no value is set for the key, or if the value has a type that cannot be encoded, false is
+// returned.
+func (m EntityMetadata) Type(key uint32) (uint32, bool) {
+	switch m[key].(type) {
+	case byte:
+		return EntityDataTypeByte, true
+	case int16:
+		return EntityDataTypeInt16, true
+	case int32:
+		return EntityDataTypeInt32, true
+	case float32:
+		return EntityDataTypeFloat32, true
+	case string:
+		return EntityDataTypeString, true
+	case map[string]any:
+		return EntityDataTypeCompoundTag, true
+	case BlockPos:
+		return EntityDataTypeBlockPos, true
+	case int64:
+		return EntityDataTypeInt64, true
+	case mgl32.Vec3:
+		return EntityDataTypeVec3, true
+	}
+	return 0, false
+}
+
+// Apply merges the metadata changes in delta, as sent in a SetActorData packet, into the entity metadata map.
+// Values in delta overwrite those in the map, even if they are of a different type, and a nil value removes
+// the key from the map. The keys of which the value was changed or removed are returned in ascending order.
+func (m EntityMetadata) Apply(delta map[uint32]any) (changed []uint32) {
+	for key, v := range delta {
+		old, ok := m[key]
+		if v == nil {
+			if ok {
+				delete(m, key)
+				changed = append(changed, key)
+			}
+			continue
+		}
+		if !ok || !reflect.DeepEqual(old, v) {
+			m[key] = v
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// Diff returns the changes that turn the entity metadata map into other, so that m.Apply(m.Diff(other))
+// results in other. Keys missing from other have a nil value in the map returned. As SetActorData packets
+// cannot remove keys, the map returned may only be sent in a SetActorData packet if it holds no nil values.
+func (m EntityMetadata) Diff(other EntityMetadata) map[uint32]any {
+	delta := make(map[uint32]any)
+	for key, v := range other {
+		if old, ok := m[key]; !ok || !reflect.DeepEqual(old, v) {
+			delta[key] = v
+		}
+	}
+	for key := range m {
+		if _, ok := other[key]; !ok {
+			delta[key] = nil
+		}
+	}
+	return delta
+}
@@ -1,8 +1,119 @@
 package protocol
 
+import (
+	"sort"
+	"sync"
+)
+
 const (
 	// CurrentProtocol is the current protocol version for the version below.
 	CurrentProtocol = 662
 	// CurrentVersion is the current version of Minecraft as supported by the `packet` package.
 	CurrentVersion = "1.20.70"
 )
+
+// Feature is a flag that describes behaviour of the protocol that depends on the protocol version. Multiple
+// Features may be combined using a bitwise OR.
+type Feature uint32
+
+const (
+	// FeatureGCMEncryption indicates that packets are encrypted using AES-256 in CTR mode with the IV derived
+	// from the shared secret, as introduced in v1.16.220. Older versions use AES-256 in CFB8 mode.
+	FeatureGCMEncryption Feature = 1 << iota
+	// FeatureSubChunkRequests indicates that the client may request sub chunks using the SubChunkRequest
+	// packet, as introduced in v1.18.0.
+	FeatureSubChunkRequests
+	// FeatureNetworkSettings indicates that the client sends a RequestNetworkSettings packet before the Login
+	// packet and that compression is negotiated using the NetworkSettings packet, as introduced in v1.19.30.
+	// Older versions send the Login packet immediately and always use flate compression.
+	FeatureNetworkSettings
+	// FeatureSnappy indicates that the Snappy compression algorithm may be selected in the NetworkSettings
+	// packet, as introduced in v1.19.30.
+	FeatureSnappy
+)
+
+// Version holds information on a single protocol version of Minecraft.
+type Version struct {
+	// Protocol is the protocol number of the version, such as CurrentProtocol.
+	Protocol int32
+	// Name is the Minecraft version that the protocol number belongs to, such as CurrentVersion.
+	Name string
+	// Features is a combination of the Feature flags supported by the version.
+	Features Feature
+}
+
+// Supports checks if the Version supports all Features passed.
+func (v Version) Supports(f Feature) bool {
+	return v.Features&f == f
+}
+
+var (
+	versionsMu sync.RWMutex
+	// versions holds all registered versions, indexed by their protocol number.
+	versions = map[int32]Version{}
+)
+
+// RegisterVersion registers a Version so that it may be retrieved using VersionByProtocol. Registering a
+// Version with the protocol number of an existing Version overwrites it.
+func RegisterVersion(v Version) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions[v.Protocol] = v
+}
+
+// VersionByProtocol looks up the Version with the protocol number passed. If no Version with that protocol
+// number was registered, false is returned.
+func VersionByProtocol(protocol int32) (Version, bool) {
+	versionsMu.RLock()
+	defer versionsMu.RUnlock()
+	v, ok := versions[protocol]
+	return v, ok
+}
+
+// Versions returns all registered Versions, sorted by their protocol number in ascending order.
+func Versions() []Version {
+	versionsMu.RLock()
+	defer versionsMu.RUnlock()
+	v := make([]Version, 0, len(versions))
+	for _, ver := range versions {
+		v = append(v, ver)
+	}
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].Protocol < v[j].Protocol
+	})
+	return v
+}
+
+// CurrentVersionInfo returns the Version of CurrentProtocol. It is always registered.
+func CurrentVersionInfo() Version {
+	v, _ := VersionByProtocol(CurrentProtocol)
+	return v
+}
+
+// versionFeatures returns the Features that are supported by the protocol number passed.
+func versionFeatures(protocol int32) Feature {
+	var f Feature
+	if protocol >= 431 {
+		f |= FeatureGCMEncryption
+	}
+	if protocol >= 475 {
+		f |= FeatureSubChunkRequests
+	}
+	if protocol >= 554 {
+		f |= FeatureNetworkSettings | FeatureSnappy
+	}
+	return f
+}
+
+// init registers the protocol versions known to the protocol package.
+func init() {
+	for p, name := range map[int32]string{
+		431: "1.16.220", 440: "1.17.0", 448: "1.17.10", 465: "1.17.30", 471: "1.17.40",
+		475: "1.18.0", 486: "1.18.10", 503: "1.18.30", 527: "1.19.0", 534: "1.19.10",
+		544: "1.19.20", 554: "1.19.30", 557: "1.19.40", 560: "1.19.50", 567: "1.19.60",
+		575: "1.19.70", 582: "1.19.80", 589: "1.20.0", 594: "1.20.10", 618: "1.20.30",
+		622: "1.20.40", 630: "1.20.50", 649: "1.20.60", CurrentProtocol: CurrentVersion,
+	} {
+		RegisterVersion(Version{Protocol: p, Name: name, Features: versionFeatures(p)})
+	}
+}
@@ -63,6 +63,25 @@ type IO interface {
 	InvalidValue(value any, forField, reason string)
 }
 
+// VersionedIO is an IO that reads or writes packets for a specific protocol version. Reader and Writer
+// implement VersionedIO. Implementing it is optional: an IO that does not is assumed to read or write
+// packets for CurrentProtocol.
+type VersionedIO interface {
+	IO
+	// ProtocolVersion returns the protocol version that packets are read or written for.
+	ProtocolVersion() int32
+}
+
+// VersionOf returns the protocol version that packets are read or written for using the IO passed. Packets
+// may use it to read or write fields that only exist in specific protocol versions. If the IO does not
+// implement VersionedIO, CurrentProtocol is returned.
+func VersionOf(io IO) int32 {
+	if v, ok := io.(VersionedIO); ok {
+		return v.ProtocolVersion()
+	}
+	return CurrentProtocol
+}
+
 // Marshaler is a type that can be written to or read from an IO.
 type Marshaler interface {
 	Marshal(r IO)
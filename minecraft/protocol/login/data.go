@@ -8,6 +8,7 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"golang.org/x/text/language"
 	"net"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -265,6 +266,47 @@ type SkinAnimation struct {
 	AnimationExpression int
 }
 
+// IsZero checks if the ClientData is equal to the zero value of ClientData, treating empty slices the same
+// as nil slices.
+func (data ClientData) IsZero() bool {
+	return data.Equal(ClientData{})
+}
+
+// Equal checks if the ClientData is equal to the ClientData passed. The contents of slices are compared, and
+// empty slices are considered equal to nil slices.
+func (data ClientData) Equal(other ClientData) bool {
+	return reflect.DeepEqual(data.normalise(), other.normalise())
+}
+
+// Clone returns a deep copy of the ClientData, so that the slices of the copy may be changed without
+// changing those of the original ClientData.
+func (data ClientData) Clone() ClientData {
+	if data.AnimatedImageData != nil {
+		data.AnimatedImageData = append([]SkinAnimation{}, data.AnimatedImageData...)
+	}
+	if data.PersonaPieces != nil {
+		data.PersonaPieces = append([]PersonaPiece{}, data.PersonaPieces...)
+	}
+	if data.PieceTintColours != nil {
+		data.PieceTintColours = append([]PersonaPieceTintColour{}, data.PieceTintColours...)
+	}
+	return data
+}
+
+// normalise returns a copy of the ClientData with all empty slices set to nil.
+func (data ClientData) normalise() ClientData {
+	if len(data.AnimatedImageData) == 0 {
+		data.AnimatedImageData = nil
+	}
+	if len(data.PersonaPieces) == 0 {
+		data.PersonaPieces = nil
+	}
+	if len(data.PieceTintColours) == 0 {
+		data.PieceTintColours = nil
+	}
+	return data
+}
+
 // checkVersion is used to check if a version is an actual valid version. It must only contain numbers and
 // dots.
 var checkVersion = regexp.MustCompile("[0-9.]").MatchString
@@ -332,6 +374,76 @@ func (data ClientData) Validate() error {
 	return nil
 }
 
+// Skin converts the skin data held by the ClientData into a protocol.Skin, as sent in packets such as
+// PlayerSkin and PlayerList. The base64 encoded fields of the ClientData are decoded. Skin returns an error
+// if any of these fields is not valid base64.
+func (data ClientData) Skin() (protocol.Skin, error) {
+	skin := protocol.Skin{
+		SkinID:                   data.SkinID,
+		PlayFabID:                data.PlayFabID,
+		SkinImageWidth:           uint32(data.SkinImageWidth),
+		SkinImageHeight:          uint32(data.SkinImageHeight),
+		CapeImageWidth:           uint32(data.CapeImageWidth),
+		CapeImageHeight:          uint32(data.CapeImageHeight),
+		AnimationData:            []byte(data.SkinAnimationData),
+		PremiumSkin:              data.PremiumSkin,
+		PersonaSkin:              data.PersonaSkin,
+		PersonaCapeOnClassicSkin: data.CapeOnClassicSkin,
+		CapeID:                   data.CapeID,
+		SkinColour:               data.SkinColour,
+		ArmSize:                  data.ArmSize,
+		Trusted:                  data.TrustedSkin,
+		OverrideAppearance:       data.OverrideSkin,
+	}
+	for _, f := range []struct {
+		name string
+		src  string
+		dst  *[]byte
+	}{
+		{name: "SkinData", src: data.SkinData, dst: &skin.SkinData},
+		{name: "CapeData", src: data.CapeData, dst: &skin.CapeData},
+		{name: "SkinResourcePatch", src: data.SkinResourcePatch, dst: &skin.SkinResourcePatch},
+		{name: "SkinGeometry", src: data.SkinGeometry, dst: &skin.SkinGeometry},
+		{name: "SkinGeometryVersion", src: data.SkinGeometryVersion, dst: &skin.GeometryDataEngineVersion},
+	} {
+		b, err := base64.StdEncoding.DecodeString(f.src)
+		if err != nil {
+			return protocol.Skin{}, fmt.Errorf("decode %v: %w", f.name, err)
+		}
+		*f.dst = b
+	}
+	for _, anim := range data.AnimatedImageData {
+		b, err := base64.StdEncoding.DecodeString(anim.Image)
+		if err != nil {
+			return protocol.Skin{}, fmt.Errorf("decode animated image data: %w", err)
+		}
+		skin.Animations = append(skin.Animations, protocol.SkinAnimation{
+			ImageWidth:     uint32(anim.ImageWidth),
+			ImageHeight:    uint32(anim.ImageHeight),
+			ImageData:      b,
+			AnimationType:  uint32(anim.Type),
+			FrameCount:     float32(anim.Frames),
+			ExpressionType: uint32(anim.AnimationExpression),
+		})
+	}
+	for _, piece := range data.PersonaPieces {
+		skin.PersonaPieces = append(skin.PersonaPieces, protocol.PersonaPiece{
+			PieceID:   piece.PieceID,
+			PieceType: piece.PieceType,
+			PackID:    piece.PackID,
+			Default:   piece.Default,
+			ProductID: piece.ProductID,
+		})
+	}
+	for _, tint := range data.PieceTintColours {
+		skin.PieceTintColours = append(skin.PieceTintColours, protocol.PersonaPieceTintColour{
+			PieceType: tint.PieceType,
+			Colours:   append([]string(nil), tint.Colours[:]...),
+		})
+	}
+	return skin, nil
+}
+
 // base64DecLength decodes the base64 data passed and checks if its length is one of the valid lengths
 // passed. If either of these checks fails, an error is returned.
 func base64DecLength(base64Data string, validLengths ...int) error {
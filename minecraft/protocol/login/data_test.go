@@ -0,0 +1,77 @@
+package login
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestClientDataClone tests that mutating the slices of a cloned ClientData does not change the original.
+func TestClientDataClone(t *testing.T) {
+	original := ClientData{
+		SkinID:            "skin",
+		AnimatedImageData: []SkinAnimation{{Image: "image", Frames: 2}},
+		PersonaPieces:     []PersonaPiece{{PieceID: "piece", PieceType: "persona_body"}},
+		PieceTintColours:  []PersonaPieceTintColour{{PieceType: "persona_eyes", Colours: [4]string{"#ff000000"}}},
+	}
+	expected := ClientData{
+		SkinID:            "skin",
+		AnimatedImageData: []SkinAnimation{{Image: "image", Frames: 2}},
+		PersonaPieces:     []PersonaPiece{{PieceID: "piece", PieceType: "persona_body"}},
+		PieceTintColours:  []PersonaPieceTintColour{{PieceType: "persona_eyes", Colours: [4]string{"#ff000000"}}},
+	}
+
+	clone := original.Clone()
+	if !clone.Equal(original) {
+		t.Fatalf("expected clone to equal the original")
+	}
+	clone.AnimatedImageData[0].Image = "changed"
+	clone.PersonaPieces[0].PieceID = "changed"
+	clone.PieceTintColours[0].Colours[0] = "changed"
+	clone.AnimatedImageData = append(clone.AnimatedImageData, SkinAnimation{})
+	clone.PersonaPieces = append(clone.PersonaPieces, PersonaPiece{})
+	clone.PieceTintColours = append(clone.PieceTintColours, PersonaPieceTintColour{})
+	if !reflect.DeepEqual(original, expected) {
+		t.Fatalf("expected original to be unchanged after mutating the clone, got %+v", original)
+	}
+	if clone.Equal(original) {
+		t.Fatalf("expected mutated clone not to equal the original")
+	}
+
+	if (ClientData{}).Clone().PersonaPieces != nil {
+		t.Fatalf("expected nil slices to remain nil when cloned")
+	}
+}
+
+// TestClientDataIsZero tests that IsZero only reports true for a ClientData without any field set, treating
+// empty slices the same as nil slices.
+func TestClientDataIsZero(t *testing.T) {
+	if !(ClientData{}).IsZero() {
+		t.Fatalf("expected zero ClientData to be zero")
+	}
+	if !(ClientData{PersonaPieces: []PersonaPiece{}}).IsZero() {
+		t.Fatalf("expected ClientData with an empty slice to be zero")
+	}
+
+	typ := reflect.TypeOf(ClientData{})
+	for i := 0; i < typ.NumField(); i++ {
+		var data ClientData
+		field := reflect.ValueOf(&data).Elem().Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			field.SetInt(1)
+		case reflect.Float32, reflect.Float64:
+			field.SetFloat(1)
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		default:
+			t.Fatalf("unhandled kind %v of field %v", field.Kind(), typ.Field(i).Name)
+		}
+		if data.IsZero() {
+			t.Errorf("expected ClientData with %v set not to be zero", typ.Field(i).Name)
+		}
+	}
+}
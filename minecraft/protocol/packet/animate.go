@@ -27,7 +27,9 @@ type Animate struct {
 	// ID is unique for each world session, and entities are generally identified in packets using this
 	// runtime ID.
 	EntityRuntimeID uint64
-	// BoatRowingTime ...
+	// BoatRowingTime is the time that the paddle of the boat has been rowing for, which determines the
+	// position of the paddle in the animation. It is only sent for the AnimateActionRowRight and
+	// AnimateActionRowLeft actions.
 	BoatRowingTime float32
 }
 
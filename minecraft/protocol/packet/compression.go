@@ -27,6 +27,10 @@ var (
 	// SnappyCompression is the implementation of the Snappy compression
 	// algorithm. This is used by default.
 	SnappyCompression snappyCompression
+	// NopCompression is an implementation of Compression that leaves data
+	// uncompressed. It is used when a server disables compression using the
+	// CompressionAlgorithmNone algorithm in the NetworkSettings packet.
+	NopCompression nopCompression
 
 	DefaultCompression Compression = FlateCompression
 )
@@ -36,6 +40,8 @@ type (
 	flateCompression struct{}
 	// snappyCompression is the implementation of the Snappy compression algorithm. This is used by default.
 	snappyCompression struct{}
+	// nopCompression is an implementation of Compression that does not compress data at all.
+	nopCompression struct{}
 )
 
 // flateDecompressPool is a sync.Pool for io.ReadCloser flate readers. These are
@@ -84,6 +90,12 @@ func (flateCompression) Compress(decompressed []byte) ([]byte, error) {
 
 // Decompress ...
 func (flateCompression) Decompress(compressed []byte) ([]byte, error) {
+	return flateCompression{}.decompressLimit(compressed, -1)
+}
+
+// decompressLimit decompresses the data passed, stopping as soon as more than limit bytes are decompressed. If
+// limit is negative, the data is decompressed fully.
+func (flateCompression) decompressLimit(compressed []byte, limit int) ([]byte, error) {
 	buf := bytes.NewReader(compressed)
 	c := flateDecompressPool.Get().(io.ReadCloser)
 	defer flateDecompressPool.Put(c)
@@ -95,9 +107,16 @@ func (flateCompression) Decompress(compressed []byte) ([]byte, error) {
 
 	// Guess an uncompressed size of 2*len(compressed).
 	decompressed := bytes.NewBuffer(make([]byte, 0, len(compressed)*2))
-	if _, err := io.Copy(decompressed, c); err != nil {
+	var r io.Reader = c
+	if limit >= 0 {
+		r = io.LimitReader(c, int64(limit)+1)
+	}
+	if _, err := io.Copy(decompressed, r); err != nil {
 		return nil, fmt.Errorf("decompress flate: %v", err)
 	}
+	if limit >= 0 && decompressed.Len() > limit {
+		return nil, &PacketSizeError{Size: decompressed.Len(), MaxSize: limit}
+	}
 	return decompressed.Bytes(), nil
 }
 
@@ -127,10 +146,39 @@ func (snappyCompression) Decompress(compressed []byte) ([]byte, error) {
 	return decompressed, nil
 }
 
+// decompressLimit decompresses the data passed, returning a *PacketSizeError without decompressing if the
+// decoded length prefix exceeds limit.
+func (c snappyCompression) decompressLimit(compressed []byte, limit int) ([]byte, error) {
+	n, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompress snappy: %w", err)
+	}
+	if n > limit {
+		return nil, &PacketSizeError{Size: n, MaxSize: limit}
+	}
+	return c.Decompress(compressed)
+}
+
+// EncodeCompression ...
+func (nopCompression) EncodeCompression() uint16 {
+	return CompressionAlgorithmNone
+}
+
+// Compress ...
+func (nopCompression) Compress(decompressed []byte) ([]byte, error) {
+	return decompressed, nil
+}
+
+// Decompress ...
+func (nopCompression) Decompress(compressed []byte) ([]byte, error) {
+	return compressed, nil
+}
+
 // init registers all valid compressions with the protocol.
 func init() {
 	RegisterCompression(flateCompression{})
 	RegisterCompression(snappyCompression{})
+	RegisterCompression(nopCompression{})
 }
 
 var compressions = map[uint16]Compression{}
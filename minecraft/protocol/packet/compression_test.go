@@ -0,0 +1,99 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// benchmarkBatch returns a representative batch of packets as they would be passed to Compression.Compress
+// by an Encoder: A mix of small movement packets and larger text packets, each prefixed with its length.
+func benchmarkBatch() []byte {
+	buf := bytes.NewBuffer(nil)
+	payload := bytes.NewBuffer(nil)
+	for i := 0; i < 64; i++ {
+		payload.Reset()
+		var pk Packet = &MoveActorAbsolute{EntityRuntimeID: uint64(i)}
+		if i%8 == 0 {
+			pk = &Text{TextType: TextTypeChat, SourceName: "Steve", Message: "The quick brown fox jumps over the lazy dog."}
+		}
+		hdr := Header{PacketID: pk.ID()}
+		_ = hdr.Write(payload)
+		pk.Marshal(protocol.NewWriter(payload, 0))
+		_ = protocol.WriteVaruint32(buf, uint32(payload.Len()))
+		buf.Write(payload.Bytes())
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkFlateCompress(b *testing.B)    { benchmarkCompress(b, FlateCompression) }
+func BenchmarkSnappyCompress(b *testing.B)   { benchmarkCompress(b, SnappyCompression) }
+func BenchmarkNopCompress(b *testing.B)      { benchmarkCompress(b, NopCompression) }
+func BenchmarkFlateDecompress(b *testing.B)  { benchmarkDecompress(b, FlateCompression) }
+func BenchmarkSnappyDecompress(b *testing.B) { benchmarkDecompress(b, SnappyCompression) }
+func BenchmarkNopDecompress(b *testing.B)    { benchmarkDecompress(b, NopCompression) }
+
+// TestCompressionRoundTrip tests that a batch encoded by an Encoder using each registered compression is
+// decoded intact by a Decoder, which finds the compression by the ID prefixed to the batch.
+func TestCompressionRoundTrip(t *testing.T) {
+	for _, id := range []uint16{CompressionAlgorithmFlate, CompressionAlgorithmSnappy, CompressionAlgorithmNone} {
+		c, ok := CompressionByID(id)
+		if !ok {
+			t.Fatalf("compression %v not registered", id)
+		}
+		if c.EncodeCompression() != id {
+			t.Fatalf("compression registered as %v reports ID %v", id, c.EncodeCompression())
+		}
+		packets := [][]byte{bytes.Repeat([]byte{0x01}, 300), []byte("hello"), bytes.Repeat([]byte{0x02, 0x03}, 100)}
+
+		buf := bytes.NewBuffer(nil)
+		enc := NewEncoder(buf)
+		enc.EnableCompression(c)
+		if err := enc.Encode(packets); err != nil {
+			t.Fatalf("compression %v: encode: %v", id, err)
+		}
+		dec := NewDecoder(buf)
+		dec.EnableCompression()
+		decoded, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("compression %v: decode: %v", id, err)
+		}
+		if len(decoded) != len(packets) {
+			t.Fatalf("compression %v: expected %v packets, got %v", id, len(packets), len(decoded))
+		}
+		for i := range packets {
+			if !bytes.Equal(decoded[i], packets[i]) {
+				t.Fatalf("compression %v: packet %v changed after round trip", id, i)
+			}
+		}
+	}
+}
+
+// benchmarkCompress benchmarks compressing a representative batch using the Compression passed.
+func benchmarkCompress(b *testing.B, c Compression) {
+	data := benchmarkBatch()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Compress(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkDecompress benchmarks decompressing a representative batch using the Compression passed.
+func benchmarkDecompress(b *testing.B, c Compression) {
+	data := benchmarkBatch()
+	compressed, err := c.Compress(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Decompress(compressed); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
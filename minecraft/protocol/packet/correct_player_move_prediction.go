@@ -25,7 +25,7 @@ type CorrectPlayerMovePrediction struct {
 	// Tick is the tick of the movement which was corrected by this packet.
 	Tick uint64
 	// CorrectionType is the correction type send to the player. This influences how the rewind is performed
-	// on the client.
+	// on the client. CorrectionType is only present for protocol versions of v1.20.60 and higher.
 	CorrectionType byte
 }
 
@@ -39,5 +39,8 @@ func (pk *CorrectPlayerMovePrediction) Marshal(io protocol.IO) {
 	io.Vec3(&pk.Delta)
 	io.Bool(&pk.OnGround)
 	io.Varuint64(&pk.Tick)
-	io.Uint8(&pk.CorrectionType)
+	if protocol.VersionOf(io) >= 649 {
+		// The CorrectionType was added in v1.20.60.
+		io.Uint8(&pk.CorrectionType)
+	}
 }
@@ -0,0 +1,53 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TestCorrectPlayerMovePredictionVersion checks that the CorrectionType of a CorrectPlayerMovePrediction
+// packet is only written and read for protocol versions of v1.20.60 (649) and higher.
+func TestCorrectPlayerMovePredictionVersion(t *testing.T) {
+	pk := &CorrectPlayerMovePrediction{Position: mgl32.Vec3{1, 2, 3}, OnGround: true, Tick: 10, CorrectionType: CorrectionTypeVechile}
+	for _, test := range []struct {
+		version        int32
+		correctionType bool
+	}{
+		{version: 630, correctionType: false},
+		{version: 648, correctionType: false},
+		{version: 649, correctionType: true},
+		{version: protocol.CurrentProtocol, correctionType: true},
+	} {
+		buf := bytes.NewBuffer(nil)
+		w := protocol.NewWriter(buf, 0)
+		w.SetProtocolVersion(test.version)
+		pk.Marshal(w)
+		// Position (12), Delta (12), OnGround (1), Tick (1) and optionally CorrectionType (1).
+		expectedLen := 26
+		if test.correctionType {
+			expectedLen++
+		}
+		if buf.Len() != expectedLen {
+			t.Errorf("version %v: expected %v bytes, got %v", test.version, expectedLen, buf.Len())
+			continue
+		}
+
+		var decoded CorrectPlayerMovePrediction
+		r := protocol.NewReader(buf, 0, false)
+		r.SetProtocolVersion(test.version)
+		decoded.Marshal(r)
+		if buf.Len() != 0 {
+			t.Errorf("version %v: %v unread bytes left", test.version, buf.Len())
+		}
+		expected := *pk
+		if !test.correctionType {
+			expected.CorrectionType = 0
+		}
+		if decoded != expected {
+			t.Errorf("version %v: expected %+v, got %+v", test.version, expected, decoded)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"errors"
 	"fmt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"io"
@@ -25,6 +26,9 @@ type Decoder struct {
 	encrypt    *encrypt
 
 	checkPacketLimit bool
+	maxPacketSize    int
+	// batchSize is the size of the last batch read, as returned by BatchSize.
+	batchSize int
 }
 
 // packetReader is used to read packets immediately instead of copying them in a buffer first. This is a
@@ -33,16 +37,56 @@ type packetReader interface {
 	ReadPacket() ([]byte, error)
 }
 
+const (
+	// DefaultReadBufferSize is the size of the read buffer of a Decoder created using NewDecoder. It is large
+	// enough to hold any batch sent by a vanilla client or server.
+	DefaultReadBufferSize = 1024 * 1024 * 3
+	// MinReadBufferSize is the minimum size of the read buffer of a Decoder created using NewDecoderSize.
+	MinReadBufferSize = 4096
+	// DefaultMaxPacketSize is the default maximum size of a batch, both before and after decompression, that a
+	// Decoder accepts. It may be changed using Decoder.SetMaxPacketSize.
+	DefaultMaxPacketSize = 1024 * 1024 * 8
+)
+
+// PacketSizeError is returned by Decoder.Decode if a batch, or a packet held in it, exceeds the maximum packet
+// size of the Decoder.
+type PacketSizeError struct {
+	// Size is the size of the batch or packet. If decompression was stopped as soon as the maximum size was
+	// exceeded, Size is the amount of bytes decompressed at that point.
+	Size int
+	// MaxSize is the maximum packet size of the Decoder.
+	MaxSize int
+}
+
+// Error ...
+func (err *PacketSizeError) Error() string {
+	return fmt.Sprintf("packet size %v exceeds maximum of %v bytes", err.Size, err.MaxSize)
+}
+
 // NewDecoder returns a new decoder decoding data from the io.Reader passed. One read call from the reader is
 // assumed to consume an entire packet.
 func NewDecoder(reader io.Reader) *Decoder {
+	return NewDecoderSize(reader, DefaultReadBufferSize)
+}
+
+// NewDecoderSize returns a new decoder decoding data from the io.Reader passed, similarly to NewDecoder, but
+// with a read buffer of size bytes. Because one read call is assumed to consume an entire packet, the buffer
+// must be large enough to hold the largest batch that may be received. If size is smaller than
+// MinReadBufferSize, MinReadBufferSize is used instead.
+// If the io.Reader passed reads packets directly, as is the case for RakNet connections, no read buffer is
+// allocated and size is ignored.
+func NewDecoderSize(reader io.Reader, size int) *Decoder {
 	if pr, ok := reader.(packetReader); ok {
-		return &Decoder{checkPacketLimit: true, pr: pr}
+		return &Decoder{checkPacketLimit: true, maxPacketSize: DefaultMaxPacketSize, pr: pr}
+	}
+	if size < MinReadBufferSize {
+		size = MinReadBufferSize
 	}
 	return &Decoder{
 		r:                reader,
-		buf:              make([]byte, 1024*1024*3),
+		buf:              make([]byte, size),
 		checkPacketLimit: true,
+		maxPacketSize:    DefaultMaxPacketSize,
 	}
 }
 
@@ -66,6 +110,16 @@ func (decoder *Decoder) DisableBatchPacketLimit() {
 	decoder.checkPacketLimit = false
 }
 
+// SetMaxPacketSize sets the maximum size of a batch, both before and after decompression, and of the packets
+// held in it. Decode returns a *PacketSizeError for batches that exceed it, before allocating memory for the
+// decompressed data where possible. If n is 0 or lower, DefaultMaxPacketSize is used.
+func (decoder *Decoder) SetMaxPacketSize(n int) {
+	if n <= 0 {
+		n = DefaultMaxPacketSize
+	}
+	decoder.maxPacketSize = n
+}
+
 const (
 	// header is the header of compressed 'batches' from Minecraft.
 	header = 0xfe
@@ -86,11 +140,15 @@ func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 		data, err = decoder.pr.ReadPacket()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error reading batch from reader: %v", err)
+		return nil, fmt.Errorf("error reading batch from reader: %w", err)
 	}
+	decoder.batchSize = len(data)
 	if len(data) == 0 {
 		return nil, nil
 	}
+	if len(data) > decoder.maxPacketSize {
+		return nil, &PacketSizeError{Size: len(data), MaxSize: decoder.maxPacketSize}
+	}
 	if data[0] != header {
 		return nil, fmt.Errorf("error reading packet: invalid packet header %x: expected %x", data[0], header)
 	}
@@ -105,16 +163,21 @@ func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 	}
 
 	if decoder.decompress {
+		if len(data) == 0 {
+			return nil, fmt.Errorf("error decompressing packet: missing compression algorithm")
+		}
 		if data[0] == 0xff {
+			// The batch was not compressed, either because compression is disabled or because the batch
+			// was smaller than the compression threshold.
 			data = data[1:]
 		} else {
 			compression, ok := CompressionByID(uint16(data[0]))
 			if !ok {
 				return nil, fmt.Errorf("error decompressing packet: unknown compression algorithm %v", data[0])
 			}
-			data, err = compression.Decompress(data[1:])
+			data, err = decompressLimit(compression, data[1:], decoder.maxPacketSize)
 			if err != nil {
-				return nil, fmt.Errorf("error decompressing packet: %v", err)
+				return nil, err
 			}
 		}
 	}
@@ -125,6 +188,9 @@ func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 		if err := protocol.Varuint32(b, &length); err != nil {
 			return nil, fmt.Errorf("error reading packet length: %v", err)
 		}
+		if int64(length) > int64(decoder.maxPacketSize) {
+			return nil, &PacketSizeError{Size: int(length), MaxSize: decoder.maxPacketSize}
+		}
 		packets = append(packets, b.Next(int(length)))
 	}
 	if len(packets) > maximumInBatch && decoder.checkPacketLimit {
@@ -132,3 +198,40 @@ func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 	}
 	return packets, nil
 }
+
+// BatchSize returns the size in bytes of the last batch read by Decode, as it was read from the io.Reader:
+// compressed and encrypted if enabled.
+func (decoder *Decoder) BatchSize() int {
+	return decoder.batchSize
+}
+
+// limitDecompressor is implemented by Compressions that are able to stop decompressing as soon as the
+// decompressed data exceeds a limit.
+type limitDecompressor interface {
+	decompressLimit(compressed []byte, limit int) ([]byte, error)
+}
+
+// decompressLimit decompresses the data passed using the Compression passed, returning a *PacketSizeError if
+// the decompressed data exceeds limit bytes.
+func decompressLimit(compression Compression, compressed []byte, limit int) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if c, ok := compression.(limitDecompressor); ok {
+		data, err = c.decompressLimit(compressed, limit)
+	} else {
+		data, err = compression.Decompress(compressed)
+	}
+	if err != nil {
+		var sizeErr *PacketSizeError
+		if errors.As(err, &sizeErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error decompressing packet: %v", err)
+	}
+	if len(data) > limit {
+		return nil, &PacketSizeError{Size: len(data), MaxSize: limit}
+	}
+	return data, nil
+}
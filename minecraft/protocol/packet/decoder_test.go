@@ -0,0 +1,48 @@
+package packet
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TestDecoderMaxPacketSize tests that a Decoder rejects batches that hold a packet with a length prefix
+// exceeding its maximum packet size, as well as batches that decompress to more than the maximum size.
+func TestDecoderMaxPacketSize(t *testing.T) {
+	const maxSize = MinReadBufferSize
+
+	lengthPrefix := bytes.NewBuffer([]byte{header})
+	_ = protocol.WriteVaruint32(lengthPrefix, 1<<31)
+
+	compressed, err := FlateCompression.Compress(make([]byte, maxSize*4))
+	if err != nil {
+		t.Fatalf("error compressing batch: %v", err)
+	}
+	flateBomb := append([]byte{header, byte(CompressionAlgorithmFlate)}, compressed...)
+
+	compressed, err = SnappyCompression.Compress(make([]byte, maxSize*4))
+	if err != nil {
+		t.Fatalf("error compressing batch: %v", err)
+	}
+	snappyBomb := append([]byte{header, byte(CompressionAlgorithmSnappy)}, compressed...)
+
+	for name, batch := range map[string][]byte{"length prefix": lengthPrefix.Bytes(), "flate": flateBomb, "snappy": snappyBomb} {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoderSize(bytes.NewReader(batch), maxSize)
+			dec.SetMaxPacketSize(maxSize)
+			if name != "length prefix" {
+				dec.EnableCompression()
+			}
+			_, err := dec.Decode()
+			var sizeErr *PacketSizeError
+			if !errors.As(err, &sizeErr) {
+				t.Fatalf("expected *PacketSizeError, got %v", err)
+			}
+			if sizeErr.MaxSize != maxSize {
+				t.Errorf("expected max size %v, got %v", maxSize, sizeErr.MaxSize)
+			}
+		})
+	}
+}
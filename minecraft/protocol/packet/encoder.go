@@ -13,9 +13,15 @@ import (
 // and optionally encoded before they are sent to the io.Writer.
 type Encoder struct {
 	w io.Writer
+	// buf is the buffer that packets are written to before compression. If nil, a buffer is taken from
+	// internal.BufferPool for every call to Encode.
+	buf *bytes.Buffer
 
 	compression Compression
+	threshold   int
 	encrypt     *encrypt
+	// batchSize is the size of the last batch written, as returned by BatchSize.
+	batchSize int
 }
 
 // NewEncoder returns a new Encoder for the io.Writer passed. Each final packet produced by the Encoder is
@@ -26,6 +32,24 @@ func NewEncoder(w io.Writer) *Encoder {
 	}
 }
 
+// MinWriteBufferSize is the minimum size of the write buffer of an Encoder created using NewEncoderSize.
+const MinWriteBufferSize = 4096
+
+// NewEncoderSize returns a new Encoder for the io.Writer passed, similarly to NewEncoder. Unlike NewEncoder, the
+// Encoder returned keeps its own write buffer with an initial capacity of size bytes, rather than taking one
+// from a shared pool for every batch. This avoids repeatedly growing buffers for connections that send large
+// batches. If size is smaller than MinWriteBufferSize, MinWriteBufferSize is used instead.
+// The Encoder returned must not be used concurrently.
+func NewEncoderSize(w io.Writer, size int) *Encoder {
+	if size < MinWriteBufferSize {
+		size = MinWriteBufferSize
+	}
+	return &Encoder{
+		w:   w,
+		buf: bytes.NewBuffer(make([]byte, 0, size)),
+	}
+}
+
 // EnableEncryption enables encryption for the Encoder using the secret key bytes passed. Each packet sent
 // after encryption is enabled will be encrypted.
 func (encoder *Encoder) EnableEncryption(keyBytes [32]byte) {
@@ -40,15 +64,27 @@ func (encoder *Encoder) EnableCompression(compression Compression) {
 	encoder.compression = compression
 }
 
+// SetCompressionThreshold sets the minimum size in bytes of a batch of packets for it to be compressed. Batches
+// smaller than the threshold are sent uncompressed, as if NopCompression was used. By default, the threshold
+// is 0, meaning all batches are compressed.
+func (encoder *Encoder) SetCompressionThreshold(threshold int) {
+	encoder.threshold = threshold
+}
+
 // Encode encodes the packets passed. It writes all of them as a single packet which is  compressed and
 // optionally encrypted.
 func (encoder *Encoder) Encode(packets [][]byte) error {
-	buf := internal.BufferPool.Get().(*bytes.Buffer)
-	defer func() {
-		// Reset the buffer, so we can return it to the buffer pool safely.
-		buf.Reset()
-		internal.BufferPool.Put(buf)
-	}()
+	buf := encoder.buf
+	if buf == nil {
+		buf = internal.BufferPool.Get().(*bytes.Buffer)
+		defer func() {
+			// Reset the buffer, so we can return it to the buffer pool safely.
+			buf.Reset()
+			internal.BufferPool.Put(buf)
+		}()
+	} else {
+		defer buf.Reset()
+	}
 
 	l := make([]byte, 5)
 	for _, packet := range packets {
@@ -64,9 +100,13 @@ func (encoder *Encoder) Encode(packets [][]byte) error {
 	data := buf.Bytes()
 	prepend := []byte{header}
 	if encoder.compression != nil {
-		prepend = append(prepend, byte(encoder.compression.EncodeCompression()))
+		compression := encoder.compression
+		if len(data) < encoder.threshold {
+			compression = NopCompression
+		}
+		prepend = append(prepend, byte(compression.EncodeCompression()))
 		var err error
-		data, err = encoder.compression.Compress(data)
+		data, err = compression.Compress(data)
 		if err != nil {
 			return fmt.Errorf("error compressing packet: %v", err)
 		}
@@ -78,12 +118,19 @@ func (encoder *Encoder) Encode(packets [][]byte) error {
 		// compressed data of this packet.
 		data = encoder.encrypt.encrypt(data)
 	}
+	encoder.batchSize = len(data)
 	if _, err := encoder.w.Write(data); err != nil {
 		return fmt.Errorf("error writing compressed packet to io.Writer: %v", err)
 	}
 	return nil
 }
 
+// BatchSize returns the size in bytes of the last batch encoded by Encode, as it was written to the
+// io.Writer: compressed and encrypted if enabled.
+func (encoder *Encoder) BatchSize() int {
+	return encoder.batchSize
+}
+
 // writeVaruint32 writes a uint32 to the destination buffer passed with a size of 1-5 bytes. It uses byte
 // slice b in order to prevent allocations.
 func writeVaruint32(dst io.Writer, x uint32, b []byte) error {
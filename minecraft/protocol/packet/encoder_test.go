@@ -0,0 +1,36 @@
+package packet
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// BenchmarkEncoderBufferSize benchmarks encoding batches of packets using Encoders with different write
+// buffer sizes, including the default pooled buffer of NewEncoder.
+func BenchmarkEncoderBufferSize(b *testing.B) {
+	batch := make([][]byte, 256)
+	for i := range batch {
+		batch[i] = make([]byte, 512)
+	}
+	b.Run("pooled", func(b *testing.B) {
+		benchmarkEncode(b, NewEncoder(io.Discard), batch)
+	})
+	for _, size := range []int{MinWriteBufferSize, 64 * 1024, 256 * 1024} {
+		b.Run(fmt.Sprintf("size=%v", size), func(b *testing.B) {
+			benchmarkEncode(b, NewEncoderSize(io.Discard, size), batch)
+		})
+	}
+}
+
+// benchmarkEncode benchmarks encoding the batch passed using the Encoder passed.
+func benchmarkEncode(b *testing.B, enc *Encoder, batch [][]byte) {
+	enc.EnableCompression(SnappyCompression)
+	b.SetBytes(int64(len(batch) * len(batch[0])))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := enc.Encode(batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
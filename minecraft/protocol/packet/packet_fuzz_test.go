@@ -0,0 +1,63 @@
+package packet
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// FuzzPacketDecode decodes arbitrary payloads for every packet registered in the client and server pools and
+// checks that malformed input is rejected by the protocol.Reader with an error, rather than resulting in a
+// runtime panic such as an out of range index or a nil pointer dereference.
+func FuzzPacketDecode(f *testing.F) {
+	ids, pool := fuzzPool()
+	for i, id := range ids {
+		// Seed the corpus with the encoding of every packet in its zero state, if it can be encoded.
+		if payload, ok := zeroPayload(pool[id]()); ok {
+			f.Add(uint32(i), payload)
+		}
+	}
+	f.Fuzz(func(t *testing.T, index uint32, payload []byte) {
+		id := ids[int(index%uint32(len(ids)))]
+		pk := pool[id]()
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(runtime.Error); ok {
+					t.Fatalf("decoding %T (0x%x) panicked: %v", pk, payload, r)
+				}
+			}
+		}()
+		pk.Marshal(protocol.NewReader(bytes.NewBuffer(payload), 0, true))
+	})
+}
+
+// fuzzPool returns a Pool holding all packets that may be sent by either a client or a server, together with
+// a sorted slice of their IDs.
+func fuzzPool() ([]uint32, Pool) {
+	pool := NewServerPool()
+	for id, pk := range NewClientPool() {
+		pool[id] = pk
+	}
+	ids := make([]uint32, 0, len(pool))
+	for id := range pool {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, pool
+}
+
+// zeroPayload encodes the packet passed and returns its payload. If the packet cannot be encoded in its
+// current state, false is returned.
+func zeroPayload(pk Packet) (payload []byte, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	buf := bytes.NewBuffer(nil)
+	pk.Marshal(protocol.NewWriter(buf, 0))
+	return buf.Bytes(), true
+}
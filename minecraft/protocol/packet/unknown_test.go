@@ -0,0 +1,33 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TestUnknownRoundTrip checks that the payload of a packet with an ID not present in any Pool is decoded
+// into an Unknown packet and encoded again without modification.
+func TestUnknownRoundTrip(t *testing.T) {
+	const id = 0x3ff
+	if _, ok := NewServerPool()[id]; ok {
+		t.Fatalf("packet ID 0x%x is registered, but the test requires it to be unknown", id)
+	}
+	payload := []byte{0x01, 0x02, 0xfe, 0xff, 0x00}
+
+	pk := &Unknown{PacketID: id}
+	pk.Marshal(protocol.NewReader(bytes.NewBuffer(payload), 0, true))
+	if pk.ID() != id {
+		t.Fatalf("expected ID 0x%x, got 0x%x", id, pk.ID())
+	}
+	if !bytes.Equal(pk.Payload, payload) {
+		t.Fatalf("expected payload 0x%x, got 0x%x", payload, pk.Payload)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	pk.Marshal(protocol.NewWriter(buf, 0))
+	if !bytes.Equal(buf.Bytes(), payload) {
+		t.Fatalf("expected encoded payload 0x%x, got 0x%x", payload, buf.Bytes())
+	}
+}
@@ -23,6 +23,7 @@ type Reader struct {
 	}
 	shieldID      int32
 	limitsEnabled bool
+	protocol      int32
 }
 
 // NewReader creates a new Reader using the io.ByteReader passed as underlying source to read bytes from.
@@ -613,6 +614,21 @@ func (r *Reader) ShieldID() int32 {
 	return r.shieldID
 }
 
+// SetProtocolVersion sets the protocol version that packets read using the Reader were written for. By
+// default, CurrentProtocol is assumed.
+func (r *Reader) SetProtocolVersion(v int32) {
+	r.protocol = v
+}
+
+// ProtocolVersion returns the protocol version set using SetProtocolVersion, or CurrentProtocol if none was
+// set.
+func (r *Reader) ProtocolVersion() int32 {
+	if r.protocol == 0 {
+		return CurrentProtocol
+	}
+	return r.protocol
+}
+
 // UnknownEnumOption panics with an unknown enum option error.
 func (r *Reader) UnknownEnumOption(value any, enum string) {
 	r.panicf("unknown value '%v' for enum type '%v'", value, enum)
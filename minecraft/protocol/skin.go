@@ -95,7 +95,7 @@ func (x *Skin) Marshal(r IO) {
 	r.String(&x.SkinColour)
 	SliceUint32Length(r, &x.PersonaPieces)
 	SliceUint32Length(r, &x.PieceTintColours)
-	if err := x.validate(); err != nil {
+	if err := x.Validate(); err != nil {
 		r.InvalidValue(fmt.Sprintf("Skin %v", x.SkinID), "serialised skin", err.Error())
 	}
 	r.Bool(&x.PremiumSkin)
@@ -105,9 +105,9 @@ func (x *Skin) Marshal(r IO) {
 	r.Bool(&x.OverrideAppearance)
 }
 
-// validate checks the skin and makes sure every one of its values are correct. It checks the image dimensions
+// Validate checks the skin and makes sure every one of its values are correct. It checks the image dimensions
 // and makes sure they match the image size of the skin, cape and the skin's animations.
-func (x Skin) validate() error {
+func (x Skin) Validate() error {
 	if x.SkinImageHeight*x.SkinImageWidth*4 != uint32(len(x.SkinData)) {
 		return fmt.Errorf("expected size of skin is %vx%v (%v bytes total), but got %v bytes", x.SkinImageWidth, x.SkinImageHeight, x.SkinImageHeight*x.SkinImageWidth*4, len(x.SkinData))
 	}
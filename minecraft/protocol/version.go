@@ -0,0 +1,113 @@
+package protocol
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionNumber is a parsed Minecraft version string such as "1.20.70" or "1.21.0-beta.2". Version strings
+// of any number of segments are supported, as Minecraft uses both three and four segment versions.
+type VersionNumber struct {
+	// Segments holds the numeric segments of the version, such as [1 20 70] for "1.20.70".
+	Segments []int
+	// PreRelease is the pre-release suffix of the version without the leading '-', such as "beta.2" for
+	// "1.21.0-beta.2". It is empty for release versions.
+	PreRelease string
+}
+
+// ParseVersion parses a version string such as "1.20.70" into a VersionNumber. The numeric segments are
+// separated by a dot and may be followed by a pre-release suffix starting with a '-'. Build metadata starting
+// with a '+' is ignored. An error is returned if a segment is not a non-negative number.
+func ParseVersion(s string) (VersionNumber, error) {
+	var v VersionNumber
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "+")
+	s, v.PreRelease, _ = strings.Cut(s, "-")
+	if s == "" {
+		return v, fmt.Errorf("parse version: empty version")
+	}
+	for _, seg := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(seg)
+		if err != nil || n < 0 {
+			return VersionNumber{}, fmt.Errorf("parse version %q: invalid segment %q", s, seg)
+		}
+		v.Segments = append(v.Segments, n)
+	}
+	return v, nil
+}
+
+// String returns the version in the format accepted by ParseVersion.
+func (v VersionNumber) String() string {
+	segments := make([]string, len(v.Segments))
+	for i, n := range v.Segments {
+		segments[i] = strconv.Itoa(n)
+	}
+	s := strings.Join(segments, ".")
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare compares the version with the version passed. It returns -1 if v is lower than other, 0 if they are
+// equal and 1 if v is higher than other. Missing segments count as 0, so that "1.12" and "1.12.0" are equal.
+// A pre-release version is lower than the release of the same version, and pre-release suffixes are compared
+// like in semantic versioning: "beta.2" is lower than "beta.10", which is lower than "rc.1".
+func (v VersionNumber) Compare(other VersionNumber) int {
+	for i := 0; i < max(len(v.Segments), len(other.Segments)); i++ {
+		var a, b int
+		if i < len(v.Segments) {
+			a = v.Segments[i]
+		}
+		if i < len(other.Segments) {
+			b = other.Segments[i]
+		}
+		if a != b {
+			return cmp.Compare(a, b)
+		}
+	}
+	switch {
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	}
+	a, b := strings.Split(v.PreRelease, "."), strings.Split(other.PreRelease, ".")
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if c := comparePreRelease(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// CompareVersions parses and compares the version strings passed using VersionNumber.Compare. It returns -1
+// if a is lower than b, 0 if they are equal and 1 if a is higher than b. If either of the versions cannot be
+// parsed, the strings themselves are compared instead.
+func CompareVersions(a, b string) int {
+	va, errA := ParseVersion(a)
+	vb, errB := ParseVersion(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return va.Compare(vb)
+}
+
+// comparePreRelease compares two identifiers of a pre-release suffix. Numeric identifiers are compared
+// numerically and are lower than non-numeric identifiers, which are compared lexically.
+func comparePreRelease(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.12.0", "1.12.0", 0},
+		{"1.12", "1.12.0", 0},
+		{"1.12.0.0", "1.12", 0},
+		{"1.12.0", "1.12.1", -1},
+		{"1.12.1", "1.12", 1},
+		{"1.9.0", "1.12.0", -1},
+		{"1.20.70", "1.20.7", 1},
+		{"1.21.0-beta.1", "1.21.0", -1},
+		{"1.21.0", "1.21.0-beta.1", 1},
+		{"1.21.0-beta.2", "1.21.0-beta.10", -1},
+		{"1.21.0-beta", "1.21.0-beta.1", -1},
+		{"1.21.0-1", "1.21.0-beta", -1},
+		{"1.21.0-beta.1", "1.21.0-rc.1", -1},
+		{"1.21.0-beta.1", "1.20.80", 1},
+		{"1.20.70+build.5", "1.20.70", 0},
+		{" 1.2.3 ", "1.2.3", 0},
+	}
+	for _, test := range tests {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q): expected %v, got %v", test.a, test.b, test.want, got)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, s := range []string{"", "1..2", "1.a.0", "1.-2.0", "-beta"} {
+		if v, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q): expected error, got %v", s, v)
+		}
+	}
+	v, err := ParseVersion("1.21.0-beta.2")
+	if err != nil {
+		t.Fatalf("ParseVersion: %v", err)
+	}
+	if s := v.String(); s != "1.21.0-beta.2" {
+		t.Fatalf("expected version 1.21.0-beta.2, got %v", s)
+	}
+}
@@ -23,6 +23,7 @@ type Writer struct {
 		io.ByteWriter
 	}
 	shieldID int32
+	protocol int32
 }
 
 // NewWriter creates a new initialised Writer with an underlying io.ByteWriter to write to.
@@ -537,6 +538,21 @@ func (w *Writer) ShieldID() int32 {
 	return w.shieldID
 }
 
+// SetProtocolVersion sets the protocol version that packets written using the Writer are written for. By
+// default, CurrentProtocol is assumed.
+func (w *Writer) SetProtocolVersion(v int32) {
+	w.protocol = v
+}
+
+// ProtocolVersion returns the protocol version set using SetProtocolVersion, or CurrentProtocol if none was
+// set.
+func (w *Writer) ProtocolVersion() int32 {
+	if w.protocol == 0 {
+		return CurrentProtocol
+	}
+	return w.protocol
+}
+
 // UnknownEnumOption panics with an unknown enum option error.
 func (w *Writer) UnknownEnumOption(value any, enum string) {
 	w.panicf("unknown value '%v' for enum type '%v'", value, enum)
@@ -0,0 +1,74 @@
+package minecraft
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// legacyProto is a Protocol for an older protocol version that uses the same packets as the latest protocol.
+type legacyProto struct {
+	proto
+	id int32
+}
+
+func (p legacyProto) ID() int32 { return p.id }
+
+// TestConnProtocolVersion tests that packets written and read by a Conn are encoded for the protocol version
+// of its Protocol, omitting the CorrectionType of CorrectPlayerMovePrediction before v1.20.60 (649).
+func TestConnProtocolVersion(t *testing.T) {
+	for _, test := range []struct {
+		id             int32
+		correctionType bool
+	}{
+		{id: 630, correctionType: false},
+		{id: 649, correctionType: true},
+		{id: DefaultProtocol.ID(), correctionType: true},
+	} {
+		c, other := net.Pipe()
+		conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), legacyProto{id: test.id}, time.Millisecond, 0, 0, false)
+		conn.pool = conn.proto.Packets(false)
+
+		pk := &packet.CorrectPlayerMovePrediction{Position: mgl32.Vec3{1, 2, 3}, Tick: 10, CorrectionType: packet.CorrectionTypeVechile}
+		if err := conn.WritePacket(pk); err != nil {
+			t.Fatalf("protocol %v: error writing packet: %v", test.id, err)
+		}
+		batch, err := packet.NewDecoder(other).Decode()
+		if err != nil {
+			t.Fatalf("protocol %v: error decoding batch: %v", test.id, err)
+		}
+		_ = conn.Close()
+		_ = other.Close()
+
+		// Position (12), Delta (12), OnGround (1), Tick (1) and optionally CorrectionType (1), preceded by
+		// the header (2).
+		expectedLen := 28
+		if test.correctionType {
+			expectedLen++
+		}
+		if len(batch) != 1 || len(batch[0]) != expectedLen {
+			t.Errorf("protocol %v: expected packet of %v bytes, got %v", test.id, expectedLen, batch)
+			continue
+		}
+
+		data, err := parseData(batch[0], conn)
+		if err != nil {
+			t.Fatalf("protocol %v: error parsing packet: %v", test.id, err)
+		}
+		pks, err := data.decode(conn)
+		if err != nil || len(pks) != 1 {
+			t.Fatalf("protocol %v: error decoding packet: %v", test.id, err)
+		}
+		expected := *pk
+		if !test.correctionType {
+			expected.CorrectionType = 0
+		}
+		if decoded := pks[0].(*packet.CorrectPlayerMovePrediction); *decoded != expected {
+			t.Errorf("protocol %v: expected %+v, got %+v", test.id, expected, *decoded)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package minecraft
+
+import (
+	"errors"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Proxy forwards packets between the client and server Conns passed, until either of the two is closed. The
+// client Conn is typically obtained using a Listener, and the server Conn using a Dialer. Both connections
+// must be spawned (using Conn.StartGame and Conn.DoSpawn respectively) before Proxy is called.
+//
+// If filter is non-nil, it is called for every packet read from either connection before it is forwarded.
+// toServer is true if the packet was sent by the client and is about to be forwarded to the server. The
+// packet returned by filter is forwarded in place of the one passed, and if the bool returned is false, the
+// packet is dropped instead.
+//
+// Packets are always converted to the latest protocol when read and from the latest protocol when written,
+// so the two Conns may use different Protocols. Runtime IDs (such as those of items and blocks) are not
+// translated, however, so the client Conn should be started using the GameData of the server Conn.
+//
+// Proxy closes both connections before returning. If the server disconnected the client using a
+// packet.Disconnect, the same message is sent to the client. The error that caused either connection to be
+// closed is returned.
+func Proxy(client, server *Conn, filter func(pk packet.Packet, toServer bool) (packet.Packet, bool)) error {
+	return proxy(client, server, func(src, dst *Conn, toServer bool) error {
+		return forward(src, dst, filter, toServer)
+	})
+}
+
+// ProxyRaw forwards packets between the client and server Conns passed like Proxy, but only decodes the
+// packets for which decode returns true. These packets are passed to filter, if non-nil, as in Proxy. All
+// other packets are forwarded as the raw bytes received, after decryption and decompression, without being
+// decoded and encoded again. This preserves fields that are not modelled by the packet implementations
+// exactly and avoids the cost of decoding packets that the proxy has no interest in. If decode is nil, no
+// packets are decoded at all.
+//
+// Packets can only be forwarded without being decoded if both Conns use the same Protocol. If they don't,
+// every packet is decoded and converted as in Proxy. Packets that are not present in the packet pool of the
+// Conn they are read from are passed to filter as a *packet.Unknown if decoded, provided the Conn allows
+// unknown packets.
+//
+// Note that packets forwarded without being decoded are not observed by the Conn they are read from, so that
+// state maintained by the Conn, such as its scoreboards and world time, is not updated for these packets.
+func ProxyRaw(client, server *Conn, decode func(id uint32, toServer bool) bool, filter func(pk packet.Packet, toServer bool) (packet.Packet, bool)) error {
+	return proxy(client, server, func(src, dst *Conn, toServer bool) error {
+		return forwardRaw(src, dst, decode, filter, toServer)
+	})
+}
+
+// proxy runs the forward function passed in both directions between the client and server Conns passed, until
+// either returns an error. Both connections are closed before proxy returns.
+func proxy(client, server *Conn, forward func(src, dst *Conn, toServer bool) error) error {
+	errs := make(chan error, 2)
+	go func() {
+		errs <- forward(client, server, true)
+	}()
+	go func() {
+		errs <- forward(server, client, false)
+	}()
+	err := <-errs
+
+	var disconnect DisconnectError
+	if errors.As(err, &disconnect) {
+		_ = client.WritePacket(&packet.Disconnect{Message: disconnect.Error()})
+	}
+	_ = client.Close()
+	_ = server.Close()
+	<-errs
+	return err
+}
+
+// forward reads packets from src and writes them to dst until an error occurs while reading or writing. The
+// filter passed is applied to every packet if non-nil.
+func forward(src, dst *Conn, filter func(pk packet.Packet, toServer bool) (packet.Packet, bool), toServer bool) error {
+	for {
+		pk, err := src.ReadPacket()
+		if err != nil {
+			return err
+		}
+		if err := forwardPacket(dst, pk, filter, toServer); err != nil {
+			return err
+		}
+	}
+}
+
+// forwardRaw reads packets from src and writes them to dst until an error occurs while reading or writing.
+// Only packets for which decode returns true are decoded and passed to filter. Other packets are written to
+// dst without being decoded, unless src and dst use different protocols.
+func forwardRaw(src, dst *Conn, decode func(id uint32, toServer bool) bool, filter func(pk packet.Packet, toServer bool) (packet.Packet, bool), toServer bool) error {
+	sameProtocol := src.proto.ID() == dst.proto.ID()
+	for {
+		// Packets resulting from the conversion of a packet read before ProxyRaw was called must be forwarded
+		// first.
+		for len(src.additional) > 0 {
+			pk := <-src.additional
+			src.observePacket(pk)
+			if err := forwardPacket(dst, pk, filter, toServer); err != nil {
+				return err
+			}
+		}
+		data, err := src.readPacketData("proxy")
+		if err != nil {
+			return err
+		}
+		if sameProtocol && (decode == nil || !decode(data.h.PacketID, toServer)) {
+			if err := dst.WriteRaw(data.h.PacketID, data.payload.Bytes()); err != nil {
+				return err
+			}
+			continue
+		}
+		pks, err := data.decode(src)
+		if err != nil {
+			src.log.Error("decode packet", "id", data.h.PacketID, "err", err)
+			continue
+		}
+		for _, pk := range pks {
+			src.observePacket(pk)
+			if err := forwardPacket(dst, pk, filter, toServer); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forwardPacket writes the packet passed to dst after applying the filter passed to it, if non-nil.
+func forwardPacket(dst *Conn, pk packet.Packet, filter func(pk packet.Packet, toServer bool) (packet.Packet, bool), toServer bool) error {
+	if filter != nil {
+		var ok bool
+		if pk, ok = filter(pk, toServer); !ok {
+			return nil
+		}
+	}
+	return dst.WritePacket(pk)
+}
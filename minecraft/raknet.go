@@ -2,16 +2,33 @@ package minecraft
 
 import (
 	"context"
+	"encoding/binary"
 	"github.com/sandertv/go-raknet"
 	"net"
 )
 
 // RakNet is an implementation of a RakNet v10 Network.
-type RakNet struct{}
+type RakNet struct {
+	// ProtocolVersion is the RakNet protocol version sent when dialing a server, which is unrelated to the
+	// protocol version of Minecraft. Servers generally refuse connections with a RakNet protocol version other
+	// than their own. If ProtocolVersion is 0, the version of go-raknet is used, which is the one of current
+	// Minecraft versions. To dial servers with a different RakNet protocol version, a RakNet with a
+	// ProtocolVersion set may be registered using RegisterNetwork under a different ID, which is then passed
+	// to Dialer.Dial.
+	ProtocolVersion byte
+}
 
 // DialContext ...
 func (r RakNet) DialContext(ctx context.Context, address string) (net.Conn, error) {
-	return raknet.DialContext(ctx, address)
+	if r.ProtocolVersion == 0 {
+		return raknet.DialContext(ctx, address)
+	}
+	conn, err := raknet.Dialer{UpstreamDialer: protocolVersionDialer{version: r.ProtocolVersion}}.DialContext(ctx, address)
+	if err != nil {
+		// Return an untyped nil so that the net.Conn returned is nil too.
+		return nil, err
+	}
+	return conn, nil
 }
 
 // PingContext ...
@@ -24,6 +41,111 @@ func (r RakNet) Listen(address string) (NetworkListener, error) {
 	return raknet.Listen(address)
 }
 
+// ListenServerID listens on the address passed like Listen, but makes the listener use the server ID passed
+// instead of a random one. The ID is sent in the pongs of the listener and during the RakNet connection
+// sequence, and is returned by NetworkListener.ID. If id is 0, the listener is created like Listen, with a
+// random ID.
+func (r RakNet) ListenServerID(address string, id int64) (NetworkListener, error) {
+	if id == 0 {
+		return r.Listen(address)
+	}
+	pc, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: "raknet", Err: err}
+	}
+	l, err := raknet.ListenConfig{UpstreamPacketListener: serverIDPacketListener{conn: &serverIDConn{PacketConn: pc, id: id}}}.Listen(address)
+	if err != nil {
+		_ = pc.Close()
+		return nil, err
+	}
+	return serverIDListener{Listener: l, id: id}, nil
+}
+
+// serverIDListener is a raknet.Listener of which the server ID was replaced.
+type serverIDListener struct {
+	*raknet.Listener
+	id int64
+}
+
+// ID returns the server ID that the listener was created with.
+func (l serverIDListener) ID() int64 {
+	return l.id
+}
+
+// serverIDPacketListener is a raknet.UpstreamPacketListener that returns a net.PacketConn that was already
+// opened.
+type serverIDPacketListener struct {
+	conn *serverIDConn
+}
+
+// ListenPacket returns the net.PacketConn of the serverIDPacketListener.
+func (l serverIDPacketListener) ListenPacket(string, string) (net.PacketConn, error) {
+	return l.conn, nil
+}
+
+// serverIDConn is a net.PacketConn that replaces the server ID in the offline messages written to it by a
+// raknet.Listener.
+type serverIDConn struct {
+	net.PacketConn
+	id int64
+}
+
+// WriteTo writes the datagram passed to the address passed, replacing the server ID if the datagram is an
+// unconnected pong (0x1c), in which it follows the ID and timestamp, an open connection reply 1 or 2 (0x06,
+// 0x08), in which it follows the ID and 16 magic bytes, or an incompatible protocol version message (0x19), in
+// which it follows the ID, protocol version and 16 magic bytes.
+func (conn *serverIDConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	offset := 0
+	if len(b) > 0 {
+		switch b[0] {
+		case 0x1c:
+			offset = 9
+		case 0x06, 0x08:
+			offset = 17
+		case 0x19:
+			offset = 18
+		}
+	}
+	if offset != 0 && len(b) >= offset+8 {
+		b = append([]byte(nil), b...)
+		binary.BigEndian.PutUint64(b[offset:], uint64(conn.id))
+	}
+	return conn.PacketConn.WriteTo(b, addr)
+}
+
+// protocolVersionDialer is a raknet.UpstreamDialer that dials UDP connections which replace the RakNet
+// protocol version in the open connection requests sent by go-raknet.
+type protocolVersionDialer struct {
+	version byte
+}
+
+// Dial dials a UDP connection to the address passed.
+func (d protocolVersionDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &protocolVersionConn{UDPConn: conn.(*net.UDPConn), version: d.version}, nil
+}
+
+// protocolVersionConn is a UDP connection that sets the RakNet protocol version of open connection requests
+// written to it.
+type protocolVersionConn struct {
+	*net.UDPConn
+	version byte
+}
+
+// Write writes the datagram passed to the connection, replacing the protocol version if the datagram is an
+// open connection request 1. This request consists of the ID 0x05 and 16 magic bytes, followed by the
+// protocol version.
+func (conn *protocolVersionConn) Write(b []byte) (int, error) {
+	if len(b) > 17 && b[0] == 0x05 {
+		b = append([]byte(nil), b...)
+		b[17] = conn.version
+	}
+	return conn.UDPConn.Write(b)
+}
+
 // init registers the RakNet network.
 func init() {
 	RegisterNetwork("raknet", RakNet{})
@@ -0,0 +1,114 @@
+package minecraft
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestListenerServerID tests that a Listener sends the ListenConfig.ServerID in the RakNet header of its pongs
+// and open connection replies as well as in the pong data, and that a random ID is used if it is not set.
+func TestListenerServerID(t *testing.T) {
+	l, err := ListenConfig{ServerID: 12345}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	if guid, pong := pingServerID(t, l); guid != 12345 || pong.ServerID != 12345 {
+		t.Fatalf("expected server ID 12345, got %v in header and %v in pong", guid, pong.ServerID)
+	}
+	if guid := openConnectionServerID(t, l); guid != 12345 {
+		t.Fatalf("expected server ID 12345 in open connection reply, got %v", guid)
+	}
+	_ = l.Close()
+
+	l, err = Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+	if _, ok := l.listener.(serverIDListener); ok {
+		t.Fatalf("expected listener without ServerID not to replace the server ID")
+	}
+	guid, pong := pingServerID(t, l)
+	if guid == 0 || uint64(guid) != pong.ServerID {
+		t.Fatalf("expected equal non-zero server IDs, got %v in header and %v in pong", guid, pong.ServerID)
+	}
+	if reply := openConnectionServerID(t, l); reply != guid {
+		t.Fatalf("expected server ID %v in open connection reply, got %v", guid, reply)
+	}
+}
+
+// openConnectionServerID sends an open connection request 1 to the Listener passed and returns the server ID
+// found in the open connection reply 1 it responds with. The reply consists of the ID 0x06 and 16 magic
+// bytes, followed by the server ID.
+func openConnectionServerID(t *testing.T, l *Listener) int64 {
+	pc, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		t.Fatalf("error listening for reply: %v", err)
+	}
+	defer pc.Close()
+	_ = pc.SetDeadline(time.Now().Add(time.Second * 5))
+
+	// The request is padded to the MTU size proposed, and holds the RakNet protocol version (11) after the
+	// magic bytes.
+	request := make([]byte, 1400)
+	request[0] = 0x05
+	copy(request[1:], unconnectedMagic[:])
+	request[17] = 11
+	if _, err := pc.WriteToUDP(request, l.Addr().(*net.UDPAddr)); err != nil {
+		t.Fatalf("error sending open connection request: %v", err)
+	}
+	b := make([]byte, 1500)
+	n, _, err := pc.ReadFromUDP(b)
+	if err != nil {
+		t.Fatalf("error reading open connection reply: %v", err)
+	}
+	if n < 25 || b[0] != 0x06 {
+		t.Fatalf("expected open connection reply 1, got %x", b[:n])
+	}
+	return int64(binary.BigEndian.Uint64(b[17:25]))
+}
+
+// pingServerID sends an unconnected ping to the Listener passed and returns the server ID found in the header
+// of the pong it responds with, together with the parsed pong data.
+func pingServerID(t *testing.T, l *Listener) (int64, Pong) {
+	pc, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		t.Fatalf("error listening for pong: %v", err)
+	}
+	defer pc.Close()
+	_ = pc.SetDeadline(time.Now().Add(time.Second * 5))
+
+	ping := new(bytes.Buffer)
+	ping.WriteByte(idUnconnectedPing)
+	_ = binary.Write(ping, binary.BigEndian, time.Now().UnixMilli())
+	ping.Write(unconnectedMagic[:])
+	ping.Write(make([]byte, 8))
+
+	b := make([]byte, 1500)
+	for {
+		if _, err := pc.WriteToUDP(ping.Bytes(), l.Addr().(*net.UDPAddr)); err != nil {
+			t.Fatalf("error sending ping: %v", err)
+		}
+		n, _, err := pc.ReadFromUDP(b)
+		if err != nil {
+			t.Fatalf("error reading pong: %v", err)
+		}
+		data, ok := parseUnconnectedPong(b[:n])
+		if !ok {
+			t.Fatalf("expected unconnected pong, got %x", b[:n])
+		}
+		// The pong data of the Listener is set asynchronously, so it may still be empty right after listening.
+		if len(data) == 0 {
+			time.Sleep(time.Millisecond * 10)
+			continue
+		}
+		pong, err := ParsePong(data)
+		if err != nil {
+			t.Fatalf("error parsing pong: %v", err)
+		}
+		return int64(binary.BigEndian.Uint64(b[9:17])), pong
+	}
+}
@@ -0,0 +1,72 @@
+package minecraft
+
+// ReadQueuePolicy specifies how a Conn behaves when a packet is received while its read queue is full. The
+// read queue holds packets received from the other end of the connection until they are read using
+// Conn.ReadPacket or Conn.Read.
+type ReadQueuePolicy int
+
+const (
+	// ReadQueuePolicyBlock makes a Conn with a full read queue stop reading from the underlying connection
+	// until a packet is read from the queue. No packets are lost, but the other end of the connection may be
+	// slowed down, and a Disconnect packet is only noticed once the packets before it are read.
+	ReadQueuePolicyBlock ReadQueuePolicy = iota
+	// ReadQueuePolicyDrop makes a Conn with a full read queue drop packets received until a packet is read
+	// from the queue. Dropping packets may leave the state of the Conn out of sync with the other end, so it
+	// should only be used by consumers that can cope with missing packets.
+	ReadQueuePolicyDrop
+)
+
+// setReadQueue sets the size and ReadQueuePolicy of the read queue of the Conn. It must be called before the
+// Conn starts receiving packets.
+func (conn *Conn) setReadQueue(size int, policy ReadQueuePolicy) {
+	if size > 0 {
+		conn.packets = make(chan *packetData, size)
+	}
+	conn.readQueueSize, conn.readQueuePolicy = size, policy
+}
+
+// queuePacket adds a packet received after logging in to the read queue of the Conn, so that it may be read
+// using ReadPacket. If the read queue is unbounded, or if packets are read manually using Dialer.ManualRead,
+// queuePacket never blocks. Otherwise, it blocks or drops the packet if the queue is full, depending on the
+// ReadQueuePolicy of the Conn.
+func (conn *Conn) queuePacket(pkData *packetData) {
+	if conn.readQueueSize <= 0 || conn.readingManually() {
+		select {
+		case <-conn.close:
+		case previous := <-conn.packets:
+			// There was already a packet in this channel, so take it out and defer it so that it is read
+			// next.
+			conn.deferPacket(previous)
+		default:
+		}
+		select {
+		case <-conn.close:
+		case conn.packets <- pkData:
+		}
+		return
+	}
+	if conn.readQueuePolicy == ReadQueuePolicyDrop {
+		select {
+		case conn.packets <- pkData:
+		default:
+			conn.droppedPackets.Add(1)
+			conn.log.Warn("read queue full: dropping packet", "id", pkData.h.PacketID)
+		}
+		return
+	}
+	select {
+	case <-conn.close:
+	case conn.packets <- pkData:
+	}
+}
+
+// readingManually checks if the background reader of the Conn was stopped because Dialer.ManualRead is set, in
+// which case packets are received on the goroutine that reads them from the read queue.
+func (conn *Conn) readingManually() bool {
+	select {
+	case <-conn.readerStopped:
+		return true
+	default:
+		return false
+	}
+}
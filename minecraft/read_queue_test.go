@@ -0,0 +1,88 @@
+package minecraft
+
+import (
+	"bytes"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// newReadQueueConn returns a logged in Conn with a read queue of two packets using the ReadQueuePolicy
+// passed.
+func newReadQueueConn(t *testing.T, policy ReadQueuePolicy) *Conn {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	conn.pool = conn.proto.Packets(false)
+	conn.setReadQueue(2, policy)
+	conn.loggedIn = true
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = other.Close()
+	})
+	return conn
+}
+
+// receiveText encodes a Text packet with the message passed and passes it to Conn.receive.
+func receiveText(conn *Conn, message string) error {
+	buf := bytes.NewBuffer(nil)
+	(&packet.Header{PacketID: packet.IDText}).Write(buf)
+	(&packet.Text{Message: message}).Marshal(conn.proto.NewWriter(buf, 0))
+	return conn.receive(buf.Bytes())
+}
+
+func TestReadQueueDrop(t *testing.T) {
+	conn := newReadQueueConn(t, ReadQueuePolicyDrop)
+	for _, message := range []string{"a", "b", "c"} {
+		if err := receiveText(conn, message); err != nil {
+			t.Fatalf("receive packet %v: %v", message, err)
+		}
+	}
+	if stats := conn.Stats(); stats.ReadQueueDepth != 2 || stats.DroppedPackets != 1 {
+		t.Fatalf("expected read queue depth 2 and 1 dropped packet, got %v and %v", stats.ReadQueueDepth, stats.DroppedPackets)
+	}
+	for _, message := range []string{"a", "b"} {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("read packet: %v", err)
+		}
+		if got := pk.(*packet.Text).Message; got != message {
+			t.Fatalf("expected message %q, got %q", message, got)
+		}
+	}
+}
+
+func TestReadQueueBlock(t *testing.T) {
+	conn := newReadQueueConn(t, ReadQueuePolicyBlock)
+	for _, message := range []string{"a", "b"} {
+		if err := receiveText(conn, message); err != nil {
+			t.Fatalf("receive packet %v: %v", message, err)
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveText(conn, "c")
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("expected receive to block, returned %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+	for _, message := range []string{"a", "b", "c"} {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("read packet: %v", err)
+		}
+		if got := pk.(*packet.Text).Message; got != message {
+			t.Fatalf("expected message %q, got %q", message, got)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("receive packet c: %v", err)
+	}
+	if dropped := conn.Stats().DroppedPackets; dropped != 0 {
+		t.Fatalf("expected no dropped packets, got %v", dropped)
+	}
+}
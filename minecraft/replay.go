@@ -0,0 +1,104 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"time"
+)
+
+// Replay replays the capture read from the io.Reader passed at real-time speed. It is the equivalent of
+// calling ReplayContext with context.Background() and a speed of 1.
+func Replay(r io.Reader, handler func(pk packet.Packet, toServer bool)) error {
+	return ReplayContext(context.Background(), r, 1, handler)
+}
+
+// ReplayContext replays a capture previously recorded using the Capture field of a Dialer or ListenConfig,
+// which is read from the io.Reader passed. Every packet in the capture is decoded and passed to the handler
+// together with its direction: toServer is true for packets sent by the client and false for packets sent
+// by the server, regardless of the side of the connection that the capture was made on.
+// The packets are passed to the handler with the time between them as recorded, divided by the speed passed,
+// so that a speed of 2 replays the capture twice as fast. If the speed is 0 or lower, the packets are passed
+// without waiting. The packets are decoded using the packets of the current protocol, so captures made with
+// another protocol may not be decoded correctly. Packets with an ID unknown to that protocol are passed as a
+// *packet.Unknown.
+// ReplayContext returns nil once all packets were replayed, or an error if the capture could not be read, a
+// packet could not be decoded or the context passed is done.
+func ReplayContext(ctx context.Context, r io.Reader, speed float64, handler func(pk packet.Packet, toServer bool)) error {
+	cr, err := NewCaptureReader(r)
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	var (
+		clientPool, serverPool = DefaultProtocol.Packets(true), DefaultProtocol.Packets(false)
+		shieldID               int32
+		start, first           time.Time
+	)
+	for {
+		captured, err := cr.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+		if first.IsZero() {
+			start, first = time.Now(), captured.Time
+		} else if speed > 0 {
+			wait := time.Until(start.Add(time.Duration(float64(captured.Time.Sub(first)) / speed)))
+			if wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return fmt.Errorf("replay: %w", ctx.Err())
+				case <-t.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+
+		toServer := captured.Sent != cr.Server()
+		pool := serverPool
+		if toServer {
+			pool = clientPool
+		}
+		pk, err := decodeCaptured(captured, pool, shieldID)
+		if err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+		if startGame, ok := pk.(*packet.StartGame); ok {
+			for _, item := range startGame.Items {
+				if item.Name == "minecraft:shield" {
+					shieldID = int32(item.RuntimeID)
+				}
+			}
+		}
+		handler(pk, toServer)
+	}
+}
+
+// decodeCaptured decodes the payload of the CapturedPacket passed into the packet with its ID found in the
+// packet.Pool passed. A *packet.Unknown is returned if the pool does not hold a packet with the ID.
+func decodeCaptured(captured CapturedPacket, pool packet.Pool, shieldID int32) (pk packet.Packet, err error) {
+	defer func() {
+		if recoveredErr := recover(); recoveredErr != nil {
+			err = fmt.Errorf("decode packet %v: %v", captured.Header.PacketID, recoveredErr)
+		}
+	}()
+	if pkFunc, ok := pool[captured.Header.PacketID]; ok {
+		pk = pkFunc()
+	} else {
+		pk = &packet.Unknown{PacketID: captured.Header.PacketID}
+	}
+	buf := bytes.NewBuffer(captured.Payload)
+	pk.Marshal(DefaultProtocol.NewReader(buf, shieldID, false))
+	if buf.Len() != 0 {
+		return nil, fmt.Errorf("decode packet %T: %v unread bytes left", pk, buf.Len())
+	}
+	return pk, nil
+}
@@ -0,0 +1,67 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"testing"
+	"time"
+)
+
+// TestReplay tests that ReplayContext passes the packets of a capture to the handler in order, with the right
+// direction, honouring the recorded time between them divided by the speed.
+func TestReplay(t *testing.T) {
+	// The capture is made on the client side: sent packets go to the server.
+	capture := bytes.NewBuffer(append([]byte(captureMagic), captureVersion, 0))
+	epoch := time.Now()
+	for i, rec := range []struct {
+		sent bool
+		pk   packet.Packet
+	}{
+		{false, &packet.Text{TextType: packet.TextTypeRaw, Message: "hello"}},
+		{true, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "hi"}},
+		{false, &packet.Text{TextType: packet.TextTypeRaw, Message: "bye"}},
+	} {
+		payload := bytes.NewBuffer(nil)
+		rec.pk.Marshal(protocol.NewWriter(payload, 0))
+		b := binary.LittleEndian.AppendUint64(nil, uint64(epoch.Add(time.Duration(i)*time.Millisecond*100).UnixNano()))
+		if rec.sent {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		b = binary.LittleEndian.AppendUint32(b, rec.pk.ID())
+		b = append(b, 0, 0)
+		b = binary.LittleEndian.AppendUint32(b, uint32(payload.Len()))
+		capture.Write(append(b, payload.Bytes()...))
+	}
+
+	var (
+		messages  []string
+		toServers []bool
+	)
+	start := time.Now()
+	err := ReplayContext(context.Background(), bytes.NewReader(capture.Bytes()), 10, func(pk packet.Packet, toServer bool) {
+		messages, toServers = append(messages, pk.(*packet.Text).Message), append(toServers, toServer)
+	})
+	if err != nil {
+		t.Fatalf("error replaying: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*20 {
+		t.Fatalf("expected replay at 10x speed to take at least 20ms, took %v", elapsed)
+	}
+	if len(messages) != 3 || messages[0] != "hello" || messages[1] != "hi" || messages[2] != "bye" {
+		t.Fatalf("unexpected messages replayed: %v", messages)
+	}
+	if toServers[0] || !toServers[1] || toServers[2] {
+		t.Fatalf("unexpected directions replayed: %v", toServers)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ReplayContext(ctx, bytes.NewReader(capture.Bytes()), 0, func(packet.Packet, bool) {}); err == nil {
+		t.Fatalf("expected replay with a cancelled context to fail")
+	}
+}
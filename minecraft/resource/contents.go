@@ -0,0 +1,174 @@
+package resource
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Files returns the paths of all files in the resource pack, relative to the directory of the pack holding
+// its manifest.json, sorted alphabetically. Directories are not included. The paths may be passed to
+// ReadFile to read the contents of the files.
+func (pack *Pack) Files() ([]string, error) {
+	r, root, err := pack.archive()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.HasPrefix(f.Name, root) {
+			continue
+		}
+		files = append(files, strings.TrimPrefix(f.Name, root))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadFile reads the file at the path passed, relative to the directory of the pack holding its
+// manifest.json, such as 'textures/blocks/stone.png'. If the pack is encrypted, the file is decrypted using
+// the content key of the pack, which must be set using WithContentKey. The manifest.json of the pack is never
+// encrypted and may be read without a content key.
+func (pack *Pack) ReadFile(name string) ([]byte, error) {
+	r, root, err := pack.archive()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, `\`, "/")), "/")
+	data, err := readZipFile(r, root+name)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := readZipFile(r, root+"contents.json")
+	if err != nil || !encryptedContents(contents) || name == "manifest.json" {
+		// The pack is not encrypted, or the file read is one that is never encrypted.
+		return data, nil
+	}
+	if pack.contentKey == "" {
+		return nil, fmt.Errorf("read %v: pack is encrypted: content key required", name)
+	}
+	keys, err := decryptContents(contents, pack.contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("read %v: %w", name, err)
+	}
+	key, ok := keys[name]
+	if !ok || key == "" {
+		// Files without a key in the contents, such as the pack icon, are not encrypted.
+		return data, nil
+	}
+	return decryptCFB8(data, []byte(key))
+}
+
+// archive returns a zip.Reader for the archive of the pack, together with the directory holding the
+// manifest.json of the pack, which is either empty or ends with a slash.
+func (pack *Pack) archive() (*zip.Reader, string, error) {
+	r, err := zip.NewReader(pack.content, pack.content.Size())
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening zip reader: %v", err)
+	}
+	root, found := "", false
+	for _, f := range r.File {
+		if f.Name == "manifest.json" {
+			return r, "", nil
+		}
+		if dir, file := path.Split(f.Name); file == "manifest.json" && (!found || len(dir) < len(root)) {
+			root, found = dir, true
+		}
+	}
+	return r, root, nil
+}
+
+// readZipFile reads the full contents of the file with the name passed in the zip.Reader.
+func readZipFile(r *zip.Reader, name string) ([]byte, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening zip file %v: %w", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading zip file %v: %w", name, err)
+	}
+	return data, nil
+}
+
+const (
+	// contentsHeaderSize is the size of the unencrypted header of the contents.json of an encrypted pack.
+	contentsHeaderSize = 0x100
+	// contentsMagic is the magic found in the header of the contents.json of an encrypted pack.
+	contentsMagic = 0x9bcfb9fc
+)
+
+// encryptedContents checks if the contents.json data passed is that of an encrypted pack.
+func encryptedContents(data []byte) bool {
+	return len(data) >= contentsHeaderSize && binary.LittleEndian.Uint32(data[4:]) == contentsMagic
+}
+
+// decryptContents decrypts the contents.json data of an encrypted pack using the content key passed and
+// returns the keys of the encrypted files, indexed by their path.
+func decryptContents(data []byte, contentKey string) (map[string]string, error) {
+	decrypted, err := decryptCFB8(data[contentsHeaderSize:], []byte(contentKey))
+	if err != nil {
+		return nil, err
+	}
+	var contents struct {
+		Content []struct {
+			Path string `json:"path"`
+			Key  string `json:"key"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(decrypted, &contents); err != nil {
+		return nil, fmt.Errorf("decode contents.json: %w (invalid content key?)", err)
+	}
+	keys := make(map[string]string, len(contents.Content))
+	for _, entry := range contents.Content {
+		keys[entry.Path] = entry.Key
+	}
+	return keys, nil
+}
+
+// decryptCFB8 decrypts data encrypted with AES-256 in CFB8 mode, using the first 16 bytes of the key as IV.
+func decryptCFB8(data, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	out := make([]byte, len(data))
+	newCFB8(block, key[:block.BlockSize()], true).XORKeyStream(out, data)
+	return out, nil
+}
+
+// cfb8 implements cipher.Stream for AES in CFB8 mode, which the standard library does not provide.
+type cfb8 struct {
+	block   cipher.Block
+	sr, out []byte
+	decrypt bool
+}
+
+// newCFB8 returns a CFB8 stream using the block and IV passed.
+func newCFB8(block cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	return &cfb8{block: block, sr: bytes.Clone(iv), out: make([]byte, block.BlockSize()), decrypt: decrypt}
+}
+
+// XORKeyStream ...
+func (x *cfb8) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		x.block.Encrypt(x.out, x.sr)
+		c := src[i] ^ x.out[0]
+		next := c
+		if x.decrypt {
+			next = src[i]
+		}
+		copy(x.sr, x.sr[1:])
+		x.sr[len(x.sr)-1] = next
+		dst[i] = c
+	}
+}
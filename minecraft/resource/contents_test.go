@@ -0,0 +1,95 @@
+package resource
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+// encryptCFB8 encrypts data with AES-256 in CFB8 mode, using the first 16 bytes of the key as IV.
+func encryptCFB8(t *testing.T, data []byte, key string) []byte {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatalf("create cipher: %v", err)
+	}
+	out := make([]byte, len(data))
+	newCFB8(block, []byte(key)[:block.BlockSize()], false).XORKeyStream(out, data)
+	return out
+}
+
+// newTestPack creates a Pack from an archive holding the files passed, nested in a 'pack' directory.
+func newTestPack(t *testing.T, files map[string][]byte) *Pack {
+	buf := bytes.NewBuffer(nil)
+	w := zip.NewWriter(buf)
+	for name, data := range files {
+		f, err := w.Create("pack/" + name)
+		if err != nil {
+			t.Fatalf("create %v: %v", name, err)
+		}
+		_, _ = f.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	pack, err := Read(buf)
+	if err != nil {
+		t.Fatalf("read pack: %v", err)
+	}
+	return pack
+}
+
+const testManifest = `{"format_version": 2, "header": {"name": "Test", "uuid": "3c6ab8c4-0b3e-4b5b-a4de-6f3b46dd8a3b", "version": [1, 0, 0]}, "modules": [{"type": "resources", "uuid": "e0cbc1b6-4f9b-4a5e-9a9c-1f8e6b3b2c4d", "version": [1, 0, 0]}]}`
+
+func TestPackReadFile(t *testing.T) {
+	pack := newTestPack(t, map[string][]byte{
+		"manifest.json":         []byte(testManifest),
+		"textures/blocks/a.png": []byte("stone"),
+		"texts/en_US.lang":      []byte("a=b"),
+	})
+	files, err := pack.Files()
+	if err != nil {
+		t.Fatalf("list files: %v", err)
+	}
+	if len(files) != 3 || files[0] != "manifest.json" || files[2] != "textures/blocks/a.png" {
+		t.Fatalf("unexpected files %v", files)
+	}
+	if data, err := pack.ReadFile("textures/blocks/a.png"); err != nil || string(data) != "stone" {
+		t.Fatalf("expected %q, got %q (%v)", "stone", data, err)
+	}
+	if data, err := pack.ReadFile("manifest.json"); err != nil || string(data) != testManifest {
+		t.Fatalf("expected manifest, got %q (%v)", data, err)
+	}
+	if _, err := pack.ReadFile("missing.json"); err == nil {
+		t.Fatal("expected error reading missing file")
+	}
+}
+
+func TestPackReadFileEncrypted(t *testing.T) {
+	const contentKey, fileKey = "0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"
+
+	contents, _ := json.Marshal(map[string]any{"content": []map[string]string{
+		{"path": "manifest.json"},
+		{"path": "textures/blocks/a.png", "key": fileKey},
+	}})
+	header := make([]byte, contentsHeaderSize)
+	binary.LittleEndian.PutUint32(header[4:], contentsMagic)
+
+	pack := newTestPack(t, map[string][]byte{
+		"manifest.json":         []byte(testManifest),
+		"contents.json":         append(header, encryptCFB8(t, contents, contentKey)...),
+		"textures/blocks/a.png": encryptCFB8(t, []byte("stone"), fileKey),
+	})
+	if data, err := pack.ReadFile("manifest.json"); err != nil || string(data) != testManifest {
+		t.Fatalf("expected manifest without content key, got %q (%v)", data, err)
+	}
+	if _, err := pack.ReadFile("textures/blocks/a.png"); err == nil {
+		t.Fatal("expected error reading encrypted file without content key")
+	}
+	data, err := pack.WithContentKey(contentKey).ReadFile("textures/blocks/a.png")
+	if err != nil || string(data) != "stone" {
+		t.Fatalf("expected %q, got %q (%v)", "stone", data, err)
+	}
+}
@@ -185,6 +185,57 @@ func (pack *Pack) HasWorldTemplate() bool {
 	return pack.manifest.worldTemplate
 }
 
+// Type is the type of a Pack, as derived from the modules in its manifest.
+type Type int
+
+const (
+	// TypeResources is the Type of resource packs, which only hold textures and other client-side resources.
+	TypeResources Type = iota
+	// TypeBehaviour is the Type of behaviour packs, which hold behaviours or scripts but no textures.
+	TypeBehaviour
+	// TypeAddon is the Type of packs that hold both textures and behaviours or scripts.
+	TypeAddon
+	// TypeWorldTemplate is the Type of packs that hold a world template.
+	TypeWorldTemplate
+	// TypeSkins is the Type of packs without any modules that hold textures or behaviours, such as skin
+	// packs.
+	TypeSkins
+)
+
+// String returns the name of the Type, such as 'behaviour'.
+func (t Type) String() string {
+	switch t {
+	case TypeResources:
+		return "resources"
+	case TypeBehaviour:
+		return "behaviour"
+	case TypeAddon:
+		return "addon"
+	case TypeWorldTemplate:
+		return "world_template"
+	case TypeSkins:
+		return "skins"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Type returns the Type of the resource pack, which is derived from the modules in its manifest. Servers send
+// packs of TypeBehaviour and TypeAddon in the behaviour pack list of the ResourcePacksInfo packet and other
+// packs in the texture pack list.
+func (pack *Pack) Type() Type {
+	switch {
+	case pack.HasWorldTemplate():
+		return TypeWorldTemplate
+	case pack.HasTextures() && (pack.HasBehaviours() || pack.HasScripts()):
+		return TypeAddon
+	case pack.HasBehaviours() || pack.HasScripts():
+		return TypeBehaviour
+	case pack.HasTextures():
+		return TypeResources
+	}
+	return TypeSkins
+}
+
 // DownloadURL returns the URL that the resource pack can be downloaded from. If the string is empty, then the
 // resource pack will be downloaded over RakNet rather than HTTP.
 func (pack *Pack) DownloadURL() string {
@@ -5,84 +5,168 @@ import (
 	"fmt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"strings"
 )
 
 // resourcePackQueue is used to aid in the handling of resource pack queueing and downloading. Only one
 // resource pack is downloaded at a time.
 type resourcePackQueue struct {
 	packs           []*resource.Pack
-	packsToDownload map[string]*resource.Pack
+	packsToDownload []*resource.Pack
 	currentPack     *resource.Pack
-	currentOffset   uint64
 
 	packAmount       int
 	downloadingPacks map[string]downloadingPack
 	awaitingPacks    map[string]*downloadingPack
+	// behaviourPacks holds the UUIDs of the packs that the server sent in the behaviour pack list of the
+	// ResourcePacksInfo packet, as opposed to the texture pack list.
+	behaviourPacks map[string]bool
 }
 
 // downloadingPack is a resource pack that is being downloaded by a client connection.
 type downloadingPack struct {
-	buf           *bytes.Buffer
-	chunkSize     uint32
-	size          uint64
+	buf       *bytes.Buffer
+	chunkSize uint32
+	size      uint64
+	// expectedIndex is the index of the next chunk of the pack to be received. If the download is
+	// interrupted, it is resumed by requesting the chunks from this index again.
 	expectedIndex uint32
-	newFrag       chan []byte
-	contentKey    string
+	// hash is the SHA256 checksum of the full pack, as sent in the ResourcePackDataInfo packet. It may be
+	// empty if the server did not send one.
+	hash       []byte
+	newFrag    chan *packet.ResourcePackChunkData
+	contentKey string
+}
+
+// chunkLen returns the length that the chunk with the index passed must have, out of a total of chunkCount
+// chunks.
+func (pack *downloadingPack) chunkLen(index, chunkCount uint32) int {
+	if index == chunkCount-1 {
+		return int(pack.size - uint64(index)*uint64(pack.chunkSize))
+	}
+	return int(pack.chunkSize)
 }
 
 // Request 'requests' all resource packs passed, provided they all exist in the resourcePackQueue. If not,
-// an error is returned.
+// an error is returned. The packs are downloaded in the order of queue.packs, regardless of the order they
+// were requested in, except that packs that other requested packs depend on are downloaded first. An error
+// is returned if the dependencies of the packs form a cycle.
 func (queue *resourcePackQueue) Request(packs []string) error {
-	queue.packsToDownload = make(map[string]*resource.Pack)
+	found := make(map[string]bool, len(packs))
 	for _, packUUID := range packs {
-		found := false
-		for _, pack := range queue.packs {
-			// Mojang made some hack that merges the UUID with the version, so we need to combine that here
-			// too in order to find the proper pack.
-			if pack.UUID()+"_"+pack.Version() == packUUID {
-				queue.packsToDownload[pack.UUID()] = pack
-				found = true
-				break
-			}
+		found[packUUID] = false
+	}
+	requested := make([]*resource.Pack, 0, len(packs))
+	for _, pack := range queue.packs {
+		// Mojang made some hack that merges the UUID with the version, so we need to combine that here
+		// too in order to find the proper pack.
+		if isFound, ok := found[pack.UUID()+"_"+pack.Version()]; ok && !isFound {
+			found[pack.UUID()+"_"+pack.Version()] = true
+			requested = append(requested, pack)
 		}
-		if !found {
+	}
+	for _, packUUID := range packs {
+		if !found[packUUID] {
 			return fmt.Errorf("could not find resource pack %v", packUUID)
 		}
 	}
+	ordered, err := orderByDependencies(requested)
+	if err != nil {
+		return err
+	}
+	queue.packsToDownload = ordered
 	return nil
 }
 
+// orderByDependencies orders the packs passed so that every pack comes after the packs it depends on.
+// Dependencies on packs that are not passed, such as script modules, are ignored. Packs without
+// dependencies between them keep their relative order. An error is returned if the dependencies form a
+// cycle.
+func orderByDependencies(packs []*resource.Pack) ([]*resource.Pack, error) {
+	byUUID := make(map[string]*resource.Pack, len(packs))
+	for _, pack := range packs {
+		byUUID[pack.UUID()] = pack
+	}
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(packs))
+	ordered := make([]*resource.Pack, 0, len(packs))
+
+	var visit func(pack *resource.Pack, path []string) error
+	visit = func(pack *resource.Pack, path []string) error {
+		path = append(path, pack.UUID())
+		switch state[pack.UUID()] {
+		case visiting:
+			return fmt.Errorf("resource pack dependency cycle: %v", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		state[pack.UUID()] = visiting
+		for _, dependency := range pack.Dependencies() {
+			dep, ok := byUUID[strings.ToLower(dependency.UUID)]
+			if !ok {
+				continue
+			}
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[pack.UUID()] = visited
+		ordered = append(ordered, pack)
+		return nil
+	}
+	for _, pack := range packs {
+		if err := visit(pack, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
 // NextPack assigns the next resource pack to the current pack and returns true if successful. If there were
 // no more packs to assign, false is returned. If ok is true, a packet with data info is returned.
 func (queue *resourcePackQueue) NextPack() (pk *packet.ResourcePackDataInfo, ok bool) {
-	for index, pack := range queue.packsToDownload {
-		delete(queue.packsToDownload, index)
+	if len(queue.packsToDownload) == 0 {
+		return nil, false
+	}
+	pack := queue.packsToDownload[0]
+	queue.packsToDownload = queue.packsToDownload[1:]
+
+	queue.currentPack = pack
+	checksum := pack.Checksum()
 
-		queue.currentPack = pack
-		queue.currentOffset = 0
-		checksum := pack.Checksum()
+	var packType byte
+	switch pack.Type() {
+	case resource.TypeWorldTemplate:
+		packType = packet.ResourcePackTypeWorldTemplate
+	case resource.TypeAddon:
+		packType = packet.ResourcePackTypeAddon
+	case resource.TypeBehaviour:
+		packType = packet.ResourcePackTypeBehaviour
+	case resource.TypeResources:
+		packType = packet.ResourcePackTypeResources
+	default:
+		packType = packet.ResourcePackTypeSkins
+	}
+	return &packet.ResourcePackDataInfo{
+		UUID:          pack.UUID(),
+		DataChunkSize: packChunkSize,
+		ChunkCount:    uint32(pack.DataChunkCount(packChunkSize)),
+		Size:          uint64(pack.Len()),
+		Hash:          checksum[:],
+		PackType:      packType,
+	}, true
+}
 
-		var packType byte
-		switch {
-		case pack.HasWorldTemplate():
-			packType = packet.ResourcePackTypeWorldTemplate
-		case pack.HasTextures() && (pack.HasBehaviours() || pack.HasScripts()):
-			packType = packet.ResourcePackTypeAddon
-		case !pack.HasTextures() && (pack.HasBehaviours() || pack.HasScripts()):
-			packType = packet.ResourcePackTypeBehaviour
-		case pack.HasTextures():
-			packType = packet.ResourcePackTypeResources
-		default:
-			packType = packet.ResourcePackTypeSkins
+// Pack looks up the resource pack with the UUID passed in the queue. False is returned if the queue holds no
+// such pack.
+func (queue *resourcePackQueue) Pack(uuid string) (*resource.Pack, bool) {
+	for _, pack := range queue.packs {
+		if pack.UUID() == uuid {
+			return pack, true
 		}
-		return &packet.ResourcePackDataInfo{
-			UUID:          pack.UUID(),
-			DataChunkSize: packChunkSize,
-			ChunkCount:    uint32(pack.DataChunkCount(packChunkSize)),
-			Size:          uint64(pack.Len()),
-			Hash:          checksum[:],
-			PackType:      packType,
-		}, true
 	}
 	return nil, false
 }
@@ -0,0 +1,185 @@
+package minecraft
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newQueueTestPack creates a resource pack with the UUID passed that depends on the packs with the
+// dependency UUIDs passed.
+func newQueueTestPack(t *testing.T, uuid string, dependencies ...string) *resource.Pack {
+	deps := make([]string, len(dependencies))
+	for i, dep := range dependencies {
+		deps[i] = fmt.Sprintf(`{"uuid": %q, "version": [1, 0, 0]}`, dep)
+	}
+	manifest := fmt.Sprintf(`{"format_version": 2, "header": {"name": %q, "uuid": %q, "version": [1, 0, 0]}, "modules": [{"type": "resources", "uuid": "%v-module", "version": [1, 0, 0]}], "dependencies": [%v]}`, uuid, uuid, uuid, strings.Join(deps, ", "))
+
+	buf := bytes.NewBuffer(nil)
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("manifest.json")
+	_, _ = f.Write([]byte(manifest))
+	_ = w.Close()
+	pack, err := resource.Read(buf)
+	if err != nil {
+		t.Fatalf("read pack %v: %v", uuid, err)
+	}
+	return pack
+}
+
+// downloadOrder requests the packs passed from the queue and returns the UUIDs of the packs in the order that
+// NextPack returns them.
+func downloadOrder(t *testing.T, queue *resourcePackQueue, requested []string) []string {
+	if err := queue.Request(requested); err != nil {
+		t.Fatalf("request packs: %v", err)
+	}
+	var order []string
+	for {
+		pk, ok := queue.NextPack()
+		if !ok {
+			break
+		}
+		order = append(order, pk.UUID)
+	}
+	if !queue.AllDownloaded() {
+		t.Fatal("expected all packs to be downloaded")
+	}
+	return order
+}
+
+func TestResourcePackQueueOrder(t *testing.T) {
+	queue := &resourcePackQueue{packs: []*resource.Pack{
+		newQueueTestPack(t, "a"), newQueueTestPack(t, "b"), newQueueTestPack(t, "c"), newQueueTestPack(t, "d"),
+	}}
+	for i := 0; i < 20; i++ {
+		order := downloadOrder(t, queue, []string{"d_1.0.0", "b_1.0.0", "a_1.0.0", "c_1.0.0"})
+		if got := strings.Join(order, ","); got != "a,b,c,d" {
+			t.Fatalf("expected packs in server order a,b,c,d, got %v", got)
+		}
+	}
+	if order := downloadOrder(t, queue, []string{"c_1.0.0", "a_1.0.0"}); strings.Join(order, ",") != "a,c" {
+		t.Fatalf("expected requested packs in server order a,c, got %v", order)
+	}
+}
+
+func TestResourcePackQueueDependencies(t *testing.T) {
+	queue := &resourcePackQueue{packs: []*resource.Pack{
+		newQueueTestPack(t, "a", "c"), newQueueTestPack(t, "b"), newQueueTestPack(t, "c", "b"),
+	}}
+	if order := downloadOrder(t, queue, []string{"a_1.0.0", "b_1.0.0", "c_1.0.0"}); strings.Join(order, ",") != "b,c,a" {
+		t.Fatalf("expected dependencies to be downloaded first in order b,c,a, got %v", order)
+	}
+
+	queue = &resourcePackQueue{packs: []*resource.Pack{newQueueTestPack(t, "a", "b"), newQueueTestPack(t, "b", "a")}}
+	if err := queue.Request([]string{"a_1.0.0", "b_1.0.0"}); err == nil {
+		t.Fatal("expected error requesting packs with a dependency cycle")
+	}
+}
+
+// TestResourcePackDownload tests that the download of a resource pack is resumed if a chunk is dropped, and
+// that the Conn is closed with an error if the downloaded pack does not match its checksum.
+func TestResourcePackDownload(t *testing.T) {
+	defer func(timeout time.Duration) {
+		packChunkTimeout = timeout
+	}(packChunkTimeout)
+	packChunkTimeout = time.Millisecond * 50
+
+	const uuid = "1f9ce3d6-7b5c-4c44-a1c9-4b8b1e8e7b2a"
+	pack := newQueueTestPack(t, uuid)
+	data := make([]byte, pack.Len())
+	if _, err := pack.ReadAt(data, 0); err != nil && err != io.EOF {
+		t.Fatalf("read pack: %v", err)
+	}
+	checksum := sha256.Sum256(data)
+
+	t.Run("Resume", func(t *testing.T) {
+		conn, done := downloadTestPack(t, uuid, data, checksum[:])
+		if err := <-done; err != nil {
+			t.Fatalf("expected download to succeed, got %v", err)
+		}
+		if len(conn.resourcePacks) != 1 || conn.resourcePacks[0].UUID() != uuid {
+			t.Fatalf("expected pack %v to be downloaded, got %v", uuid, conn.resourcePacks)
+		}
+	})
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		corrupted := checksum
+		corrupted[0] ^= 0xff
+		_, done := downloadTestPack(t, uuid, data, corrupted[:])
+		if err := <-done; err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("expected checksum mismatch error, got %v", err)
+		}
+	})
+}
+
+// downloadTestPack downloads the data passed as a resource pack in chunks of 16 bytes over a Conn. The first
+// request of the second chunk is dropped, so that the download must be resumed. The channel returned receives
+// nil once all packs are downloaded, or the error that the Conn was closed with.
+func downloadTestPack(t *testing.T, uuid string, data, hash []byte) (*Conn, <-chan error) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, time.Millisecond, 0, 0, false)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = other.Close()
+	})
+	const chunkSize = 16
+	pack := &downloadingPack{
+		buf:       bytes.NewBuffer(nil),
+		chunkSize: chunkSize,
+		size:      uint64(len(data)),
+		hash:      hash,
+		newFrag:   make(chan *packet.ResourcePackChunkData, 1),
+	}
+	conn.packQueue = &resourcePackQueue{packAmount: 1, awaitingPacks: map[string]*downloadingPack{uuid: pack}}
+	chunkCount := uint32((len(data) + chunkSize - 1) / chunkSize)
+
+	done := make(chan error, 1)
+	go func() {
+		dropped := false
+		dec := packet.NewDecoder(other)
+		for {
+			batch, err := dec.Decode()
+			if err != nil {
+				done <- conn.closeErr("download")
+				return
+			}
+			for _, b := range batch {
+				buf := bytes.NewBuffer(b)
+				var header packet.Header
+				if err := header.Read(buf); err != nil {
+					t.Errorf("read header: %v", err)
+					return
+				}
+				switch header.PacketID {
+				case packet.IDResourcePackClientResponse:
+					done <- nil
+					return
+				case packet.IDResourcePackChunkRequest:
+					var req packet.ResourcePackChunkRequest
+					req.Marshal(DefaultProtocol.NewReader(buf, 0, false))
+					if req.ChunkIndex == 1 && !dropped {
+						dropped = true
+						continue
+					}
+					offset := int(req.ChunkIndex) * chunkSize
+					_ = conn.handleResourcePackChunkData(&packet.ResourcePackChunkData{
+						UUID:       req.UUID,
+						ChunkIndex: req.ChunkIndex,
+						DataOffset: uint64(offset),
+						Data:       data[offset:min(offset+chunkSize, len(data))],
+					})
+				}
+			}
+		}
+	}()
+	go conn.downloadResourcePackChunks(uuid, uuid, pack, chunkCount)
+	return conn, done
+}
@@ -0,0 +1,83 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// respawnState holds whether the player of a Conn obtained using a Dialer is dead, and the functions called
+// when the player dies and respawns.
+type respawnState struct {
+	mu   sync.Mutex
+	dead bool
+	// auto specifies if the Conn respawns automatically when the player dies, as set in Dialer.AutoRespawn.
+	auto bool
+
+	deathFunc, respawnFunc func(conn *Conn, pos mgl32.Vec3)
+}
+
+// Dead checks if the player of the Conn is dead and has not yet respawned, as found in the Respawn packets
+// sent by the server. Dead always returns false for a Conn obtained from a Listener.
+func (conn *Conn) Dead() bool {
+	conn.respawn.mu.Lock()
+	defer conn.respawn.mu.Unlock()
+	return conn.respawn.dead
+}
+
+// Respawn requests the server to respawn the player of the Conn after it died, which the client does when the
+// respawn button on the death screen is pressed. The server completes the respawn by sending a Respawn packet
+// with the position that the player respawns at, which is passed to Dialer.RespawnFunc. Respawn does not have
+// to be called if Dialer.AutoRespawn is set. An error is returned if the Conn was obtained from a Listener or
+// if the player is not dead.
+func (conn *Conn) Respawn() error {
+	if err := conn.dialerOnly("respawn"); err != nil {
+		return err
+	}
+	if !conn.Dead() {
+		return conn.wrap(fmt.Errorf("player is not dead"), "respawn")
+	}
+	return conn.WritePacket(&packet.Respawn{
+		State:           packet.RespawnStateClientReadyToSpawn,
+		EntityRuntimeID: conn.gameData.EntityRuntimeID,
+	})
+}
+
+// handleRespawn handles a Respawn packet sent by the server. A Respawn packet with the
+// RespawnStateSearchingForSpawn state marks the death of the player, after which the client responds with
+// RespawnStateClientReadyToSpawn once it wants to respawn. The server then completes the respawn with the
+// RespawnStateReadyToSpawn state.
+func (conn *Conn) handleRespawn(pk *packet.Respawn) {
+	if conn.readerLimits || !conn.spawned() {
+		// Servers may send Respawn packets during the spawn sequence, which do not concern a death.
+		return
+	}
+	s := &conn.respawn
+	s.mu.Lock()
+	var f func(conn *Conn, pos mgl32.Vec3)
+	switch pk.State {
+	case packet.RespawnStateSearchingForSpawn:
+		if s.dead {
+			break
+		}
+		s.dead, f = true, s.deathFunc
+	case packet.RespawnStateReadyToSpawn:
+		if !s.dead {
+			break
+		}
+		s.dead, f = false, s.respawnFunc
+		conn.interaction.setPosition(pk.Position)
+	}
+	dead, auto := s.dead, s.auto
+	s.mu.Unlock()
+
+	if f != nil {
+		f(conn, pk.Position)
+	}
+	if dead && auto && pk.State == packet.RespawnStateSearchingForSpawn {
+		if err := conn.Respawn(); err != nil {
+			conn.log.Warn("auto respawn", "err", err)
+		}
+	}
+}
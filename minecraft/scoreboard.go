@@ -0,0 +1,270 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sort"
+	"sync"
+)
+
+// Scoreboard is an objective displayed by the server in one of the scoreboard display slots, together with its
+// entries.
+type Scoreboard struct {
+	// Name is the name of the objective, which is used to identify it but is not displayed.
+	Name string
+	// DisplayName is the title displayed at the top of the scoreboard.
+	DisplayName string
+	// DisplaySlot is the slot that the scoreboard is displayed in. It is one of the packet.ScoreboardSlot
+	// constants.
+	DisplaySlot string
+	// CriteriaName is the name of the criteria of the objective. It is generally 'dummy'.
+	CriteriaName string
+	// SortOrder is the order in which the entries of the scoreboard are sorted. It is one of the
+	// packet.ScoreboardSortOrder constants.
+	SortOrder int32
+	// Entries holds the entries of the scoreboard, keyed by their entry ID. Entries with the
+	// protocol.ScoreboardIdentityFakePlayer identity type display their DisplayName, whereas entries with the
+	// protocol.ScoreboardIdentityPlayer or protocol.ScoreboardIdentityEntity identity type display the name of
+	// the entity with their EntityUniqueID. The identity of entries is changed by SetScoreboardIdentity
+	// packets.
+	Entries map[int64]protocol.ScoreboardEntry
+}
+
+// Lines returns the entries of the scoreboard in the order that they are displayed in, which depends on the
+// SortOrder of the scoreboard. Entries with the same score are ordered by their entry ID.
+func (s Scoreboard) Lines() []protocol.ScoreboardEntry {
+	lines := make([]protocol.ScoreboardEntry, 0, len(s.Entries))
+	for _, entry := range s.Entries {
+		lines = append(lines, entry)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Score == lines[j].Score {
+			return lines[i].EntryID < lines[j].EntryID
+		}
+		if s.SortOrder == packet.ScoreboardSortOrderDescending {
+			return lines[i].Score > lines[j].Score
+		}
+		return lines[i].Score < lines[j].Score
+	})
+	return lines
+}
+
+// SetObjective displays the objective passed in a display slot of the player of the Conn, which is one of the
+// packet.ScoreboardSlot constants. The sort order is one of the packet.ScoreboardSortOrder constants. Any
+// objective previously displayed in the slot is replaced. Lines may be added to the objective using
+// SetScoreLine or SetScores. An error is returned if the Conn was not obtained from a Listener or if the slot
+// or sort order is unknown.
+func (conn *Conn) SetObjective(slot, objective, displayName string, sortOrder int32) error {
+	if err := conn.listenerOnly("set objective"); err != nil {
+		return err
+	}
+	switch slot {
+	case packet.ScoreboardSlotList, packet.ScoreboardSlotSidebar, packet.ScoreboardSlotBelowName:
+	default:
+		return conn.wrap(fmt.Errorf("unknown scoreboard display slot %q", slot), "set objective")
+	}
+	if sortOrder != packet.ScoreboardSortOrderAscending && sortOrder != packet.ScoreboardSortOrderDescending {
+		return conn.wrap(fmt.Errorf("unknown scoreboard sort order %v", sortOrder), "set objective")
+	}
+	return conn.WritePacket(&packet.SetDisplayObjective{
+		DisplaySlot:   slot,
+		ObjectiveName: objective,
+		DisplayName:   displayName,
+		CriteriaName:  "dummy",
+		SortOrder:     sortOrder,
+	})
+}
+
+// RemoveObjective removes the objective with the name passed, together with all of its entries. An error is
+// returned if the Conn was not obtained from a Listener.
+func (conn *Conn) RemoveObjective(objective string) error {
+	if err := conn.listenerOnly("remove objective"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.RemoveObjective{ObjectiveName: objective})
+}
+
+// SetScoreLine sets a line of plain text on the objective passed. The line is used as both entry ID and score
+// of the entry, so that lines set with a higher line number are displayed below lower ones on objectives with
+// an ascending sort order. Setting a line that was set before replaces it. An error is returned if the Conn was
+// not obtained from a Listener.
+func (conn *Conn) SetScoreLine(objective string, line int32, text string) error {
+	return conn.SetScores(objective, protocol.ScoreboardEntry{
+		EntryID:      int64(line),
+		Score:        line,
+		IdentityType: protocol.ScoreboardIdentityFakePlayer,
+		DisplayName:  text,
+	})
+}
+
+// SetScores adds the entries passed to the objective passed, or modifies them if entries with the same entry
+// IDs are already present. The ObjectiveName of the entries is set to the objective passed. An error is
+// returned if the Conn was not obtained from a Listener or if any of the entries has an unknown identity type.
+func (conn *Conn) SetScores(objective string, entries ...protocol.ScoreboardEntry) error {
+	if err := conn.listenerOnly("set scores"); err != nil {
+		return err
+	}
+	entries = append([]protocol.ScoreboardEntry(nil), entries...)
+	for i, entry := range entries {
+		switch entry.IdentityType {
+		case protocol.ScoreboardIdentityPlayer, protocol.ScoreboardIdentityEntity, protocol.ScoreboardIdentityFakePlayer:
+		default:
+			return conn.wrap(fmt.Errorf("unknown scoreboard identity type %v", entry.IdentityType), "set scores")
+		}
+		entries[i].ObjectiveName = objective
+	}
+	return conn.WritePacket(&packet.SetScore{ActionType: packet.ScoreboardActionModify, Entries: entries})
+}
+
+// RemoveScores removes the entries with the entry IDs passed, such as lines set using SetScoreLine, from the
+// objective passed. An error is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) RemoveScores(objective string, entryIDs ...int64) error {
+	if err := conn.listenerOnly("remove scores"); err != nil {
+		return err
+	}
+	entries := make([]protocol.ScoreboardEntry, len(entryIDs))
+	for i, id := range entryIDs {
+		entries[i] = protocol.ScoreboardEntry{EntryID: id, ObjectiveName: objective}
+	}
+	return conn.WritePacket(&packet.SetScore{ActionType: packet.ScoreboardActionRemove, Entries: entries})
+}
+
+// SetScoreIdentity associates the entries with the entry ID passed with the player with the entity unique ID
+// passed, using a SetScoreboardIdentity packet. The entries then display the name and head of the player
+// rather than their display name. An error is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) SetScoreIdentity(entryID, entityUniqueID int64) error {
+	if err := conn.listenerOnly("set score identity"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.SetScoreboardIdentity{
+		ActionType: packet.ScoreboardIdentityActionRegister,
+		Entries:    []protocol.ScoreboardIdentityEntry{{EntryID: entryID, EntityUniqueID: entityUniqueID}},
+	})
+}
+
+// ClearScoreIdentity removes the association of the entries with the entry IDs passed with a player, as set
+// using SetScoreIdentity, so that they display their display name again. An error is returned if the Conn was
+// not obtained from a Listener.
+func (conn *Conn) ClearScoreIdentity(entryIDs ...int64) error {
+	if err := conn.listenerOnly("clear score identity"); err != nil {
+		return err
+	}
+	entries := make([]protocol.ScoreboardIdentityEntry, len(entryIDs))
+	for i, id := range entryIDs {
+		entries[i] = protocol.ScoreboardIdentityEntry{EntryID: id}
+	}
+	return conn.WritePacket(&packet.SetScoreboardIdentity{ActionType: packet.ScoreboardIdentityActionClear, Entries: entries})
+}
+
+// scoreboardState holds the objectives displayed by the server, keyed by their name, if
+// Dialer.TrackScoreboards is set.
+type scoreboardState struct {
+	mu         sync.Mutex
+	enabled    bool
+	objectives map[string]Scoreboard
+}
+
+// Scoreboard returns the scoreboard currently displayed in the display slot passed, which is one of the
+// packet.ScoreboardSlot constants. False is returned if no objective is displayed in the slot or if the Conn was
+// not dialed with Dialer.TrackScoreboards set.
+func (conn *Conn) Scoreboard(slot string) (Scoreboard, bool) {
+	conn.scoreboards.mu.Lock()
+	defer conn.scoreboards.mu.Unlock()
+	for _, s := range conn.scoreboards.objectives {
+		if s.DisplaySlot == slot {
+			entries := make(map[int64]protocol.ScoreboardEntry, len(s.Entries))
+			for id, entry := range s.Entries {
+				entries[id] = entry
+			}
+			s.Entries = entries
+			return s, true
+		}
+	}
+	return Scoreboard{}, false
+}
+
+// handleSetDisplayObjective displays the objective held in the SetDisplayObjective packet passed, replacing the
+// objective previously displayed in the same slot.
+func (s *scoreboardState) handleSetDisplayObjective(pk *packet.SetDisplayObjective) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.objectives == nil {
+		s.objectives = make(map[string]Scoreboard)
+	}
+	for name, objective := range s.objectives {
+		if objective.DisplaySlot == pk.DisplaySlot {
+			delete(s.objectives, name)
+		}
+	}
+	s.objectives[pk.ObjectiveName] = Scoreboard{
+		Name:         pk.ObjectiveName,
+		DisplayName:  pk.DisplayName,
+		DisplaySlot:  pk.DisplaySlot,
+		CriteriaName: pk.CriteriaName,
+		SortOrder:    pk.SortOrder,
+		Entries:      make(map[int64]protocol.ScoreboardEntry),
+	}
+}
+
+// handleSetScore adds, modifies or removes the entries held in the SetScore packet passed. Entries for
+// objectives that are not displayed are ignored.
+func (s *scoreboardState) handleSetScore(pk *packet.SetScore) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range pk.Entries {
+		objective, ok := s.objectives[entry.ObjectiveName]
+		if !ok {
+			continue
+		}
+		if pk.ActionType == packet.ScoreboardActionRemove {
+			delete(objective.Entries, entry.EntryID)
+			continue
+		}
+		objective.Entries[entry.EntryID] = entry
+	}
+}
+
+// handleRemoveObjective removes the objective held in the RemoveObjective packet passed.
+func (s *scoreboardState) handleRemoveObjective(pk *packet.RemoveObjective) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objectives, pk.ObjectiveName)
+}
+
+// handleSetScoreboardIdentity changes the identity of the entries held in the SetScoreboardIdentity packet
+// passed in all objectives displayed. Registered entries are associated with the player with the entity unique
+// ID in the packet, whereas cleared entries become fake players again.
+func (s *scoreboardState) handleSetScoreboardIdentity(pk *packet.SetScoreboardIdentity) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, objective := range s.objectives {
+		for _, identity := range pk.Entries {
+			entry, ok := objective.Entries[identity.EntryID]
+			if !ok {
+				continue
+			}
+			switch pk.ActionType {
+			case packet.ScoreboardIdentityActionRegister:
+				entry.IdentityType, entry.EntityUniqueID = protocol.ScoreboardIdentityPlayer, identity.EntityUniqueID
+			case packet.ScoreboardIdentityActionClear:
+				entry.IdentityType, entry.EntityUniqueID = protocol.ScoreboardIdentityFakePlayer, 0
+			default:
+				continue
+			}
+			objective.Entries[identity.EntryID] = entry
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package minecraft
+
+import (
+	"errors"
+)
+
+// SendQueuePolicy specifies how a Conn behaves when a packet is written while its send queue is full. The send
+// queue holds packets written to the Conn until they are flushed.
+type SendQueuePolicy int
+
+const (
+	// SendQueuePolicyBlock makes writes to a Conn with a full send queue block until the queue is flushed.
+	SendQueuePolicyBlock SendQueuePolicy = iota
+	// SendQueuePolicyError makes writes to a Conn with a full send queue fail with ErrSendQueueFull.
+	SendQueuePolicyError
+)
+
+// ErrSendQueueFull is returned by Conn.WritePacket and Conn.Write if the send queue of the Conn is full and
+// SendQueuePolicyError is used. It is wrapped in a net.OpError and may be checked for using errors.Is.
+var ErrSendQueueFull = errors.New("send queue full")
+
+// reserveSendQueue makes sure n more packets may be added to the send queue of the Conn. If the queue is
+// bounded and full, reserveSendQueue either blocks until the queue is flushed or returns ErrSendQueueFull,
+// depending on the SendQueuePolicy of the Conn. A single write of more packets than the size of the queue is
+// allowed if the queue is empty.
+// conn.sendMu must be held when calling reserveSendQueue.
+func (conn *Conn) reserveSendQueue(n int, op string) error {
+	if conn.sendQueueSize <= 0 {
+		return nil
+	}
+	for len(conn.bufferedSend) != 0 && len(conn.bufferedSend)+n > conn.sendQueueSize {
+		if conn.sendQueuePolicy == SendQueuePolicyError {
+			return conn.wrap(ErrSendQueueFull, op)
+		}
+		select {
+		case <-conn.close:
+			return conn.closeErr(op)
+		default:
+		}
+		conn.sendCond.Wait()
+	}
+	return nil
+}
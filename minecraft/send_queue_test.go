@@ -0,0 +1,80 @@
+package minecraft
+
+import (
+	"errors"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// newSendQueueConn returns a Conn with a send queue of two packets using the SendQueuePolicy passed. Packets
+// are only flushed through explicit calls to Conn.Flush.
+func newSendQueueConn(t *testing.T, policy SendQueuePolicy) *Conn {
+	c, other := net.Pipe()
+	go func() {
+		_, _ = io.Copy(io.Discard, other)
+	}()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	conn.sendQueueSize, conn.sendQueuePolicy = 2, policy
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = other.Close()
+	})
+	return conn
+}
+
+func TestSendQueueError(t *testing.T) {
+	conn := newSendQueueConn(t, SendQueuePolicyError)
+	for i := 0; i < 2; i++ {
+		if err := conn.WritePacket(&packet.Text{}); err != nil {
+			t.Fatalf("write packet %v: %v", i, err)
+		}
+	}
+	if err := conn.WritePacket(&packet.Text{}); !errors.Is(err, ErrSendQueueFull) {
+		t.Fatalf("expected ErrSendQueueFull, got %v", err)
+	}
+	if depth := conn.Stats().SendQueueDepth; depth != 2 {
+		t.Fatalf("expected send queue depth 2, got %v", depth)
+	}
+	if err := conn.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if err := conn.WritePacket(&packet.Text{}); err != nil {
+		t.Fatalf("write packet after flush: %v", err)
+	}
+}
+
+func TestSendQueueBlock(t *testing.T) {
+	conn := newSendQueueConn(t, SendQueuePolicyBlock)
+	for i := 0; i < 2; i++ {
+		if err := conn.WritePacket(&packet.Text{}); err != nil {
+			t.Fatalf("write packet %v: %v", i, err)
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.WritePacket(&packet.Text{})
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("expected write packet to block, returned %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+	if err := conn.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("write packet after flush: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("write packet still blocked after flush")
+	}
+	if depth := conn.Stats().SendQueueDepth; depth != 1 {
+		t.Fatalf("expected send queue depth 1, got %v", depth)
+	}
+}
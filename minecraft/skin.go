@@ -0,0 +1,38 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// SetSkin changes the skin of the player of the Conn by sending a PlayerSkin packet with the skin passed. For
+// a Conn obtained using Dial, this requests the server to change the skin of the client. For a Conn obtained
+// from a Listener, it changes the skin of the player as seen by the client itself.
+// The Trusted field of the skin is only sent by Listener connections: Clients cannot mark their own skin as
+// trusted. OverrideAppearance is always set, as the client otherwise ignores the skin.
+// A login.ClientData may be converted to a protocol.Skin using its Skin method.
+func (conn *Conn) SetSkin(skin protocol.Skin) error {
+	id, err := uuid.Parse(conn.identityData.Identity)
+	if err != nil {
+		return conn.wrap(fmt.Errorf("parse identity UUID: %w", err), "set skin")
+	}
+	if err := skin.Validate(); err != nil {
+		return conn.wrap(fmt.Errorf("invalid skin: %w", err), "set skin")
+	}
+	if !conn.readerLimits {
+		// The Conn was obtained using Dial, so it is a client connection.
+		skin.Trusted = false
+	}
+	skin.OverrideAppearance = true
+	return conn.WritePacket(&packet.PlayerSkin{UUID: id, Skin: skin})
+}
+
+// PlayerSkin returns the skin of the player with the UUID passed in the player list sent by the server, kept
+// up to date with PlayerSkin packets. False is returned if the player is not in the player list or if the Conn
+// was not dialed with Dialer.TrackPlayers set.
+func (conn *Conn) PlayerSkin(id uuid.UUID) (protocol.Skin, bool) {
+	p, ok := conn.Player(id)
+	return p.Skin, ok
+}
@@ -0,0 +1,106 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"math"
+)
+
+// PlaySoundAt plays the sound with the name passed, such as 'random.levelup', at a position in the world of
+// the player of the Conn. The volume and pitch are multipliers, where 1 plays the sound as is. An error is
+// returned if the Conn was not obtained from a Listener, if the name is empty, if the position is not finite
+// or if the volume or pitch is negative.
+func (conn *Conn) PlaySoundAt(name string, pos mgl32.Vec3, volume, pitch float32) error {
+	if err := conn.listenerOnly("play sound"); err != nil {
+		return err
+	}
+	if name == "" {
+		return conn.wrap(fmt.Errorf("sound name must not be empty"), "play sound")
+	}
+	if err := validatePosition(pos); err != nil {
+		return conn.wrap(err, "play sound")
+	}
+	if volume < 0 || pitch < 0 {
+		return conn.wrap(fmt.Errorf("sound volume and pitch must not be negative, got %v and %v", volume, pitch), "play sound")
+	}
+	return conn.WritePacket(&packet.PlaySound{SoundName: name, Position: pos, Volume: volume, Pitch: pitch})
+}
+
+// PlaySoundEvent plays a sound hardcoded in the client at a position in the world of the player of the Conn.
+// The sound type is one of the packet.SoundEvent constants. Some sound types use extra data, such as the block
+// runtime ID for the SoundEventPlace sound. It should be -1 for sound types that do not use it. An error is
+// returned if the Conn was not obtained from a Listener or if the position is not finite.
+func (conn *Conn) PlaySoundEvent(soundType uint32, pos mgl32.Vec3, extraData int32) error {
+	if err := conn.listenerOnly("play sound event"); err != nil {
+		return err
+	}
+	if err := validatePosition(pos); err != nil {
+		return conn.wrap(err, "play sound event")
+	}
+	// Vanilla servers send an entity type of ':' for sounds that are not emitted by an entity.
+	return conn.WritePacket(&packet.LevelSoundEvent{SoundType: soundType, Position: pos, ExtraData: extraData, EntityType: ":"})
+}
+
+// SpawnParticle spawns particles hardcoded in the client at a position in the world of the player of the
+// Conn. The event type is one of the packet.LevelEventParticle constants, or a particle type combined with
+// packet.LevelEventParticleLegacyEvent. Some particles use data, such as the block runtime ID for the
+// LevelEventParticlesDestroyBlock particles. An error is returned if the Conn was not obtained from a
+// Listener, if the position is not finite or if the event type is not a particle event.
+func (conn *Conn) SpawnParticle(eventType int32, pos mgl32.Vec3, data int32) error {
+	if err := conn.listenerOnly("spawn particle"); err != nil {
+		return err
+	}
+	if err := validatePosition(pos); err != nil {
+		return conn.wrap(err, "spawn particle")
+	}
+	if !particleEvent(eventType) {
+		return conn.wrap(fmt.Errorf("level event %v is not a particle event", eventType), "spawn particle")
+	}
+	return conn.WritePacket(&packet.LevelEvent{EventType: eventType, Position: pos, EventData: data})
+}
+
+// SpawnParticleEffect spawns the particle effect with the name passed, such as 'minecraft:heart_particle', at
+// a position in the world of the player of the Conn. Unlike SpawnParticle, the particle may be one
+// implemented by a behaviour pack. An error is returned if the Conn was not obtained from a Listener, if the
+// name is empty or if the position is not finite.
+func (conn *Conn) SpawnParticleEffect(name string, pos mgl32.Vec3) error {
+	if err := conn.listenerOnly("spawn particle effect"); err != nil {
+		return err
+	}
+	if name == "" {
+		return conn.wrap(fmt.Errorf("particle name must not be empty"), "spawn particle effect")
+	}
+	if err := validatePosition(pos); err != nil {
+		return conn.wrap(err, "spawn particle effect")
+	}
+	return conn.WritePacket(&packet.SpawnParticleEffect{
+		Dimension:      byte(conn.gameData.Dimension),
+		EntityUniqueID: -1,
+		Position:       pos,
+		ParticleName:   name,
+	})
+}
+
+// particleEvent checks if the level event type passed spawns particles.
+func particleEvent(eventType int32) bool {
+	switch {
+	case eventType&packet.LevelEventParticleLegacyEvent != 0:
+		return true
+	case eventType >= packet.LevelEventParticlesShoot && eventType <= packet.LevelEventDustPlume:
+		return true
+	case eventType >= packet.LevelEventParticlesCrackBlockDown && eventType <= packet.LevelEventParticlesTrialSpawnerEjecting:
+		return true
+	}
+	return false
+}
+
+// validatePosition checks if all components of the position passed are finite.
+func validatePosition(pos mgl32.Vec3) error {
+	for _, v := range pos {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return fmt.Errorf("position %v is not finite", pos)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package minecraft
+
+// Stats holds statistics on a Conn, as returned by Conn.Stats.
+type Stats struct {
+	// SendQueueDepth is the amount of packets currently queued for sending, that is, the amount of packets
+	// written to the Conn that have not yet been flushed.
+	SendQueueDepth int
+	// SendQueueSize is the maximum amount of packets that may be queued for sending. It is 0 if the send queue
+	// is unbounded.
+	SendQueueSize int
+	// ReadQueueDepth is the amount of packets received that have not yet been read using Conn.ReadPacket or
+	// Conn.Read.
+	ReadQueueDepth int
+	// ReadQueueSize is the maximum amount of packets that may be held after being received. It is 0 if the
+	// read queue is unbounded.
+	ReadQueueSize int
+	// DroppedPackets is the total amount of packets dropped because the read queue was full, which only
+	// happens with ReadQueuePolicyDrop.
+	DroppedPackets uint64
+}
+
+// Stats returns statistics on the Conn.
+func (conn *Conn) Stats() Stats {
+	conn.deferredPacketMu.Lock()
+	readQueueDepth := len(conn.packets) + len(conn.deferredPackets)
+	conn.deferredPacketMu.Unlock()
+
+	conn.sendMu.Lock()
+	defer conn.sendMu.Unlock()
+	return Stats{
+		SendQueueDepth: len(conn.bufferedSend),
+		SendQueueSize:  conn.sendQueueSize,
+		ReadQueueDepth: readQueueDepth,
+		ReadQueueSize:  conn.readQueueSize,
+		DroppedPackets: conn.droppedPackets.Load(),
+	}
+}
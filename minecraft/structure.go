@@ -0,0 +1,195 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"sync"
+)
+
+// StructureTemplate is the data of a structure, as held by the StructureTemplateDataResponse packet. It has
+// the same layout as the .mcstructure files that structures are exported to.
+type StructureTemplate struct {
+	// FormatVersion is the version of the structure format, which is currently always 1.
+	FormatVersion int32 `nbt:"format_version"`
+	// Size is the size of the structure along the X, Y and Z axis.
+	Size []int32 `nbt:"size"`
+	// WorldOrigin is the position in the world that the structure was exported from.
+	WorldOrigin []int32 `nbt:"structure_world_origin"`
+	// Structure holds the blocks and entities in the structure.
+	Structure struct {
+		// BlockIndices holds two layers of blocks, the first holding normal blocks and the second holding
+		// liquids. Each layer holds an index into the block palette for every block in the structure, with
+		// the Z axis increasing first and the X axis increasing last. An index of -1 means there is no block.
+		BlockIndices [][]int32 `nbt:"block_indices"`
+		// Entities holds the NBT data of the entities in the structure.
+		Entities []map[string]any `nbt:"entities"`
+		// Palette holds the palettes of the structure keyed by their name, usually only 'default'.
+		Palette map[string]StructurePalette `nbt:"palette"`
+	} `nbt:"structure"`
+}
+
+// StructurePalette is a palette of a StructureTemplate that holds the block states referred to by the block
+// indices of the structure.
+type StructurePalette struct {
+	// BlockPalette holds the block states in the palette, each holding a 'name', 'states' and 'version' key.
+	BlockPalette []map[string]any `nbt:"block_palette"`
+	// BlockPositionData holds additional data of blocks, such as block entities, keyed by the index of the
+	// block in the structure.
+	BlockPositionData map[string]map[string]any `nbt:"block_position_data"`
+}
+
+// Block returns the block state in the palette of the structure at the index passed. False is returned if
+// the index is out of range.
+func (p StructurePalette) Block(index int32) (BlockState, bool) {
+	if index < 0 || int(index) >= len(p.BlockPalette) {
+		return BlockState{}, false
+	}
+	entry := p.BlockPalette[index]
+	name, _ := entry["name"].(string)
+	properties, _ := entry["states"].(map[string]any)
+	return BlockState{Name: name, Properties: properties}, true
+}
+
+// ParseStructureTemplate decodes the structure template held by the StructureTemplateDataResponse packet
+// passed. An error is returned if the structure was not found or if the structure template could not be
+// decoded.
+func ParseStructureTemplate(pk *packet.StructureTemplateDataResponse) (StructureTemplate, error) {
+	var t StructureTemplate
+	if !pk.Success {
+		return t, fmt.Errorf("structure %v not found", pk.StructureName)
+	}
+	data, err := nbt.Marshal(pk.StructureTemplate)
+	if err != nil {
+		return t, fmt.Errorf("encode structure template %v: %w", pk.StructureName, err)
+	}
+	if err := nbt.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("decode structure template %v: %w", pk.StructureName, err)
+	}
+	return t, nil
+}
+
+// structureRequests keeps track of structure template data requests sent by a Conn that have not yet received
+// a response from the server. The zero value is ready to use.
+type structureRequests struct {
+	mu sync.Mutex
+	// pending holds the channels of the requests sent for every structure name, in the order that the
+	// requests were sent. The server responds with the name of the structure requested, so responses are
+	// matched with requests by their name.
+	pending map[string][]chan *packet.StructureTemplateDataResponse
+	// closed is true once the Conn is closed, after which no responses will arrive anymore.
+	closed bool
+}
+
+// next returns a channel that receives the response to the next request for the structure with the name
+// passed. If the Conn is already closed, false is returned.
+func (r *structureRequests) next(name string) (chan *packet.StructureTemplateDataResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, false
+	}
+	if r.pending == nil {
+		r.pending = make(map[string][]chan *packet.StructureTemplateDataResponse)
+	}
+	c := make(chan *packet.StructureTemplateDataResponse, 1)
+	r.pending[name] = append(r.pending[name], c)
+	return c, true
+}
+
+// resolve passes the StructureTemplateDataResponse packet passed to the channel of the oldest request for the
+// structure with the same name. Responses to requests not sent through RequestStructure are ignored.
+func (r *structureRequests) resolve(pk *packet.StructureTemplateDataResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := r.pending[pk.StructureName]
+	if len(pending) == 0 {
+		return
+	}
+	c := pending[0]
+	if len(pending) == 1 {
+		delete(r.pending, pk.StructureName)
+	} else {
+		r.pending[pk.StructureName] = pending[1:]
+	}
+	c <- pk
+}
+
+// cancel stops tracking the request with the channel passed, for example if it could not be sent.
+func (r *structureRequests) cancel(name string, c chan *packet.StructureTemplateDataResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := r.pending[name]
+	for i, ch := range pending {
+		if ch == c {
+			pending = append(pending[:i:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(r.pending, name)
+		return
+	}
+	r.pending[name] = pending
+}
+
+// close closes the channels of all requests that have not yet received a response, as none will arrive after
+// the Conn is closed.
+func (r *structureRequests) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for name, pending := range r.pending {
+		for _, c := range pending {
+			close(c)
+		}
+		delete(r.pending, name)
+	}
+}
+
+// RequestStructure requests the data of the blocks and entities between the min and max positions passed
+// (inclusive) from the server, using a StructureTemplateDataRequest packet. The name is the name that the
+// structure is exported with. The channel returned receives the StructureTemplateDataResponse that the
+// server sends in response, of which the structure template may be decoded using ParseStructureTemplate.
+// Servers generally only respond to players that are operators in creative mode. If the Conn is closed before
+// the response arrives, the channel is closed without receiving a value, so that a receive on it returns ok as
+// false.
+// Responses are only matched with their requests when the StructureTemplateDataResponse packet holding them
+// is read using Conn.ReadPacket, so ReadPacket must be called continuously for the channel to ever receive a
+// value. An error is returned if the Conn was obtained from a Listener.
+func (conn *Conn) RequestStructure(name string, min, max protocol.BlockPos) (<-chan *packet.StructureTemplateDataResponse, error) {
+	if err := conn.dialerOnly("request structure"); err != nil {
+		return nil, err
+	}
+	for i := 0; i < 3; i++ {
+		if min[i] > max[i] {
+			min[i], max[i] = max[i], min[i]
+		}
+	}
+	c, ok := conn.structures.next(name)
+	if !ok {
+		return nil, conn.closeErr("request structure")
+	}
+	err := conn.WritePacket(&packet.StructureTemplateDataRequest{
+		StructureName: name,
+		// The structure is exported as if a structure block was placed at min, with a zero offset.
+		Position: min,
+		Settings: protocol.StructureSettings{
+			PaletteName:               "default",
+			Size:                      protocol.BlockPos{max[0] - min[0] + 1, max[1] - min[1] + 1, max[2] - min[2] + 1},
+			LastEditingPlayerUniqueID: conn.gameData.EntityUniqueID,
+			Integrity:                 1,
+		},
+		RequestType: packet.StructureTemplateRequestExportFromSave,
+	})
+	if err != nil {
+		conn.structures.cancel(name, c)
+		return nil, err
+	}
+	return c, nil
+}
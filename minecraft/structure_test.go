@@ -0,0 +1,63 @@
+package minecraft
+
+import (
+	"errors"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRequestStructure tests that the response to a structure request is passed to the channel returned by
+// RequestStructure, and that the channel of a request without response is closed when the Conn is closed.
+func TestRequestStructure(t *testing.T) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	defer other.Close()
+	go func() {
+		_, _ = io.Copy(io.Discard, other)
+	}()
+
+	conn.readerLimits = true
+	if _, err := conn.RequestStructure("house", protocol.BlockPos{}, protocol.BlockPos{}); !errors.Is(err, errNotDialer) {
+		t.Fatalf("expected requesting a structure from a Listener Conn to fail, got %v", err)
+	}
+	conn.readerLimits = false
+
+	resolved, err := conn.RequestStructure("house", protocol.BlockPos{}, protocol.BlockPos{1, 1, 1})
+	if err != nil {
+		t.Fatalf("error requesting structure: %v", err)
+	}
+	pending, err := conn.RequestStructure("house", protocol.BlockPos{}, protocol.BlockPos{1, 1, 1})
+	if err != nil {
+		t.Fatalf("error requesting structure: %v", err)
+	}
+	conn.observePacket(&packet.StructureTemplateDataResponse{StructureName: "house", Success: true})
+	select {
+	case resp := <-resolved:
+		if resp.StructureName != "house" || !resp.Success {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected response to be passed to the channel of the oldest request")
+	}
+
+	_ = conn.Close()
+	select {
+	case _, ok := <-pending:
+		if ok {
+			t.Fatalf("expected no response for the pending request")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected channel of pending request to be closed when the Conn is closed")
+	}
+	if len(conn.structures.pending) != 0 {
+		t.Fatalf("expected no pending requests after closing, got %v", len(conn.structures.pending))
+	}
+	if _, err := conn.RequestStructure("house", protocol.BlockPos{}, protocol.BlockPos{}); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed requesting a structure after closing, got %v", err)
+	}
+}
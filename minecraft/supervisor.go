@@ -0,0 +1,153 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"time"
+)
+
+// Supervisor keeps a connection to a server alive by dialing it again with exponential backoff every time
+// the connection is lost. It is typically used by bots that must stay online for a long time. A zero
+// Supervisor is not usable: At least Network and Address must be set.
+type Supervisor struct {
+	// Dialer is the Dialer used to dial the server. If its TokenSource is set, the tokens it returns are
+	// reused for every connection until they expire, so that reconnecting does not require authenticating
+	// again.
+	Dialer Dialer
+	// Network and Address are the network and address passed to Dialer.DialContext. The network is
+	// typically "raknet".
+	Network, Address string
+
+	// MinBackoff is the time waited before dialing again after the first failed attempt. The time waited is
+	// doubled for every consecutive failed attempt, until it reaches MaxBackoff. If MinBackoff is 0, a
+	// backoff of one second is used. If MaxBackoff is 0, a maximum of one minute is used.
+	MinBackoff, MaxBackoff time.Duration
+	// MaxAttempts is the maximum amount of consecutive failed attempts to establish a connection, after which
+	// Supervisor.Run returns. The count is reset every time a connection is established. If MaxAttempts is 0
+	// or lower, the Supervisor keeps dialing indefinitely.
+	MaxAttempts int
+
+	// Fatal is called with every error that a dial attempt failed with or that a connection was closed with.
+	// If it returns true, the Supervisor stops reconnecting and Supervisor.Run returns the error. If Fatal is
+	// nil, a LoginFailedError, HandshakeError or MovementModeError, after which dialing again is unlikely to
+	// succeed, is considered fatal, as well as a DisconnectError of which the DisconnectCategory returned by
+	// Classify reports that reconnecting is not likely to succeed, such as a ban.
+	Fatal func(err error) bool
+	// Classify is called by the default Fatal func to classify the message of a DisconnectError. If nil,
+	// ClassifyDisconnect is used. Classify may be set to recognise disconnect messages specific to a server.
+	Classify func(message string) DisconnectCategory
+
+	// OnConnect, if not nil, is called every time a connection is established, before it is handled.
+	OnConnect func(conn *Conn)
+	// OnDisconnect, if not nil, is called every time an established connection is lost, with the error that
+	// the connection was closed with.
+	OnDisconnect func(err error)
+}
+
+// Run dials the server and keeps dialing it again every time the connection is lost, until the context
+// passed is done, Supervisor.Fatal reports an error as fatal or Supervisor.MaxAttempts consecutive attempts
+// to dial failed. Every established connection is passed to handle, which should read packets from the
+// connection until it returns an error, and return that error. The connection is closed once handle returns.
+// If handle is nil, packets are read from the connection and discarded.
+// Run always returns a non-nil error, which is the error of the context if it is done.
+func (s Supervisor) Run(ctx context.Context, handle func(conn *Conn) error) error {
+	if handle == nil {
+		handle = discardPackets
+	}
+	if s.Dialer.TokenSource != nil {
+		s.Dialer.TokenSource = oauth2.ReuseTokenSource(nil, s.Dialer.TokenSource)
+	}
+	attempts := 0
+	for {
+		conn, err := s.Dialer.DialContext(ctx, s.Network, s.Address)
+		if err == nil {
+			attempts = 0
+			err = s.handle(conn, handle)
+		} else {
+			attempts++
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if s.fatal(err) {
+			return err
+		}
+		if s.MaxAttempts > 0 && attempts >= s.MaxAttempts {
+			return fmt.Errorf("supervisor: %v consecutive attempts to dial %v failed: %w", attempts, s.Address, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.backoff(attempts)):
+		}
+	}
+}
+
+// handle calls OnConnect for the Conn passed, handles it until the handle function returns and closes it,
+// after which OnDisconnect is called. The error that the connection was closed with is returned.
+func (s Supervisor) handle(conn *Conn, handle func(conn *Conn) error) error {
+	if s.OnConnect != nil {
+		s.OnConnect(conn)
+	}
+	err := handle(conn)
+	_ = conn.Close()
+	if err == nil {
+		err = conn.closeErr("read packet")
+	}
+	if s.OnDisconnect != nil {
+		s.OnDisconnect(err)
+	}
+	return err
+}
+
+// fatal checks if the error passed should stop the Supervisor from reconnecting.
+func (s Supervisor) fatal(err error) bool {
+	if s.Fatal != nil {
+		return s.Fatal(err)
+	}
+	var (
+		loginErr      LoginFailedError
+		handshakeErr  HandshakeError
+		movementErr   MovementModeError
+		disconnectErr DisconnectError
+	)
+	if errors.As(err, &loginErr) || errors.As(err, &handshakeErr) || errors.As(err, &movementErr) {
+		return true
+	}
+	if errors.As(err, &disconnectErr) {
+		classify := s.Classify
+		if classify == nil {
+			classify = ClassifyDisconnect
+		}
+		return !classify(string(disconnectErr)).Reconnect()
+	}
+	return false
+}
+
+// backoff returns the time to wait before dialing again after the amount of consecutive failed attempts
+// passed.
+func (s Supervisor) backoff(attempts int) time.Duration {
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = time.Second
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+	backoff := minBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// discardPackets reads packets from the Conn passed and discards them until an error is returned.
+func discardPackets(conn *Conn) error {
+	for {
+		if _, err := conn.ReadPacket(); err != nil {
+			return err
+		}
+	}
+}
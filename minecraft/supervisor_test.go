@@ -0,0 +1,97 @@
+package minecraft_test
+
+import (
+	"context"
+	"errors"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"testing"
+	"time"
+)
+
+// TestSupervisorReconnect tests that a Supervisor dials the server again after being disconnected, and that it
+// stops once Supervisor.Fatal reports an error as fatal.
+func TestSupervisorReconnect(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	go func() {
+		for _, msg := range []string{"restarting", "banned"} {
+			conn, err := minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{})
+			if err != nil {
+				return
+			}
+			_ = l.Disconnect(conn, msg)
+		}
+	}()
+
+	var connects, disconnects int
+	s := minecraft.Supervisor{
+		Network:    "raknet",
+		Address:    l.Addr().String(),
+		MinBackoff: time.Millisecond * 10,
+		Fatal: func(err error) bool {
+			var disc minecraft.DisconnectError
+			return errors.As(err, &disc) && disc == "banned"
+		},
+		OnConnect:    func(*minecraft.Conn) { connects++ },
+		OnDisconnect: func(error) { disconnects++ },
+	}
+	err = s.Run(ctx, nil)
+	var disc minecraft.DisconnectError
+	if !errors.As(err, &disc) || disc != "banned" {
+		t.Fatalf("expected Run to return the ban, got %v", err)
+	}
+	if connects != 2 || disconnects != 2 {
+		t.Fatalf("expected 2 connects and disconnects, got %v and %v", connects, disconnects)
+	}
+}
+
+// TestSupervisorDefaultFatal tests that a Supervisor without a Fatal func reconnects after a server restart,
+// but stops once banned, and that the DisconnectReason of the connection holds the disconnect message.
+func TestSupervisorDefaultFatal(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	messages := []string{"Server restarting", "You are banned from this server"}
+	go func() {
+		for _, msg := range messages {
+			conn, err := minecraft.AcceptEmptyWorld(ctx, l, minecraft.GameData{})
+			if err != nil {
+				return
+			}
+			_ = l.Disconnect(conn, msg)
+		}
+	}()
+
+	var reasons []string
+	s := minecraft.Supervisor{Network: "raknet", Address: l.Addr().String(), MinBackoff: time.Millisecond * 10}
+	err = s.Run(ctx, func(conn *minecraft.Conn) error {
+		for {
+			if _, err := conn.ReadPacket(); err != nil {
+				if _, message, ok := conn.DisconnectReason(); ok {
+					reasons = append(reasons, message)
+				}
+				return err
+			}
+		}
+	})
+	var disc minecraft.DisconnectError
+	if !errors.As(err, &disc) || disc.Category() != minecraft.DisconnectCategoryBanned {
+		t.Fatalf("expected Run to return the ban, got %v", err)
+	}
+	if len(reasons) != 2 || reasons[0] != messages[0] || reasons[1] != messages[1] {
+		t.Fatalf("expected disconnect reasons %v, got %v", messages, reasons)
+	}
+}
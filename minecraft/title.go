@@ -0,0 +1,92 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"time"
+)
+
+// Title is a title shown in the centre of the screen of a player, optionally with a subtitle below it, as
+// sent using Conn.SendTitle.
+type Title struct {
+	// Text is the text of the title.
+	Text string
+	// Subtitle is the text shown below the title. It is not shown if left empty.
+	Subtitle string
+	// FadeIn, Duration and FadeOut are the durations that the title takes to fade in, remains on screen and
+	// takes to fade out. They are rounded down to ticks (20ths of a second). If all three are 0, the client
+	// uses the durations last set or its defaults.
+	FadeIn, Duration, FadeOut time.Duration
+}
+
+// SendTitle shows the Title passed to the player of the Conn. An error is returned if the Conn was not
+// obtained from a Listener or if any of the durations of the title are negative.
+func (conn *Conn) SendTitle(t Title) error {
+	if err := conn.listenerOnly("send title"); err != nil {
+		return err
+	}
+	if t.FadeIn < 0 || t.Duration < 0 || t.FadeOut < 0 {
+		return conn.wrap(fmt.Errorf("title durations must not be negative"), "send title")
+	}
+	pks := make([]packet.Packet, 0, 3)
+	if t.FadeIn != 0 || t.Duration != 0 || t.FadeOut != 0 {
+		pks = append(pks, &packet.SetTitle{
+			ActionType:      packet.TitleActionSetDurations,
+			FadeInDuration:  durationTicks(t.FadeIn),
+			RemainDuration:  durationTicks(t.Duration),
+			FadeOutDuration: durationTicks(t.FadeOut),
+		})
+	}
+	if t.Subtitle != "" {
+		pks = append(pks, &packet.SetTitle{ActionType: packet.TitleActionSetSubtitle, Text: t.Subtitle})
+	}
+	// The title is shown as soon as its text is set, so it must be sent after the durations and subtitle.
+	pks = append(pks, &packet.SetTitle{ActionType: packet.TitleActionSetTitle, Text: t.Text})
+	for _, pk := range pks {
+		if err := conn.WritePacket(pk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendActionBar shows the text passed in the action bar of the player of the Conn, just above the hotbar. An
+// error is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) SendActionBar(text string) error {
+	return conn.sendTitleAction("send action bar", packet.TitleActionSetActionBar, text)
+}
+
+// ClearTitle removes the title and subtitle currently shown to the player of the Conn. Durations set using
+// SendTitle remain in use. An error is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) ClearTitle() error {
+	return conn.sendTitleAction("clear title", packet.TitleActionClear, "")
+}
+
+// ResetTitle removes the title and subtitle currently shown to the player of the Conn and resets the durations
+// of titles to their defaults. An error is returned if the Conn was not obtained from a Listener.
+func (conn *Conn) ResetTitle() error {
+	return conn.sendTitleAction("reset title", packet.TitleActionReset, "")
+}
+
+// SendToast shows a toast with the title and message passed at the top of the screen of the player of the
+// Conn, like the ones shown when obtaining an achievement. An error is returned if the Conn was not obtained
+// from a Listener.
+func (conn *Conn) SendToast(title, message string) error {
+	if err := conn.listenerOnly("send toast"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.ToastRequest{Title: title, Message: message})
+}
+
+// sendTitleAction sends a SetTitle packet with the action type and text passed.
+func (conn *Conn) sendTitleAction(op string, action int32, text string) error {
+	if err := conn.listenerOnly(op); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.SetTitle{ActionType: action, Text: text})
+}
+
+// durationTicks converts the time.Duration passed to a number of ticks, rounding down.
+func durationTicks(d time.Duration) int32 {
+	return int32(d / (time.Second / 20))
+}
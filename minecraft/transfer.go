@@ -0,0 +1,65 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// TransferRequest is a request by a server to transfer the client to another server, as sent in a Transfer
+// packet. Vanilla clients always follow the request, but a Conn leaves the decision to the caller: The
+// transfer may be followed using Dialer.FollowTransfer.
+type TransferRequest struct {
+	// Address is the address of the server to transfer to, which might be either a hostname or an IP address.
+	Address string
+	// Port is the port of the server to transfer to.
+	Port uint16
+}
+
+// String returns the address of the server to transfer to in a form that may be passed to Dialer.Dial, such
+// as "127.0.0.1:19132".
+func (t TransferRequest) String() string {
+	return net.JoinHostPort(t.Address, strconv.Itoa(int(t.Port)))
+}
+
+// transferState holds the last TransferRequest received by a Conn.
+type transferState struct {
+	mu       sync.Mutex
+	request  TransferRequest
+	received bool
+}
+
+// Transfer returns the last TransferRequest sent by the server in a Transfer packet. False is returned if the
+// server has not sent a Transfer packet.
+func (conn *Conn) Transfer() (TransferRequest, bool) {
+	conn.transfer.mu.Lock()
+	defer conn.transfer.mu.Unlock()
+	return conn.transfer.request, conn.transfer.received
+}
+
+// handleTransfer stores the TransferRequest held in the Transfer packet passed.
+func (conn *Conn) handleTransfer(pk *packet.Transfer) {
+	conn.transfer.mu.Lock()
+	defer conn.transfer.mu.Unlock()
+	conn.transfer.request, conn.transfer.received = TransferRequest{Address: pk.Address, Port: pk.Port}, true
+}
+
+// FollowTransfer follows the TransferRequest sent to the Conn passed, which must have been obtained using
+// Dial, by closing it and dialing the server that it was transferred to. The Minecraft auth chain that conn
+// logged in with is reused, so that authentication does not have to be repeated. If conn did not use
+// authentication, the Dialer logs in as it would in DialContext.
+// An error is returned if the server has not sent a Transfer packet to conn.
+func (d Dialer) FollowTransfer(ctx context.Context, network string, conn *Conn) (*Conn, error) {
+	t, ok := conn.Transfer()
+	if !ok {
+		return nil, &net.OpError{Op: "follow transfer", Net: "minecraft", Err: fmt.Errorf("no transfer received")}
+	}
+	_ = conn.Close()
+	if conn.chainData == "" {
+		return d.DialContext(ctx, network, t.String())
+	}
+	return d.dial(ctx, network, t.String(), conn.privateKey, conn.chainData)
+}
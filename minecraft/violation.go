@@ -0,0 +1,38 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// handlePacketViolationWarning handles a PacketViolationWarning packet sent by the client, which it sends when
+// it receives a packet it could not handle. The warning is logged and passed to the
+// ListenConfig.PacketViolationFunc, if set.
+func (conn *Conn) handlePacketViolationWarning(pk *packet.PacketViolationWarning) {
+	conn.log.Warn("packet violation warning", "id", pk.PacketID, "type", violationType(pk.Type), "severity", violationSeverity(pk.Severity), "context", pk.ViolationContext)
+	if conn.violationFunc != nil {
+		conn.violationFunc(pk)
+	}
+}
+
+// violationType returns a readable name for the type of a PacketViolationWarning.
+func violationType(t int32) string {
+	switch t {
+	case packet.ViolationTypeMalformed:
+		return "malformed"
+	}
+	return fmt.Sprintf("unknown (%v)", t)
+}
+
+// violationSeverity returns a readable name for the severity of a PacketViolationWarning.
+func violationSeverity(severity int32) string {
+	switch severity {
+	case packet.ViolationSeverityWarning:
+		return "warning"
+	case packet.ViolationSeverityFinalWarning:
+		return "final warning"
+	case packet.ViolationSeverityTerminatingConnection:
+		return "terminating connection"
+	}
+	return fmt.Sprintf("unknown (%v)", severity)
+}
@@ -0,0 +1,109 @@
+package minecraft
+
+import (
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SetTime sets the time of the world of the player of the Conn to the amount of ticks passed, where a full day
+// takes 24000 ticks. If the 'doDaylightCycle' game rule is enabled, which it is by default, the client keeps
+// advancing the time by itself after receiving it. An error is returned if the Conn was not obtained from a
+// Listener.
+func (conn *Conn) SetTime(ticks int) error {
+	if err := conn.listenerOnly("set time"); err != nil {
+		return err
+	}
+	return conn.WritePacket(&packet.SetTime{Time: int32(ticks)})
+}
+
+// SetWeather starts or stops rain and thunder in the world of the player of the Conn. The intensity, ranging
+// from 0 to 1, specifies how heavily it rains or thunders and is ignored when stopping. An error is returned
+// if the Conn was not obtained from a Listener or if the intensity is out of bounds.
+func (conn *Conn) SetWeather(rain, thunder bool, intensity float32) error {
+	if err := conn.listenerOnly("set weather"); err != nil {
+		return err
+	}
+	if intensity < 0 || intensity > 1 {
+		return conn.wrap(fmt.Errorf("weather intensity must be between 0 and 1, got %v", intensity), "set weather")
+	}
+	// The client expects the intensity of the weather to be scaled to the range of a uint16.
+	data := int32(intensity * 65535)
+
+	rainPk := &packet.LevelEvent{EventType: packet.LevelEventStopRaining}
+	if rain {
+		rainPk = &packet.LevelEvent{EventType: packet.LevelEventStartRaining, EventData: data}
+	}
+	thunderPk := &packet.LevelEvent{EventType: packet.LevelEventStopThunderstorm}
+	if thunder {
+		thunderPk = &packet.LevelEvent{EventType: packet.LevelEventStartThunderstorm, EventData: data}
+	}
+	if err := conn.WritePacket(rainPk); err != nil {
+		return err
+	}
+	return conn.WritePacket(thunderPk)
+}
+
+// worldTimeState holds the time of the world last sent by the server and whether the client advances it by
+// itself.
+type worldTimeState struct {
+	mu            sync.Mutex
+	ticks         int64
+	at            time.Time
+	daylightCycle bool
+}
+
+// reset sets the time of the world to the ticks passed at the current time and updates whether the time
+// advances using the game rules passed.
+func (s *worldTimeState) reset(ticks int64, gameRules []protocol.GameRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ticks, s.at, s.daylightCycle = ticks, time.Now(), true
+	s.updateGameRules(gameRules)
+}
+
+// current returns the current time of the world in ticks. s.mu must be held when calling current.
+func (s *worldTimeState) current() int64 {
+	if !s.daylightCycle || s.at.IsZero() {
+		return s.ticks
+	}
+	return s.ticks + int64(time.Since(s.at)/(time.Second/20))
+}
+
+// updateGameRules updates whether the time advances using the 'doDaylightCycle' game rule, if present in the
+// game rules passed. s.mu must be held when calling updateGameRules.
+func (s *worldTimeState) updateGameRules(gameRules []protocol.GameRule) {
+	for _, rule := range gameRules {
+		if enabled, ok := rule.Value.(bool); ok && strings.EqualFold(rule.Name, "doDaylightCycle") {
+			// The time advanced so far must be kept when the cycle is stopped or started.
+			s.ticks, s.at, s.daylightCycle = s.current(), time.Now(), enabled
+		}
+	}
+}
+
+// WorldTime returns the current time of the world in ticks, as last sent by the server in the StartGame or a
+// SetTime packet. Like the client, the time is advanced by 20 ticks per second for as long as the
+// 'doDaylightCycle' game rule is enabled.
+func (conn *Conn) WorldTime() int64 {
+	conn.worldTime.mu.Lock()
+	defer conn.worldTime.mu.Unlock()
+	return conn.worldTime.current()
+}
+
+// handleSetTime updates the time of the world to the one held in the SetTime packet passed.
+func (s *worldTimeState) handleSetTime(pk *packet.SetTime) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ticks, s.at = int64(pk.Time), time.Now()
+}
+
+// handleGameRulesChanged updates whether the time of the world advances using the game rules in the
+// GameRulesChanged packet passed.
+func (s *worldTimeState) handleGameRulesChanged(pk *packet.GameRulesChanged) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateGameRules(pk.GameRules)
+}
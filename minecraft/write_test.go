@@ -0,0 +1,264 @@
+package minecraft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWritePacketConcurrent tests that packets written from many goroutines simultaneously all arrive intact
+// at the other end of the connection. It is most useful when run with the race detector enabled.
+func TestWritePacketConcurrent(t *testing.T) {
+	const goroutines, packets = 16, 200
+
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	// A small send queue makes writers wait for flushes, during which other writers may acquire the Conn.
+	conn.sendQueueSize, conn.sendQueuePolicy = 8, SendQueuePolicyBlock
+	var headerMu sync.Mutex
+	headers := map[uint32]int{}
+	conn.packetFunc = func(header packet.Header, _ []byte, _, _ net.Addr) {
+		headerMu.Lock()
+		defer headerMu.Unlock()
+		headers[header.PacketID]++
+	}
+	defer func() {
+		_ = conn.Close()
+		_ = other.Close()
+	}()
+
+	received := make(chan map[string]bool, 1)
+	go func() {
+		seen := map[string]bool{}
+		defer func() {
+			received <- seen
+		}()
+		dec := packet.NewDecoder(other)
+		for len(seen) < goroutines*packets {
+			batch, err := dec.Decode()
+			if err != nil {
+				t.Errorf("decode batch: %v", err)
+				return
+			}
+			for _, data := range batch {
+				buf := bytes.NewBuffer(data)
+				var header packet.Header
+				if err := header.Read(buf); err != nil {
+					t.Errorf("read header: %v", err)
+					return
+				}
+				var pk packet.Packet = &packet.Text{}
+				if header.PacketID == packet.IDSetTitle {
+					pk = &packet.SetTitle{}
+				} else if header.PacketID != packet.IDText {
+					t.Errorf("unexpected packet ID %v", header.PacketID)
+					return
+				}
+				pk.Marshal(protocol.NewReader(buf, 0, false))
+				if buf.Len() != 0 {
+					t.Errorf("%v leftover bytes after %T", buf.Len(), pk)
+					return
+				}
+				msg := ""
+				switch pk := pk.(type) {
+				case *packet.Text:
+					msg = pk.Message
+				case *packet.SetTitle:
+					msg = pk.Text
+				}
+				if seen[msg] {
+					t.Errorf("packet %q received twice", msg)
+					return
+				}
+				seen[msg] = true
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < packets; i++ {
+				var pk packet.Packet = &packet.Text{TextType: packet.TextTypeRaw, Message: fmt.Sprintf("%v-%v", g, i)}
+				if g%2 == 1 {
+					pk = &packet.SetTitle{ActionType: packet.TitleActionSetTitle, Text: fmt.Sprintf("%v-%v", g, i)}
+				}
+				if err := conn.WritePacket(pk); err != nil {
+					t.Errorf("write packet: %v", err)
+					return
+				}
+			}
+		}(g)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for flushing := true; flushing; {
+		select {
+		case <-done:
+			flushing = false
+		case <-ticker.C:
+		}
+		if err := conn.Flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+	}
+
+	if seen := <-received; len(seen) != goroutines*packets {
+		t.Fatalf("expected %v packets, got %v", goroutines*packets, len(seen))
+	}
+	if headers[packet.IDText] != goroutines/2*packets || headers[packet.IDSetTitle] != goroutines/2*packets {
+		t.Fatalf("expected %v headers of each packet, got %v", goroutines/2*packets, headers)
+	}
+}
+
+// TestWriteRaw tests that a packet encoded using EncodePacket and written using WriteRaw arrives at the other
+// end of the connection exactly like one written using WritePacket.
+func TestWriteRaw(t *testing.T) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	defer func() {
+		_ = conn.Close()
+		_ = other.Close()
+	}()
+
+	pk := &packet.Text{TextType: packet.TextTypeRaw, Message: "raw"}
+	if err := conn.WritePacket(pk); err != nil {
+		t.Fatalf("write packet: %v", err)
+	}
+	if err := conn.WriteRaw(pk.ID(), EncodePacket(pk, 0)); err != nil {
+		t.Fatalf("write raw: %v", err)
+	}
+	go func() {
+		_ = conn.Flush()
+	}()
+	batch, err := packet.NewDecoder(other).Decode()
+	if err != nil {
+		t.Fatalf("decode batch: %v", err)
+	}
+	if len(batch) != 2 {
+		t.Fatalf("expected 2 packets, got %v", len(batch))
+	}
+	if !bytes.Equal(batch[0], batch[1]) {
+		t.Fatalf("raw packet %x differs from encoded packet %x", batch[1], batch[0])
+	}
+}
+
+// TestBroadcast tests that Broadcast writes a packet to every connection passed and reports the errors of
+// connections that could not be written to separately.
+func TestBroadcast(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	conns, others := make([]*Conn, 3), make([]net.Conn, 3)
+	for i := range conns {
+		var c net.Conn
+		c, others[i] = net.Pipe()
+		conns[i] = newConn(c, nil, log, DefaultProtocol, -1, 0, 0, false)
+		defer func(i int) {
+			_ = conns[i].Close()
+			_ = others[i].Close()
+		}(i)
+	}
+	_ = conns[1].Close()
+
+	pk := &packet.Text{TextType: packet.TextTypeRaw, Message: "broadcast"}
+	errs := Broadcast(conns, pk)
+	if len(errs) != len(conns) {
+		t.Fatalf("expected %v errors, got %v", len(conns), len(errs))
+	}
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Fatalf("expected only the closed connection to fail, got %v", errs)
+	}
+	for _, i := range []int{0, 2} {
+		go func(conn *Conn) {
+			_ = conn.Flush()
+		}(conns[i])
+		batch, err := packet.NewDecoder(others[i]).Decode()
+		if err != nil {
+			t.Fatalf("decode batch of connection %v: %v", i, err)
+		}
+		if len(batch) != 1 {
+			t.Fatalf("expected 1 packet on connection %v, got %v", i, len(batch))
+		}
+	}
+}
+
+// TestWriteClosed tests that writing to a Conn after closing it, or while it is being closed, returns
+// ErrConnClosed rather than panicking or blocking.
+func TestWriteClosed(t *testing.T) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	conn.sendQueueSize, conn.sendQueuePolicy = 1, SendQueuePolicyBlock
+	go func() {
+		_, _ = io.Copy(io.Discard, other)
+	}()
+	defer other.Close()
+
+	pk := &packet.Text{TextType: packet.TextTypeRaw, Message: "closed"}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				// Writes block on the full send queue until the Conn is closed.
+				if err := conn.WritePacket(pk); err != nil {
+					if !errors.Is(err, ErrConnClosed) {
+						t.Errorf("expected ErrConnClosed from concurrent write, got %v", err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	if err := conn.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	wg.Wait()
+
+	if err := conn.WritePacket(pk); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed from WritePacket, got %v", err)
+	}
+	if _, err := conn.Write([]byte{0x09}); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed from Write, got %v", err)
+	}
+	if err := conn.WriteRaw(pk.ID(), EncodePacket(pk, 0)); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed from WriteRaw, got %v", err)
+	}
+	if err := conn.Flush(); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed from Flush, got %v", err)
+	}
+	if _, err := conn.ReadPacket(); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed from ReadPacket, got %v", err)
+	}
+}
+
+// TestFlushBrokenConn tests that flushing a Conn of which the underlying connection was closed by the other
+// end returns an error instead of panicking.
+func TestFlushBrokenConn(t *testing.T) {
+	c, other := net.Pipe()
+	conn := newConn(c, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DefaultProtocol, -1, 0, 0, false)
+	_ = other.Close()
+
+	if err := conn.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "broken"}); err != nil {
+		t.Fatalf("write packet: %v", err)
+	}
+	if err := conn.Flush(); err == nil {
+		t.Fatal("expected an error flushing to a broken connection")
+	}
+	_ = conn.Close()
+}